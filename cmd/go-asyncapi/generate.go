@@ -2,11 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -33,6 +35,7 @@ import (
 	"github.com/xcnt/go-asyncapi/internal/asyncapi"
 	"github.com/xcnt/go-asyncapi/internal/common"
 	"github.com/xcnt/go-asyncapi/internal/compiler"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
 	"github.com/xcnt/go-asyncapi/internal/linker"
 	"github.com/xcnt/go-asyncapi/internal/writer"
 	"github.com/samber/lo"
@@ -58,7 +61,15 @@ type generatePubSubArgs struct {
 	FileScope     string `arg:"--file-scope" default:"name" help:"How to split up the generated code on files inside packages. Possible values: name, type" placeholder:"SCOPE"`
 	generateObjectSelectionOpts
 	ImplementationsOpts
-	AllowRemoteRefs bool `arg:"--allow-remote-refs" help:"Allow fetching spec files from remote $ref URLs"`
+	AllowRemoteRefs  bool              `arg:"--allow-remote-refs" help:"Allow fetching spec files from remote $ref URLs"`
+	FormatTypes      map[string]string `arg:"--format-type,separate" help:"Go type to use for the schema format, overrides the default one. Can be repeated. E.g. uuid=github.com/google/uuid.UUID, date-time=string" placeholder:"FORMAT=TYPE"`
+	Encoders         map[string]string `arg:"--encoder,separate" help:"Encoder constructor for the content type or suffix pattern, overrides the default one. Can be repeated. E.g. application/cbor=github.com/fxamacker/cbor/v2.NewEncoder" placeholder:"CONTENT_TYPE=FUNC"`
+	Decoders         map[string]string `arg:"--decoder,separate" help:"Decoder constructor for the content type or suffix pattern, overrides the default one. Can be repeated. E.g. *+cbor=github.com/fxamacker/cbor/v2.NewDecoder" placeholder:"CONTENT_TYPE=FUNC"`
+	SourceSnippets   bool              `arg:"--source-snippets" help:"Show the spec source snippet pointing to the place an error occurred"`
+	ValidateMessages bool              `arg:"--validate-messages" help:"Validate messages against the schema constraints in Marshal/Unmarshal envelope methods"`
+
+	DiagnosticsFormat string `arg:"--diagnostics-format" help:"Write all warnings and errors to stdout in machine-readable format. Possible values: json, sarif" placeholder:"FORMAT"`
+	Strict            bool   `arg:"--strict" help:"Treat warnings as errors, i.e. exit with non-zero code if any warning occurred"`
 
 	RuntimeModule         string        `arg:"--runtime-module" default:"github.com/xcnt/go-asyncapi/run" help:"Runtime module name" placeholder:"MODULE"`
 	FileResolverSearchDir string        `arg:"--file-resolver-search-dir" help:"Directory to search the local spec files for [default: current working directory]" placeholder:"PATH"`
@@ -108,11 +119,16 @@ type generateObjectSelectionOpts struct {
 	IgnoreServersRe    string `arg:"--ignore-servers-re" help:"Ignore servers whose name in document matches the regex" placeholder:"REGEX"`
 	ReuseServersModule string `arg:"--reuse-servers-module" help:"Reuse the module with servers code" placeholder:"MODULE"`
 
-	NoImplementations bool `arg:"--no-implementations" help:"Do not generate any protocol implementation"`
-	NoEncoding        bool `arg:"--no-encoding" help:"Do not generate encoders/decoders code"`
+	NoImplementations bool   `arg:"--no-implementations" help:"Do not generate any protocol implementation"`
+	NoEncoding        bool   `arg:"--no-encoding" help:"Do not generate encoders/decoders code"`
+	EnumAllowUnknown  bool   `arg:"--enum-allow-unknown" help:"Do not reject the values not in enum on marshal and unmarshal"`
+	OptionalFields    string `arg:"--optional-fields" default:"pointer" help:"How to generate the fields of optional properties. Possible values: pointer, omitempty" placeholder:"MODE"`
+	NullableFields    string `arg:"--nullable-fields" default:"pointer" help:"How to generate the nullable types. Possible values: pointer, optional (run.Optional[T])" placeholder:"MODE"`
+	CloudEvents       string `arg:"--cloudevents" help:"Map the messages to CloudEvents in a given content mode. Possible values: structured, binary" placeholder:"MODE"`
+	CloudEventsSource string `arg:"--cloudevents-source" help:"Default CloudEvents source attribute of messages, e.g. /orders-service. Required along with --cloudevents" placeholder:"URI"`
 }
 
-func generate(cmd *GenerateCmd) error {
+func generate(cmd *GenerateCmd) (err error) {
 	if cmd.Implementation != nil {
 		return generateImplementation(cmd)
 	}
@@ -123,6 +139,15 @@ func generate(cmd *GenerateCmd) error {
 	if !isSub && !isPub {
 		return fmt.Errorf("%w: no publisher or subscriber set to generate", ErrWrongCliArgs)
 	}
+	switch pubSubOpts.DiagnosticsFormat {
+	case "", "json", "sarif":
+	default:
+		return fmt.Errorf("%w: unknown diagnostics format: %q", ErrWrongCliArgs, pubSubOpts.DiagnosticsFormat)
+	}
+	defer func() {
+		err = reportDiagnostics(err, pubSubOpts.DiagnosticsFormat, pubSubOpts.Strict)
+	}()
+
 	compileOpts, err := getCompileOpts(*pubSubOpts, isPub, isSub)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrWrongCliArgs, err)
@@ -139,20 +164,26 @@ func generate(cmd *GenerateCmd) error {
 	specURL := specurl.Parse(pubSubOpts.Spec)
 	modules, err := generationCompile(specURL, compileOpts, resolver)
 	if err != nil {
+		logSourceSnippet(types.ErrorSource(err), pubSubOpts.SourceSnippets)
 		return err
 	}
 	objSources := lo.MapValues(modules, func(value *compiler.Module, _ string) linker.ObjectSource { return value })
 	mainModule := modules[specURL.SpecID]
 
 	// Linking
-	if err = generationLinking(objSources); err != nil {
+	if err = generationLinking(objSources, pubSubOpts.SourceSnippets); err != nil {
 		return err
 	}
 
 	// Rendering
 	protoRenderers := lo.MapValues(protocolBuilders(), func(value asyncapi.ProtocolBuilder, _ string) common.ProtocolRenderer { return value })
-	files, err := writer.RenderPackages(mainModule, protoRenderers, renderOpts)
+	files, err := writer.RenderPackages(getRenderSource(mainModule, modules), protoRenderers, renderOpts)
 	if err != nil {
+		logSourceSnippet(types.ErrorSource(err), pubSubOpts.SourceSnippets)
+		var rErr writer.RenderError
+		if errors.As(err, &rErr) {
+			diagnostics.Add(diagnostics.SeverityError, diagnostics.CodeRenderError, rErr.Source, rErr.Path, fmt.Sprintf("%s: %v", rErr.Object, rErr.Panic))
+		}
 		return fmt.Errorf("schema render: %w", err)
 	}
 
@@ -208,7 +239,7 @@ func generationCompile(specURL *specurl.URL, compileOpts common.CompileOpts, res
 			)
 		}
 		logger.Debug("Loading a spec", "specURL", specURL)
-		if err := module.Load(resolver); err != nil {
+		if err := module.Load(resolver, compileOpts.AllowRemoteRefs); err != nil {
 			return nil, fmt.Errorf("load a spec: %w", err)
 		}
 		logger.Debug("Compilation a spec", "specURL", specURL)
@@ -223,7 +254,33 @@ func generationCompile(specURL *specurl.URL, compileOpts common.CompileOpts, res
 	return modules, nil
 }
 
-func generationLinking(objSources map[string]linker.ObjectSource) error {
+// getRenderSource returns the main module joined with the standalone schema documents it refers to (such as Avro
+// schema files). Schema documents are not rendered by themselves, so the code of their types is put along with the main
+// module code.
+func getRenderSource(mainModule *compiler.Module, modules map[string]*compiler.Module) modulesRenderSource {
+	res := modulesRenderSource{mainModule}
+	specIDs := lo.Keys(modules)
+	sort.Strings(specIDs) // Keep the objects order stable
+	for _, specID := range specIDs {
+		if m := modules[specID]; m != mainModule && m.SpecKind() != compiler.SpecKindAsyncapi {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// modulesRenderSource joins the objects of several modules to render them together
+type modulesRenderSource []*compiler.Module
+
+func (m modulesRenderSource) PackageObjects(pkgName string) []compiler.Object {
+	return lo.FlatMap(m, func(item *compiler.Module, _ int) []compiler.Object { return item.PackageObjects(pkgName) })
+}
+
+func (m modulesRenderSource) Packages() []string {
+	return lo.Uniq(lo.FlatMap(m, func(item *compiler.Module, _ int) []string { return item.Packages() }))
+}
+
+func generationLinking(objSources map[string]linker.ObjectSource, sourceSnippets bool) error {
 	logger := types.NewLogger("Linking 🔗")
 	logger.Info("Run linking")
 	// Linking refs
@@ -231,7 +288,20 @@ func generationLinking(objSources map[string]linker.ObjectSource) error {
 	danglingRefs := linker.DanglingRefs(objSources)
 	logger.Debugf("Linker stats: %s", linker.Stats(objSources))
 	if len(danglingRefs) > 0 {
-		logger.Error("Some refs remain dangling", "refs", danglingRefs)
+		for _, chain := range linker.RefLoops(objSources) {
+			logger.Error("Refs point to each other in a loop", "chain", strings.Join(chain, " -> "))
+			diagnostics.Add(diagnostics.SeverityError, diagnostics.CodeRefLoop, nil, chain[0], diagnostics.FormatMessage(
+				"Refs point to each other in a loop", "chain", strings.Join(chain, " -> "),
+			))
+		}
+		for _, ref := range danglingRefs {
+			logger.Error("Ref cannot be resolved", "ref", ref.Ref, "source", ref.Source)
+			logSourceSnippet(ref.Source, sourceSnippets)
+			diagnostics.Add(diagnostics.SeverityError, diagnostics.CodeDanglingRef, ref.Source, "", diagnostics.FormatMessage(
+				"Ref cannot be resolved", "ref", ref.Ref,
+			))
+		}
+		logger.Error("Some refs remain dangling", "count", len(danglingRefs))
 		return fmt.Errorf("cannot finish linking")
 	}
 
@@ -244,6 +314,12 @@ func generationLinking(objSources map[string]linker.ObjectSource) error {
 		logger.Error("Cannot assign internal list promises", "promises", danglingPromises)
 		return fmt.Errorf("cannot finish linking")
 	}
+	if err := linker.FlattenAllOf(objSources); err != nil {
+		logger.Error("Cannot merge allOf schemas", "err", err)
+		logSourceSnippet(types.ErrorSource(err), sourceSnippets)
+		return err
+	}
+	linker.PrepareRecursiveTypes(objSources)
 
 	refsCount := lo.SumBy(lo.Values(objSources), func(item linker.ObjectSource) int {
 		return lo.CountBy(item.Promises(), func(p common.ObjectPromise) bool {
@@ -254,6 +330,42 @@ func generationLinking(objSources map[string]linker.ObjectSource) error {
 	return nil
 }
 
+// reportDiagnostics writes the collected warnings and errors to stdout in the given format, if any. The generation
+// error, if not reported yet, is also written. Returns the generation error, or an error if strict mode is enabled
+// and any warning occurred.
+func reportDiagnostics(genErr error, format string, strict bool) error {
+	if genErr != nil && diagnostics.Count(diagnostics.SeverityError) == 0 {
+		diagnostics.AddError(diagnostics.CodeError, "", genErr)
+	}
+
+	var err error
+	switch format {
+	case "json":
+		err = diagnostics.WriteJSON(os.Stdout)
+	case "sarif":
+		err = diagnostics.WriteSARIF(os.Stdout)
+	}
+	switch {
+	case genErr != nil:
+		return genErr
+	case err != nil:
+		return fmt.Errorf("write diagnostics: %w", err)
+	case strict && diagnostics.Count(diagnostics.SeverityWarning) > 0:
+		return fmt.Errorf("strict mode: %d warning(s) occurred", diagnostics.Count(diagnostics.SeverityWarning))
+	}
+	return nil
+}
+
+// logSourceSnippet prints the spec source lines around the position, if enabled
+func logSourceSnippet(pos *types.SourcePosition, enabled bool) {
+	if !enabled || pos == nil {
+		return
+	}
+	if snippet := pos.Snippet(); snippet != "" {
+		mainLogger.Print(pos.String() + "\n" + snippet)
+	}
+}
+
 func generationWriteImplementations(selectedImpls map[string]string, protocols []string, implDir string) error {
 	logger := types.NewLogger("Writing 📝")
 	logger.Info("Writing implementations")
@@ -351,6 +463,39 @@ func getCompileOpts(opts generatePubSubArgs, isPub, isSub bool) (common.CompileO
 		RuntimeModule:       opts.RuntimeModule,
 		GeneratePublishers:  isPub,
 		GenerateSubscribers: isSub,
+		EnumAllowUnknown:    opts.EnumAllowUnknown,
+		FormatTypes:         opts.FormatTypes,
+		OptionalFields:      common.OptionalFieldsMode(opts.OptionalFields),
+		NullableFields:      common.NullableFieldsMode(opts.NullableFields),
+		CloudEvents:         common.CloudEventsMode(opts.CloudEvents),
+		CloudEventsSource:   opts.CloudEventsSource,
+	}
+	switch res.OptionalFields {
+	case common.OptionalFieldsPointer, common.OptionalFieldsOmitEmpty:
+	default:
+		return res, fmt.Errorf("%w: unknown optional fields mode: %q", ErrWrongCliArgs, opts.OptionalFields)
+	}
+	switch res.NullableFields {
+	case common.NullableFieldsPointer, common.NullableFieldsOptional:
+	default:
+		return res, fmt.Errorf("%w: unknown nullable fields mode: %q", ErrWrongCliArgs, opts.NullableFields)
+	}
+	switch res.CloudEvents {
+	case common.CloudEventsNone, common.CloudEventsStructured, common.CloudEventsBinary:
+	default:
+		return res, fmt.Errorf("%w: unknown cloudevents mode: %q", ErrWrongCliArgs, opts.CloudEvents)
+	}
+	if res.CloudEvents != common.CloudEventsNone && res.CloudEventsSource == "" {
+		// Source is a required attribute, the events without it can't be sent
+		return res, fmt.Errorf("%w: --cloudevents-source is required along with --cloudevents", ErrWrongCliArgs)
+	}
+	for format, typ := range opts.FormatTypes {
+		if typ == "" {
+			return res, fmt.Errorf("empty Go type for format %q", format)
+		}
+	}
+	if res.Codecs, err = getCodecs(opts.Encoders, opts.Decoders); err != nil {
+		return res, err
 	}
 
 	includeAll := !opts.SelectChannelsAll && !opts.SelectMessagesAll && !opts.SelectModelsAll && !opts.SelectServersAll
@@ -397,6 +542,38 @@ func getCompileOpts(opts generatePubSubArgs, isPub, isSub bool) (common.CompileO
 	return res, nil
 }
 
+// getCodecs joins the encoders and decoders set by user for the content types
+func getCodecs(encoders, decoders map[string]string) (map[string]common.ContentTypeCodec, error) {
+	res := make(map[string]common.ContentTypeCodec)
+	for contentType, fn := range encoders {
+		if err := checkCodecFunc(contentType, fn); err != nil {
+			return nil, err
+		}
+		codec := res[contentType]
+		codec.Encoder = fn
+		res[contentType] = codec
+	}
+	for contentType, fn := range decoders {
+		if err := checkCodecFunc(contentType, fn); err != nil {
+			return nil, err
+		}
+		codec := res[contentType]
+		codec.Decoder = fn
+		res[contentType] = codec
+	}
+	return res, nil
+}
+
+// checkCodecFunc checks that the encoder or decoder constructor is set as a function full name, e.g.
+// "github.com/fxamacker/cbor/v2.NewEncoder"
+func checkCodecFunc(contentType, fn string) error {
+	pkg, name, ok := strings.Cut(fn[strings.LastIndex(fn, "/")+1:], ".")
+	if contentType == "" || !ok || pkg == "" || name == "" {
+		return fmt.Errorf("%w: function for content type %q must be set as PACKAGE.FUNC, got %q", ErrWrongCliArgs, contentType, fn)
+	}
+	return nil
+}
+
 func getResolver(opts generatePubSubArgs) compiler.SpecFileResolver {
 	logger := types.NewLogger("Resolving 📡")
 	if opts.FileResolverCommand != "" {
@@ -416,9 +593,10 @@ func getResolver(opts generatePubSubArgs) compiler.SpecFileResolver {
 
 func getRenderOpts(opts generatePubSubArgs, targetDir, targetPkg string) (common.RenderOpts, error) {
 	res := common.RenderOpts{
-		RuntimeModule: opts.RuntimeModule,
-		TargetPackage: targetPkg,
-		TargetDir:     targetDir,
+		RuntimeModule:    opts.RuntimeModule,
+		TargetPackage:    targetPkg,
+		TargetDir:        targetDir,
+		ValidateMessages: opts.ValidateMessages,
 	}
 
 	importBase := opts.ProjectModule
@@ -0,0 +1,960 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/alexflint/go-arg"
+	"github.com/charmbracelet/log"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/types"
+	"golang.org/x/exp/slices"
+)
+
+// TestGenerate generates the code from the spec and runs the program against it. The program is placed in the same
+// module as the generated code, which is imported as "gentest/asyncapi".
+func TestGenerate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	tests := []struct {
+		name    string
+		spec    string
+		files   map[string]string // Files referenced by spec
+		args    []string
+		program string
+		want    string
+	}{
+		{
+			name: "defaults",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  orders:
+    subscribe:
+      message:
+        payload:
+          $ref: '#/components/schemas/Order'
+components:
+  schemas:
+    Order:
+      type: object
+      properties:
+        qty:
+          type: integer
+          default: 1
+        nested:
+          type: object
+          default: {value: 7}
+          properties:
+            value:
+              type: integer
+              default: 5
+        labels:
+          type: object
+          default: {a: x}
+          additionalProperties:
+            type: string
+`,
+			program: `
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gentest/asyncapi/models"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	for _, data := range []string{"{}", "{\"nested\":{}}", "{\"qty\":3,\"nested\":{\"value\":1},\"labels\":{\"b\":\"y\"}}"} {
+		var fromJSON, fromYAML models.Order
+		if err := json.Unmarshal([]byte(data), &fromJSON); err != nil {
+			panic(err)
+		}
+		if err := yaml.Unmarshal([]byte(data), &fromYAML); err != nil {
+			panic(err)
+		}
+		j, _ := json.Marshal(fromJSON)
+		y, _ := json.Marshal(fromYAML)
+		fmt.Println(string(j), string(y))
+	}
+}
+`,
+			want: `{"qty":1,"nested":{"value":7},"labels":{"a":"x"}} {"qty":1,"nested":{"value":7},"labels":{"a":"x"}}
+{"qty":1,"nested":{"value":5},"labels":{"a":"x"}} {"qty":1,"nested":{"value":5},"labels":{"a":"x"}}
+{"qty":3,"nested":{"value":1},"labels":{"b":"y"}} {"qty":3,"nested":{"value":1},"labels":{"b":"y"}}
+`,
+		},
+		{
+			name: "compression",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+servers:
+  local: {url: localhost, protocol: kafka}
+channels:
+  gzip: {subscribe: {message: {x-content-encoding: gzip, payload: {type: string}}}}
+  deflate: {subscribe: {message: {x-content-encoding: deflate, payload: {type: string}}}}
+  zstd: {subscribe: {message: {x-content-encoding: zstd, payload: {type: string}}}}
+  snappy: {subscribe: {message: {x-content-encoding: snappy, payload: {type: string}}}}
+  lz: {subscribe: {message: {x-content-encoding: lz4, payload: {type: string}}}}
+  plain: {subscribe: {message: {payload: {type: string}}}}
+`,
+			program: `
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"gentest/asyncapi/channels"
+	"gentest/asyncapi/encoding"
+	"github.com/xcnt/go-asyncapi/run"
+	"github.com/xcnt/go-asyncapi/run/kafka"
+)
+
+type envelope struct {
+	bytes.Buffer
+	headers run.Headers
+}
+
+func (e *envelope) ResetPayload()                     { e.Reset() }
+func (e *envelope) SetHeaders(h run.Headers)          { e.headers = h }
+func (e *envelope) SetContentType(string)             {}
+func (e *envelope) SetBindings(kafka.MessageBindings) {}
+func (e *envelope) SetTopic(string)                   {}
+func (e *envelope) Headers() run.Headers              { return e.headers }
+
+// roundTrip sends the message and receives it back, printing the Content-Encoding header and whether the payload
+// was compressed. The message without content encoding is received as well, it's decompressed by the header.
+func roundTrip(out kafka.EnvelopeMarshaler, in kafka.EnvelopeUnmarshaler) {
+	var e envelope
+	if err := out.MarshalKafkaEnvelope(&e); err != nil {
+		panic(err)
+	}
+	fmt.Print(e.headers[run.ContentEncodingHeader], " ", !bytes.HasPrefix(e.Bytes(), []byte("\"event\"")), " ")
+	plain := envelope{Buffer: *bytes.NewBuffer(bytes.Clone(e.Bytes())), headers: e.headers}
+	if err := in.UnmarshalKafkaEnvelope(&e); err != nil {
+		panic(err)
+	}
+	plainIn := channels.NewPlainSubscribeMessageIn()
+	if err := plainIn.UnmarshalKafkaEnvelope(&plain); err != nil {
+		panic(err)
+	}
+	fmt.Print(plainIn.Payload, " ")
+}
+
+func main() {
+	gzipIn := channels.NewGzipSubscribeMessageIn()
+	roundTrip(channels.NewGzipSubscribeMessageOut().WithPayload("event"), gzipIn)
+	fmt.Println(gzipIn.Payload)
+	deflateIn := channels.NewDeflateSubscribeMessageIn()
+	roundTrip(channels.NewDeflateSubscribeMessageOut().WithPayload("event"), deflateIn)
+	fmt.Println(deflateIn.Payload)
+	zstdIn := channels.NewZstdSubscribeMessageIn()
+	roundTrip(channels.NewZstdSubscribeMessageOut().WithPayload("event"), zstdIn)
+	fmt.Println(zstdIn.Payload)
+	snappyIn := channels.NewSnappySubscribeMessageIn()
+	roundTrip(channels.NewSnappySubscribeMessageOut().WithPayload("event"), snappyIn)
+	fmt.Println(snappyIn.Payload)
+	lz4In := channels.NewLzSubscribeMessageIn()
+	roundTrip(channels.NewLzSubscribeMessageOut().WithPayload("event"), lz4In)
+	fmt.Println(lz4In.Payload)
+
+	// Identity encoding keeps the data as is
+	var buf bytes.Buffer
+	zw, err := encoding.NewCompressor("identity", &buf)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = zw.Write([]byte("event")); err != nil {
+		panic(err)
+	}
+	fmt.Println(zw.Close(), buf.String())
+}
+`,
+			want: `gzip true event event
+deflate true event event
+zstd true event event
+snappy true event event
+lz4 true event event
+<nil> event
+`,
+		},
+		{
+			name: "oneOf dispatch",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+servers:
+  local: {url: localhost, protocol: kafka}
+channels:
+  events:
+    publish:
+      message:
+        oneOf: &messages
+          - $ref: '#/components/messages/Placed'
+          - $ref: '#/components/messages/Cancelled'
+          - $ref: '#/components/messages/Ping'
+    subscribe:
+      message:
+        oneOf: *messages
+components:
+  messages:
+    Placed:
+      messageId: placed
+      payload: {$ref: '#/components/schemas/Placed'}
+    Cancelled:
+      payload: {$ref: '#/components/schemas/Cancelled'}
+    Ping:
+      contentType: application/yaml
+      payload: {type: object, properties: {at: {type: string}}}
+  schemas:
+    Placed:
+      type: object
+      discriminator: kind
+      properties:
+        kind: {type: string}
+        id: {type: string}
+    Cancelled:
+      type: object
+      discriminator: kind
+      properties:
+        kind: {type: string, const: cancelled}
+        id: {type: string}
+`,
+			program: `
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"gentest/asyncapi/channels"
+	"gentest/asyncapi/messages"
+	"gentest/asyncapi/models"
+	"github.com/xcnt/go-asyncapi/run"
+	"github.com/xcnt/go-asyncapi/run/kafka"
+)
+
+type envelope struct {
+	bytes.Buffer
+	headers run.Headers
+}
+
+func (e *envelope) ResetPayload()                     { e.Reset() }
+func (e *envelope) SetHeaders(h run.Headers)          { e.headers = h }
+func (e *envelope) SetContentType(string)             {}
+func (e *envelope) SetBindings(kafka.MessageBindings) {}
+func (e *envelope) SetTopic(string)                   {}
+func (e *envelope) Headers() run.Headers              { return e.headers }
+
+func main() {
+	channel := channels.NewEventsKafka(nil, nil)
+
+	// Payload has no discriminator value, the message is picked by the messageId header set on sealing
+	var e envelope
+	if err := channel.SealEnvelope(&e, messages.NewPlacedOut().WithPayload(models.Placed{})); err != nil {
+		panic(err)
+	}
+	fmt.Println(e.headers[run.MessageIDHeader])
+
+	for _, env := range []*envelope{
+		&e,
+		{Buffer: *bytes.NewBufferString("{\"kind\":\"cancelled\",\"id\":\"2\"}")},
+		{Buffer: *bytes.NewBufferString("at: now\n"), headers: run.Headers{"Content-Type": "application/yaml"}},
+		{Buffer: *bytes.NewBufferString("{\"kind\":\"other\"}")},
+	} {
+		msg, err := channel.ExtractEnvelope(env)
+		fmt.Printf("%T %v %v\n", msg, errors.Is(err, run.ErrUnknownMessage), err)
+	}
+}
+`,
+			want: `placed
+*messages.PlacedIn false <nil>
+*messages.CancelledIn false <nil>
+*messages.PingIn false <nil>
+<nil> true unknown message: cannot determine the message type, message id ""
+`,
+		},
+		{
+			name: "oneOf dispatch compressed",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+servers:
+  local: {url: localhost, protocol: kafka}
+channels:
+  events:
+    subscribe:
+      message:
+        oneOf:
+          - $ref: '#/components/messages/Placed'
+          - $ref: '#/components/messages/Cancelled'
+components:
+  messages:
+    Placed:
+      x-content-encoding: gzip
+      payload: {$ref: '#/components/schemas/Placed'}
+      bindings:
+        kafka: {schemaIdLocation: payload}
+    Cancelled:
+      x-content-encoding: gzip
+      payload: {$ref: '#/components/schemas/Cancelled'}
+      bindings:
+        kafka: {schemaIdLocation: payload}
+  schemas:
+    Placed:
+      type: object
+      discriminator: kind
+      properties:
+        kind: {type: string, const: placed}
+        id: {type: string}
+    Cancelled:
+      type: object
+      discriminator: kind
+      properties:
+        kind: {type: string, const: cancelled}
+        id: {type: string}
+`,
+			program: `
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"gentest/asyncapi/channels"
+	"gentest/asyncapi/messages"
+	"github.com/xcnt/go-asyncapi/run"
+	"github.com/xcnt/go-asyncapi/run/kafka"
+)
+
+type envelope struct {
+	bytes.Buffer
+	headers  run.Headers
+	schemaID int64
+}
+
+func (e *envelope) Headers() run.Headers { return e.headers }
+
+func (e *envelope) SetSchemaID(id int64) { e.schemaID = id }
+
+func main() {
+	channel := channels.NewEventsKafka(nil)
+
+	// Payload is compressed and follows the schema id, the message is picked by the discriminator
+	payload, err := kafka.AppendSchemaID(nil, 7, "", kafka.Schema{Type: kafka.SchemaTypeJSON})
+	if err != nil {
+		panic(err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err = zw.Write([]byte("{\"kind\":\"cancelled\",\"id\":\"2\"}")); err != nil {
+		panic(err)
+	}
+	if err = zw.Close(); err != nil {
+		panic(err)
+	}
+	e := &envelope{Buffer: *bytes.NewBuffer(append(payload, buf.Bytes()...))}
+	msg, err := channel.ExtractEnvelope(e)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(*msg.(*messages.CancelledIn).Payload.ID, e.schemaID)
+}
+`,
+			want: `2 7
+`,
+		},
+		{
+			name: "omitempty fields",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  json: {subscribe: {message: {contentType: application/json, payload: {$ref: '#/components/schemas/Order'}}}}
+  cbor: {subscribe: {message: {contentType: application/cbor, payload: {$ref: '#/components/schemas/Order'}}}}
+  msgpack: {subscribe: {message: {contentType: application/x-msgpack, payload: {$ref: '#/components/schemas/Order'}}}}
+components:
+  schemas:
+    Order:
+      type: object
+      required: [id]
+      properties:
+        id: {type: string}
+        qty: {type: integer}
+        item: {$ref: '#/components/schemas/Item'}
+        note: {type: [string, 'null']}
+    Item:
+      type: object
+      properties:
+        name: {type: string}
+`,
+			args: []string{"--optional-fields", "omitempty", "--nullable-fields", "optional"},
+			program: `
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"gentest/asyncapi/encoding"
+	"gentest/asyncapi/models"
+	"github.com/xcnt/go-asyncapi/run"
+)
+
+func main() {
+	for _, ct := range []string{"application/json", "application/cbor", "application/x-msgpack"} {
+		for _, order := range []models.Order{
+			{ID: "1"},
+			{ID: "2", Qty: 3, Item: &models.Item{Name: "a"}, Note: &run.Optional[string]{Value: "b", Set: true}},
+		} {
+			var buf bytes.Buffer
+			if err := encoding.NewEncoder(ct, &buf).Encode(order); err != nil {
+				panic(err)
+			}
+			var res models.Order
+			if err := encoding.NewDecoder(ct, bytes.NewReader(buf.Bytes())).Decode(&res); err != nil {
+				panic(err)
+			}
+			fmt.Printf("%s %s %d %v %v\n", ct, res.ID, res.Qty, res.Item, res.Note)
+		}
+		// Optional value is encoded as the value itself
+		var opt, value bytes.Buffer
+		if err := encoding.NewEncoder(ct, &opt).Encode(run.NewOptional("b")); err != nil {
+			panic(err)
+		}
+		if err := encoding.NewEncoder(ct, &value).Encode("b"); err != nil {
+			panic(err)
+		}
+		fmt.Println(bytes.Equal(opt.Bytes(), value.Bytes()))
+	}
+	var buf bytes.Buffer
+	if err := encoding.NewEncoder("application/json", &buf).Encode(models.Order{ID: "1"}); err != nil {
+		panic(err)
+	}
+	fmt.Print(buf.String())
+}
+`,
+			want: `application/json 1 0 <nil> <nil>
+application/json 2 3 &{a} &{b true}
+true
+application/cbor 1 0 <nil> <nil>
+application/cbor 2 3 &{a} &{b true}
+true
+application/x-msgpack 1 0 <nil> <nil>
+application/x-msgpack 2 3 &{a} &{b true}
+true
+{"id":"1"}
+`,
+		},
+		{
+			name: "pattern properties",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  labels:
+    subscribe:
+      message:
+        payload:
+          $ref: '#/components/schemas/Labels'
+components:
+  schemas:
+    Labels:
+      type: object
+      properties:
+        id: {type: string}
+      patternProperties:
+        "^x-": {type: string}
+    Strict:
+      type: object
+      patternProperties:
+        "^x-": {type: string}
+      additionalProperties: false
+`,
+			program: `
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gentest/asyncapi/models"
+)
+
+func main() {
+	var l models.Labels
+	err := json.Unmarshal([]byte("{\"id\":\"1\",\"x-a\":\"s\",\"other\":1}"), &l)
+	fmt.Println(l.PatternProperties, l.AdditionalProperties, err)
+	data, err := json.Marshal(l)
+	fmt.Println(string(data), err)
+
+	var s models.Strict
+	fmt.Println(json.Unmarshal([]byte("{\"x-a\":\"s\",\"other\":1}"), &s))
+	_, err = json.Marshal(models.Strict{PatternProperties: map[string]string{"other": "s"}})
+	fmt.Println(err)
+}
+`,
+			want: `map[x-a:s] map[other:1] <nil>
+{"id":"1","other":1,"x-a":"s"} <nil>
+unknown property "other"
+json: error calling MarshalJSON for type *models.Strict: property "other": name does not match pattern "^x-"
+`,
+		},
+		{
+			name: "allOf nested types",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  events:
+    subscribe:
+      message:
+        payload:
+          $ref: '#/components/schemas/Event'
+components:
+  schemas:
+    Base:
+      type: object
+      properties:
+        id: {type: string}
+    Event:
+      allOf:
+        - $ref: '#/components/schemas/Base'
+        - type: object
+          properties:
+            kind:
+              type: string
+              enum: [a, b]
+            cond:
+              type: object
+              properties:
+                x: {type: integer, default: 1}
+`,
+			program: `
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gentest/asyncapi/models"
+)
+
+func main() {
+	kind := models.EventKindA
+	var cond models.EventCond
+	if err := json.Unmarshal([]byte("{}"), &cond); err != nil {
+		panic(err)
+	}
+	data, err := json.Marshal(models.Event{Kind: &kind, Cond: &cond})
+	fmt.Println(string(data), err)
+}
+`,
+			want: `{"kind":"a","cond":{"x":1}} <nil>
+`,
+		},
+		{
+			name: "cloudevents",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+servers:
+  local: {url: localhost, protocol: kafka}
+channels:
+  orders:
+    subscribe:
+      message:
+        messageId: orderPlaced
+        payload: {type: string}
+`,
+			args: []string{"--cloudevents", "binary", "--cloudevents-source", "/shop"},
+			program: `
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"gentest/asyncapi/channels"
+	"github.com/xcnt/go-asyncapi/run"
+	"github.com/xcnt/go-asyncapi/run/kafka"
+)
+
+type envelope struct {
+	bytes.Buffer
+	headers run.Headers
+}
+
+func (e *envelope) ResetPayload()                     { e.Reset() }
+func (e *envelope) SetHeaders(h run.Headers)          { e.headers = h }
+func (e *envelope) SetContentType(string)             {}
+func (e *envelope) SetBindings(kafka.MessageBindings) {}
+func (e *envelope) SetTopic(string)                   {}
+func (e *envelope) Headers() run.Headers              { return e.headers }
+
+func main() {
+	// The attributes filled on marshal are kept in the message
+	var e envelope
+	out := channels.NewOrdersSubscribeMessageOut().WithPayload("placed")
+	if err := out.MarshalKafkaEnvelope(&e); err != nil {
+		panic(err)
+	}
+	fmt.Println(out.CloudEvent.ID != "", out.CloudEvent.Source, out.CloudEvent.Type, !out.CloudEvent.Time.IsZero())
+
+	in := channels.NewOrdersSubscribeMessageIn()
+	err := in.UnmarshalKafkaEnvelope(&e)
+	fmt.Println(err, in.CloudEvent.ID == out.CloudEvent.ID, in.CloudEvent.Time.Equal(out.CloudEvent.Time), in.Payload)
+}
+`,
+			want: `true /shop orderPlaced true
+<nil> true true placed
+`,
+		},
+		{
+			name: "tuple items",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  points:
+    subscribe:
+      message:
+        payload:
+          $ref: '#/components/schemas/Point'
+components:
+  schemas:
+    Point:
+      type: array
+      prefixItems:
+        - {type: number, title: lat}
+        - {type: number, title: lon}
+    Pair:
+      type: array
+      prefixItems:
+        - {type: string}
+        - {type: string}
+      minItems: 1
+`,
+			program: `
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gentest/asyncapi/models"
+)
+
+func main() {
+	// Without minItems all items are optional
+	for _, data := range []string{"[]", "[1]", "[1,2]"} {
+		var p models.Point
+		err := json.Unmarshal([]byte(data), &p)
+		out, _ := json.Marshal(p)
+		fmt.Println(p.Lat != nil, p.Lon != nil, string(out), err)
+	}
+	var p models.Pair
+	fmt.Println(json.Unmarshal([]byte("[]"), &p), json.Unmarshal([]byte("[\"a\"]"), &p), p.Item0, p.Item1)
+}
+`,
+			want: `false false [] <nil>
+true false [1] <nil>
+true true [1,2] <nil>
+0 items is less than 1 <nil> a <nil>
+`,
+		},
+		{
+			name: "conditions validate",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  addresses:
+    subscribe:
+      message:
+        payload:
+          $ref: '#/components/schemas/Address'
+components:
+  schemas:
+    Address:
+      type: object
+      required: [country]
+      properties:
+        country: {type: string}
+      if:
+        properties:
+          country: {const: US}
+      then:
+        required: [zip]
+        properties:
+          zip: {type: string, pattern: '^[0-9]{5}$'}
+`,
+			program: `
+package main
+
+import (
+	"fmt"
+
+	"gentest/asyncapi/models"
+)
+
+func main() {
+	zip, badZip := "12345", "1"
+	fmt.Println(models.Address{Country: "DE"}.Validate())
+	fmt.Println(models.Address{Country: "DE", Zip: &badZip}.Validate())
+	fmt.Println(models.Address{Country: "US", Zip: &zip}.Validate())
+	fmt.Println(models.Address{Country: "US"}.Validate())
+	fmt.Println(models.Address{Country: "US", Zip: &badZip}.Validate())
+}
+`,
+			want: `<nil>
+<nil>
+<nil>
+$.zip: required property is missing (in "then" schema, since "if" schema matches)
+$.zip: value "1" does not match pattern "^[0-9]{5}$" (in "then" schema, since "if" schema matches)
+`,
+		},
+		{
+			name: "protobuf receivers and enums",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  packets:
+    subscribe:
+      message:
+        schemaFormat: application/vnd.google.protobuf;version=3
+        contentType: application/x-protobuf
+        payload:
+          $ref: './packet.proto#/Packet'
+`,
+			files: map[string]string{"packet.proto": `
+syntax = "proto3";
+
+message Packet {
+  enum Kind {
+    option allow_alias = true;
+    KIND_UNSPECIFIED = 0;
+    KIND_DATA = 1;
+    KIND_PAYLOAD = 1;
+  }
+  repeated int32 p = 1;
+  map<string, Kind> b = 2;
+  Kind r = 3;
+}
+`},
+			program: `
+package main
+
+import (
+	"fmt"
+
+	"gentest/asyncapi/models"
+)
+
+func main() {
+	// Field names match the local variables of generated methods
+	buf, err := models.Packet{P: []int32{1, 2}, B: map[string]models.PacketKind{"x": models.PacketKindData}, R: 1}.MarshalProtobuf()
+	if err != nil {
+		panic(err)
+	}
+	var p models.Packet
+	if err = p.UnmarshalProtobuf(buf); err != nil {
+		panic(err)
+	}
+	fmt.Println(p.P, p.B, p.R)
+	fmt.Println(models.PacketKindPayload.IsValid(), models.PacketKind(5).IsValid(), models.PacketKind(5))
+}
+`,
+			want: `[1 2] map[x:KIND_DATA] KIND_DATA
+true false 5
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runGenerated(t, tt.spec, tt.files, tt.args, tt.program); got != tt.want {
+				t.Errorf("expect output:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetCompileOptsCloudEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{"no cloudevents", nil, false},
+		{"source set", []string{"--cloudevents", "binary", "--cloudevents-source", "/shop"}, false},
+		{"no source", []string{"--cloudevents", "structured"}, true},
+		{"unknown mode", []string{"--cloudevents", "batch", "--cloudevents-source", "/shop"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cliArgs cli
+			parser, err := arg.NewParser(arg.Config{}, &cliArgs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = parser.Parse(append([]string{"generate", "pubsub", "spec.yaml"}, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+			_, err = getCompileOpts(*cliArgs.GenerateCmd.PubSub, true, true)
+			if (err != nil) != tt.wantErr || err != nil && !errors.Is(err, ErrWrongCliArgs) {
+				t.Errorf("expect error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateDanglingRefs(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want []string // Pointers of the dangling-ref diagnostics
+	}{
+		{
+			name: "message",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  a:
+    subscribe:
+      message:
+        $ref: '#/components/messages/missing'
+`,
+			want: []string{"#/channels/a/subscribe/message/$ref"},
+		},
+		{
+			name: "property",
+			spec: `
+asyncapi: 2.6.0
+info: {title: test, version: "1"}
+channels:
+  a:
+    subscribe:
+      message:
+        payload:
+          type: object
+          properties:
+            x:
+              $ref: '#/components/schemas/missing'
+`,
+			want: []string{"#/channels/a/subscribe/message/payload/properties/x/$ref"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "spec.yaml"), []byte(tt.spec), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			before := len(diagnostics.All())
+			if err := generateSpec(t, dir, nil); err == nil {
+				t.Fatal("expect error, got nil")
+			}
+			var got []string
+			for _, d := range diagnostics.All()[before:] {
+				if d.Code == diagnostics.CodeDanglingRef {
+					got = append(got, d.Pointer)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expect dangling refs %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// runGenerated generates the code from spec with extra cli args to a temporary module, then runs the program in it
+// and returns its output
+func runGenerated(t *testing.T, spec string, extraFiles map[string]string, args []string, program string) string {
+	t.Helper()
+	dir := t.TempDir()
+	repoDir, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The module requires the same dependencies as the tool, and the runtime module from this repo
+	goMod, err := os.ReadFile(filepath.Join(repoDir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goMod = regexp.MustCompile(`(?m)^module .*$`).ReplaceAll(goMod, []byte("module gentest"))
+	goMod = append(goMod, "\nreplace github.com/xcnt/go-asyncapi/run => "+filepath.Join(repoDir, "run")+"\n"...)
+	goSum, err := os.ReadFile(filepath.Join(repoDir, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"go.mod":      goMod,
+		"go.sum":      goSum,
+		"spec.yaml":   []byte(spec),
+		"chk/main.go": []byte(program),
+	}
+	for name, data := range extraFiles {
+		files[name] = []byte(data)
+	}
+	for name, data := range files {
+		if err = os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = generateSpec(t, dir, args); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "run", "./chk")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOSUMDB=off")
+	out, err := cmd.Output()
+	if err != nil {
+		var stderr string
+		if e, ok := err.(*exec.ExitError); ok {
+			stderr = strings.TrimSpace(string(e.Stderr))
+		}
+		t.Fatalf("run program: %v\n%s", err, stderr)
+	}
+	return string(out)
+}
+
+// generateSpec generates the code from "spec.yaml" in dir with extra cli args to the "asyncapi" subdirectory
+func generateSpec(t *testing.T, dir string, args []string) error {
+	t.Helper()
+	var cliArgs cli
+	parser, err := arg.NewParser(arg.Config{}, &cliArgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmdline := []string{
+		"generate", "-t", filepath.Join(dir, "asyncapi"), "pubsub", "spec.yaml",
+		"-M", "gentest", "--no-implementations", "--file-resolver-search-dir", dir,
+	}
+	if err = parser.Parse(append(cmdline, args...)); err != nil {
+		t.Fatal(err)
+	}
+	log.SetLevel(log.WarnLevel)
+	mainLogger = types.NewLogger("")
+	return generate(cliArgs.GenerateCmd)
+}
@@ -27,7 +27,6 @@ type ConsumeClient struct {
 }
 
 func (c ConsumeClient) Subscriber(_ context.Context, channelName string, bindings *runKafka.ChannelBindings) (runKafka.Subscriber, error) {
-	// TODO: schema registry https://github.com/twmb/franz-go/blob/master/examples/schema_registry/schema_registry.go
 	// TODO: bindings.ClientID, bindings.GroupID
 	var opts []kgo.Opt
 
@@ -18,6 +18,8 @@ func NewEnvelopeOut() *EnvelopeOut {
 type EnvelopeOut struct {
 	*kgo.Record
 	messageBindings runKafka.MessageBindings
+	schema          *runKafka.Schema
+	schemaIDWritten bool // Schema id has been written to the record, the envelope is being sent again
 }
 
 func (e *EnvelopeOut) Write(p []byte) (n int, err error) {
@@ -27,6 +29,7 @@ func (e *EnvelopeOut) Write(p []byte) (n int, err error) {
 
 func (e *EnvelopeOut) ResetPayload() {
 	e.Value = e.Value[:0]
+	e.schemaIDWritten = false
 }
 
 func (e *EnvelopeOut) SetHeaders(headers run.Headers) {
@@ -43,6 +46,11 @@ func (e *EnvelopeOut) SetBindings(bindings runKafka.MessageBindings) {
 	e.messageBindings = bindings
 }
 
+// SetSchema sets the payload schema, its id is written to the record on sending if the schema registry is used
+func (e *EnvelopeOut) SetSchema(schema runKafka.Schema) {
+	e.schema = &schema
+}
+
 func (e *EnvelopeOut) SetTopic(topic string) {
 	e.Topic = topic
 }
@@ -61,6 +69,9 @@ func NewEnvelopeIn(r *kgo.Record) *EnvelopeIn {
 type EnvelopeIn struct {
 	*kgo.Record
 	rd *bytes.Reader
+
+	schemaID    int64
+	hasSchemaID bool
 }
 
 func (e EnvelopeIn) Read(p []byte) (n int, err error) {
@@ -74,3 +85,14 @@ func (e EnvelopeIn) Headers() run.Headers {
 	}
 	return res
 }
+
+// SetSchemaID sets the schema id read from the payload by the generated code
+func (e *EnvelopeIn) SetSchemaID(id int64) {
+	e.schemaID, e.hasSchemaID = id, true
+}
+
+// SchemaID returns the schema id read from the payload. Returns false if the message bindings don't set the schema id
+// location to "payload" or the envelope has not been unmarshalled yet.
+func (e *EnvelopeIn) SchemaID() (int64, bool) {
+	return e.schemaID, e.hasSchemaID
+}
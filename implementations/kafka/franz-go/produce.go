@@ -2,6 +2,8 @@ package franzgo
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
@@ -13,21 +15,28 @@ import (
 )
 
 func NewProducer(serverURL string, bindings *runKafka.ServerBindings, extraOpts []kgo.Opt) (*ProduceClient, error) {
-	return &ProduceClient{
+	res := ProduceClient{
 		serverURL: serverURL,
 		bindings:  bindings,
 		extraOpts: extraOpts,
-	}, nil
+	}
+	if bindings != nil && bindings.SchemaRegistryURL != "" {
+		if _, err := runKafka.SchemaIDFormat(bindings.SchemaRegistryVendor, runKafka.MessageBindings{}); err != nil {
+			return nil, err
+		}
+		res.registry = runKafka.NewSchemaRegistry(bindings.SchemaRegistryURL, nil)
+	}
+	return &res, nil
 }
 
 type ProduceClient struct {
 	serverURL string
 	bindings  *runKafka.ServerBindings
 	extraOpts []kgo.Opt
+	registry  *runKafka.SchemaRegistry // nil if schema registry url is not set in server bindings
 }
 
 func (p ProduceClient) Publisher(_ context.Context, channelName string, bindings *runKafka.ChannelBindings) (runKafka.Publisher, error) {
-	// TODO: schema registry https://github.com/twmb/franz-go/blob/master/examples/schema_registry/schema_registry.go
 	var opts []kgo.Opt
 
 	u, err := url.Parse(p.serverURL)
@@ -50,11 +59,16 @@ func (p ProduceClient) Publisher(_ context.Context, channelName string, bindings
 		return nil, err
 	}
 
-	return &PublishChannel{
+	res := PublishChannel{
 		Client:   cl,
 		Topic:    topic,
 		bindings: bindings,
-	}, nil
+		registry: p.registry,
+	}
+	if p.bindings != nil {
+		res.registryVendor = p.bindings.SchemaRegistryVendor
+	}
+	return &res, nil
 }
 
 type ImplementationRecord interface {
@@ -66,17 +80,76 @@ type PublishChannel struct {
 	*kgo.Client
 	Topic    string
 	bindings *runKafka.ChannelBindings
+	registry *runKafka.SchemaRegistry
+
+	registryVendor string
 }
 
 func (p PublishChannel) Send(ctx context.Context, envelopes ...runKafka.EnvelopeWriter) error {
 	records := make([]*kgo.Record, 0, len(envelopes))
 	for _, e := range envelopes {
+		if se, ok := e.(*EnvelopeOut); ok {
+			if err := p.writeSchemaID(ctx, se); err != nil {
+				return err
+			}
+		}
 		rm := e.(ImplementationRecord)
 		records = append(records, rm.AsFranzGoRecord())
 	}
 	return p.Client.ProduceSync(ctx, records...).FirstErr()
 }
 
+// writeSchemaID writes the id of envelope payload schema to the record according to message bindings and the schema
+// registry vendor. The id is written once, so the envelope may be sent again.
+func (p PublishChannel) writeSchemaID(ctx context.Context, e *EnvelopeOut) error {
+	if e.schema == nil || e.messageBindings.SchemaIDLocation == "" || e.schemaIDWritten {
+		return nil
+	}
+	if p.registry == nil {
+		return errors.New("schema id location is set in message bindings, but schema registry url is not set in server bindings")
+	}
+	topic := e.Topic
+	if topic == "" {
+		topic = p.Topic
+	}
+	payloadEncoding, err := runKafka.SchemaIDFormat(p.registryVendor, e.messageBindings)
+	if err != nil {
+		return err
+	}
+	subject, err := runKafka.SubjectName(e.messageBindings.SchemaLookupStrategy, topic, *e.schema)
+	if err != nil {
+		return err
+	}
+	id, err := p.registry.SchemaID(ctx, subject, *e.schema)
+	if err != nil {
+		return err
+	}
+
+	switch e.messageBindings.SchemaIDLocation {
+	case runKafka.SchemaIDLocationPayload:
+		prefix, err := runKafka.AppendSchemaID(nil, id, payloadEncoding, *e.schema)
+		if err != nil {
+			return err
+		}
+		e.Value = append(prefix, e.Value...)
+	case runKafka.SchemaIDLocationHeader:
+		headers := e.Record.Headers[:0]
+		for _, h := range e.Record.Headers {
+			if h.Key != runKafka.SchemaIDHeader { // Written on previous sending
+				headers = append(headers, h)
+			}
+		}
+		e.Record.Headers = append(headers, kgo.RecordHeader{
+			Key:   runKafka.SchemaIDHeader,
+			Value: binary.BigEndian.AppendUint64(nil, uint64(id)),
+		})
+	default:
+		return fmt.Errorf("unknown schema id location %q", e.messageBindings.SchemaIDLocation)
+	}
+	e.schemaIDWritten = true
+	return nil
+}
+
 func (p PublishChannel) Close() error {
 	p.Client.Close()
 	return nil
@@ -0,0 +1,190 @@
+package asyncapi
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/specurl"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+// AsyncAPIV3 is the root object of AsyncAPI 3.x document
+type AsyncAPIV3 struct {
+	Asyncapi           string                                `json:"asyncapi" yaml:"asyncapi"`
+	ID                 string                                `json:"id" yaml:"id"`
+	Info               InfoItem                              `json:"info" yaml:"info"`
+	Servers            types.OrderedMap[string, ServerV3]    `json:"servers" yaml:"servers" cgen:"directRender,pkgScope=servers"`
+	DefaultContentType string                                `json:"defaultContentType" yaml:"defaultContentType"`
+	Channels           types.OrderedMap[string, ChannelV3]   `json:"channels" yaml:"channels" cgen:"directRender,pkgScope=channels"`
+	Operations         types.OrderedMap[string, OperationV3] `json:"operations" yaml:"operations"`
+	Components         ComponentsItemV3                      `json:"components" yaml:"components"`
+}
+
+func (a *AsyncAPIV3) Compile(ctx *common.CompileContext) error {
+	if a.DefaultContentType != "" {
+		ctx.Storage.SetDefaultContentType(a.DefaultContentType)
+	}
+	ctx.Logger.Trace(fmt.Sprintf("Default content type set to %s", ctx.Storage.DefaultContentType()))
+
+	ctx.Storage.SetActiveServers(a.Servers.Keys())
+	ctx.Logger.Trace("Active servers list", "servers", ctx.Storage.ActiveServers())
+
+	ctx.Storage.SetActiveChannels(a.Channels.Keys())
+	ctx.Logger.Trace("Active channels list", "channels", ctx.Storage.ActiveChannels())
+
+	return a.bindOperations(ctx)
+}
+
+// bindOperations assigns the operations to channels they are applied to. In generated code the operations are
+// a part of channel, so we need to know them before the channels get compiled.
+func (a *AsyncAPIV3) bindOperations(ctx *common.CompileContext) error {
+	for _, e := range a.Operations.Entries() {
+		op := e.Value
+		opPath := []string{"operations", e.Key}
+		if op.Ref != "" {
+			ctx.Logger.Trace("Operation ref", "operation", e.Key, "$ref", op.Ref)
+			key, ok := localObjectKey(op.Ref, "components", "operations")
+			if !ok {
+				err := fmt.Errorf("operation must be a reference to `components.operations` document section, got %q", op.Ref)
+				return types.CompileError{Err: err, Path: specurl.BuildRef(opPath...)}
+			}
+			if op, ok = a.Components.Operations.Get(key); !ok {
+				return types.CompileError{Err: fmt.Errorf("operation %q not found", e.Value.Ref), Path: specurl.BuildRef(opPath...)}
+			}
+			opPath = []string{"components", "operations", key}
+		}
+		if op.XIgnore {
+			ctx.Logger.Debug("Operation denoted to be ignored", "operation", e.Key)
+			continue
+		}
+		if op.Action != operationActionSend && op.Action != operationActionReceive {
+			err := fmt.Errorf("unknown operation action %q", op.Action)
+			return types.CompileError{Err: err, Path: specurl.BuildRef(append(opPath, "action")...)}
+		}
+
+		chOp := channelOperationV3{
+			OperationKey: e.Key,
+			Action:       op.Action,
+			Description:  op.Description,
+			MessageRefs:  lo.Map(op.Messages, func(item Reference, _ int) string { return item.Ref }),
+		}
+		if op.Bindings != nil {
+			chOp.BindingsRef = specurl.BuildRef(append(opPath, "bindings")...)
+		}
+		ctx.Logger.Trace("Operation", "operation", e.Key, "action", op.Action, "channel", op.Channel.Ref)
+		if err := a.addChannelOperation(ctx, op.Channel.Ref, chOp); err != nil {
+			return types.CompileError{Err: err, Path: specurl.BuildRef(append(opPath, "channel")...)}
+		}
+
+		if op.Reply == nil {
+			continue
+		}
+		// Reply operation is the reverse one of the operation it belongs to
+		reply := *op.Reply
+		replyPath := append(opPath, "reply")
+		if reply.Ref != "" {
+			key, ok := localObjectKey(reply.Ref, "components", "replies")
+			if !ok {
+				err := fmt.Errorf("reply must be a reference to `components.replies` document section, got %q", reply.Ref)
+				return types.CompileError{Err: err, Path: specurl.BuildRef(replyPath...)}
+			}
+			if reply, ok = a.Components.Replies.Get(key); !ok {
+				return types.CompileError{Err: fmt.Errorf("reply %q not found", op.Reply.Ref), Path: specurl.BuildRef(replyPath...)}
+			}
+		}
+		if reply.Channel == nil {
+			ctx.Logger.Warn(diagnostics.CodeUnsupportedFeature, "Operation reply without channel is not supported, skip it", "operation", e.Key)
+			continue
+		}
+		replyOp := channelOperationV3{
+			OperationKey: e.Key,
+			Action:       lo.Ternary(op.Action == operationActionSend, operationActionReceive, operationActionSend),
+			MessageRefs:  lo.Map(reply.Messages, func(item Reference, _ int) string { return item.Ref }),
+		}
+		ctx.Logger.Trace("Operation reply", "operation", e.Key, "action", replyOp.Action, "channel", reply.Channel.Ref)
+		if err := a.addChannelOperation(ctx, reply.Channel.Ref, replyOp); err != nil {
+			return types.CompileError{Err: err, Path: specurl.BuildRef(append(replyPath, "channel")...)}
+		}
+	}
+
+	return nil
+}
+
+func (a *AsyncAPIV3) addChannelOperation(ctx *common.CompileContext, channelRef string, op channelOperationV3) error {
+	chKey, ok := localObjectKey(channelRef, "channels")
+	if !ok {
+		return fmt.Errorf("channel must be a reference to `channels` document section, got %q", channelRef)
+	}
+	ch, ok := a.Channels.Get(chKey)
+	if !ok {
+		return fmt.Errorf("channel %q not found", channelRef)
+	}
+	ch.operations = append(ch.operations, op)
+	a.Channels.Set(chKey, ch)
+
+	if ch.Ref == "" {
+		return nil
+	}
+	// The channel refers to a channel in components, which will be rendered instead of it. So, apply the operation
+	// to the target channel as well, fixing the message refs which point to the channel in `channels` section.
+	compKey, ok := localObjectKey(ch.Ref, "components", "channels")
+	if !ok {
+		ctx.Logger.Warn(diagnostics.CodeUnsupportedFeature, "Operations can be applied only to channels in `components.channels` document section", "$ref", ch.Ref)
+		return nil
+	}
+	compCh, ok := a.Components.Channels.Get(compKey)
+	if !ok {
+		return fmt.Errorf("channel %q not found", ch.Ref)
+	}
+	oldPrefix := specurl.BuildRef("channels", chKey) + "/"
+	newPrefix := specurl.BuildRef("components", "channels", compKey) + "/"
+	op.MessageRefs = lo.Map(op.MessageRefs, func(item string, _ int) string {
+		if strings.HasPrefix(item, oldPrefix) {
+			return newPrefix + strings.TrimPrefix(item, oldPrefix)
+		}
+		return item
+	})
+	compCh.operations = append(compCh.operations, op)
+	a.Components.Channels.Set(compKey, compCh)
+	return nil
+}
+
+type ComponentsItemV3 struct {
+	Schemas types.OrderedMap[string, Object]   `json:"schemas" yaml:"schemas" cgen:"directRender,components,pkgScope=models,marshal"`
+	Servers types.OrderedMap[string, ServerV3] `json:"servers" yaml:"servers" cgen:"directRender,components,pkgScope=servers"`
+	// ServerVariables don't get rendered directly, only as a part of other object. However, they have to be compiled as separate objects
+	ServerVariables types.OrderedMap[string, ServerVariable]   `json:"serverVariables" yaml:"serverVariables" cgen:"components"`
+	Channels        types.OrderedMap[string, ChannelV3]        `json:"channels" yaml:"channels" cgen:"directRender,components,pkgScope=channels"`
+	Operations      types.OrderedMap[string, OperationV3]      `json:"operations" yaml:"operations" cgen:"components"`
+	Replies         types.OrderedMap[string, OperationReplyV3] `json:"replies" yaml:"replies" cgen:"components"`
+	Messages        types.OrderedMap[string, Message]          `json:"messages" yaml:"messages" cgen:"directRender,components,pkgScope=messages"`
+	Parameters      types.OrderedMap[string, Parameter]        `json:"parameters" yaml:"parameters" cgen:"directRender,components,pkgScope=parameters"`
+	// CorrelationIDs don't get rendered directly, only as a part of other object. However, they have to be compiled as separate objects
+	CorrelationIDs types.OrderedMap[string, CorrelationID] `json:"correlationIds" yaml:"correlationIds" cgen:"directRender,components"`
+
+	// Bindings don't get rendered directly, only as a part of other object. However, they have to be compiled as separate objects
+	ServerBindings    types.OrderedMap[string, ServerBindings]   `json:"serverBindings" yaml:"serverBindings" cgen:"components"`
+	ChannelBindings   types.OrderedMap[string, ChannelBindings]  `json:"channelBindings" yaml:"channelBindings" cgen:"components"`
+	OperationBindings types.OrderedMap[string, OperationBinding] `json:"operationBindings" yaml:"operationBindings" cgen:"components"`
+	MessageBindings   types.OrderedMap[string, MessageBindings]  `json:"messageBindings" yaml:"messageBindings" cgen:"components"`
+}
+
+// localObjectKey returns the key of object the ref points to if the object is located in the given section of the
+// current document. E.g. for "#/components/channels/foo" and section "components", "channels" it returns "foo".
+func localObjectKey(ref string, section ...string) (string, bool) {
+	u := specurl.Parse(ref)
+	if u.IsExternal() || len(u.Pointer) != len(section)+1 {
+		return "", false
+	}
+	key, err := url.PathUnescape(u.Pointer[len(section)])
+	if err != nil || !u.MatchPointer(append(slices.Clone(section), key)) {
+		return "", false
+	}
+	return key, true
+}
@@ -0,0 +1,174 @@
+package asyncapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/specurl"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+func TestBindOperations(t *testing.T) {
+	const channels = `
+channels:
+  orders: {address: orders, messages: {placed: {payload: {type: string}}}}
+  replies: {address: replies}
+  shared: {$ref: '#/components/channels/common'}
+`
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			"send and receive",
+			`
+operations:
+  placeOrder: {action: send, channel: {$ref: '#/channels/orders'}, messages: [{$ref: '#/channels/orders/messages/placed'}]}
+  watchOrders: {action: receive, channel: {$ref: '#/channels/orders'}}
+`,
+			map[string][]string{"orders": {"placeOrder send [#/channels/orders/messages/placed]", "watchOrders receive []"}},
+			"",
+		},
+		{
+			"reply is the reverse operation",
+			`
+operations:
+  placeOrder:
+    action: send
+    channel: {$ref: '#/channels/orders'}
+    reply: {channel: {$ref: '#/channels/replies'}}
+`,
+			map[string][]string{"orders": {"placeOrder send []"}, "replies": {"placeOrder receive []"}},
+			"",
+		},
+		{
+			"operation and reply refs",
+			`
+operations:
+  placeOrder: {$ref: '#/components/operations/place'}
+components:
+  operations:
+    place: {action: receive, channel: {$ref: '#/channels/orders'}, reply: {$ref: '#/components/replies/ack'}}
+  replies:
+    ack: {channel: {$ref: '#/channels/replies'}}
+`,
+			map[string][]string{"orders": {"placeOrder receive []"}, "replies": {"placeOrder send []"}},
+			"",
+		},
+		{
+			"component channel gets operation with fixed message refs",
+			`
+operations:
+  share: {action: send, channel: {$ref: '#/channels/shared'}, messages: [{$ref: '#/channels/shared/messages/note'}]}
+components:
+  channels:
+    common: {address: common, messages: {note: {payload: {type: string}}}}
+`,
+			map[string][]string{
+				"shared":            {"share send [#/channels/shared/messages/note]"},
+				"components/common": {"share send [#/components/channels/common/messages/note]"},
+			},
+			"",
+		},
+		{
+			"ignored operation",
+			`
+operations:
+  placeOrder: {action: send, channel: {$ref: '#/channels/orders'}, x-ignore: true}
+`,
+			map[string][]string{},
+			"",
+		},
+		{
+			"unknown channel",
+			`
+operations:
+  placeOrder: {action: send, channel: {$ref: '#/channels/unknown'}}
+`,
+			nil,
+			`channel "#/channels/unknown" not found`,
+		},
+		{
+			"channel outside channels section",
+			`
+operations:
+  placeOrder: {action: send, channel: {$ref: '#/components/channels/common'}}
+`,
+			nil,
+			"channel must be a reference to `channels` document section",
+		},
+		{
+			"unknown action",
+			`
+operations:
+  placeOrder: {action: publish, channel: {$ref: '#/channels/orders'}}
+`,
+			nil,
+			`unknown operation action "publish"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc AsyncAPIV3
+			if err := yaml.Unmarshal([]byte(channels+tt.spec), &doc); err != nil {
+				t.Fatal(err)
+			}
+			ctx := common.NewCompileContext(specurl.Parse("asyncapi.yaml"), common.CompileOpts{})
+			err := doc.bindOperations(ctx)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expect error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make(map[string][]string)
+			collect := func(prefix string, channels types.OrderedMap[string, ChannelV3]) {
+				for _, e := range channels.Entries() {
+					for _, op := range e.Value.operations {
+						got[prefix+e.Key] = append(got[prefix+e.Key], fmt.Sprintf("%s %s %v", op.OperationKey, op.Action, op.MessageRefs))
+					}
+				}
+			}
+			collect("", doc.Channels)
+			collect("components/", doc.Components.Channels)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expect operations %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestServerV3ToServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  string
+		wantURL string
+	}{
+		{"host only", `{host: "localhost:9092", protocol: kafka}`, "localhost:9092"},
+		{"host and pathname", `{host: "example.com:8080", pathname: /ws, protocol: ws}`, "example.com:8080/ws"},
+		{"variables", `{host: "{region}.example.com", pathname: "/{version}", protocol: ws}`, "{region}.example.com/{version}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s ServerV3
+			if err := yaml.Unmarshal([]byte(tt.server), &s); err != nil {
+				t.Fatal(err)
+			}
+			got := s.toServer()
+			if got.URL != tt.wantURL || got.Protocol != s.Protocol {
+				t.Errorf("expect url %q protocol %q, got %q %q", tt.wantURL, s.Protocol, got.URL, got.Protocol)
+			}
+		})
+	}
+}
@@ -0,0 +1,453 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/render"
+	"github.com/xcnt/go-asyncapi/internal/types"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+const avroSchemaFormatPrefix = "application/vnd.apache.avro"
+
+// isAvroSchemaFormat returns true if schemaFormat denotes the Avro schema, such as
+// "application/vnd.apache.avro;version=1.9.0" or "application/vnd.apache.avro+json;version=1.9.0"
+func isAvroSchemaFormat(schemaFormat string) bool {
+	return strings.HasPrefix(schemaFormat, avroSchemaFormatPrefix)
+}
+
+// AvroSchema is the Apache Avro schema of a message payload, inline or in a separate .avsc file.
+// See https://avro.apache.org/docs/1.11.1/specification/
+type AvroSchema struct {
+	Ref string `json:"$ref" yaml:"$ref"`
+
+	node    *yaml.Node // Schema document, compiled at once, since the Avro schema is not a tree of the same objects
+	docName string     // Name of the root document, used as type name if the root schema is not a named type
+}
+
+// NewAvroSchemaDocument returns the root schema object of a standalone Avro schema document (.avsc file)
+func NewAvroSchemaDocument(docName string) *AvroSchema {
+	return &AvroSchema{docName: docName}
+}
+
+func (a *AvroSchema) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.DocumentNode && len(value.Content) > 0 {
+		value = value.Content[0]
+	}
+	if ref := yamlMappingValue(value, "$ref"); ref != nil {
+		a.Ref = ref.Value
+		return nil
+	}
+	a.node = value
+	return nil
+}
+
+func (a AvroSchema) Compile(ctx *common.CompileContext) error {
+	if len(ctx.Stack.Items()) == 0 {
+		// Root of a standalone .avsc document, its path is empty
+		pkgName := PackageScopeModels
+		if reusePkg, ok := ctx.CompileOpts.ReusePackages[pkgName]; ok {
+			pkgName = reusePkg
+		}
+		return a.compile(ctx, pkgName, nil, utils.ToGolangName(a.docName, true))
+	}
+
+	ctx.RegisterNameTop(ctx.Stack.Top().PathItem)
+	if a.Ref != "" {
+		ctx.Logger.Trace("Ref", "$ref", a.Ref)
+		res := render.NewGolangTypePromise(a.Ref, common.PromiseOriginUser)
+		ctx.PutPromise(res)
+		ctx.PutObject(res)
+		return nil
+	}
+	return a.compile(ctx, ctx.CurrentPackage(), ctx.PathStack(), ctx.GenerateObjName("", ""))
+}
+
+func (a AvroSchema) compile(ctx *common.CompileContext, pkgName string, path []string, name string) error {
+	if a.node == nil {
+		return types.CompileError{Err: errors.New("empty avro schema"), Path: ctx.PathStackRef()}
+	}
+	// Record fields get the tags for all content types the messages use, besides the `avro` tag
+	messagesPrm := render.NewListCbPromise[*render.Message](func(item common.Renderer, _ []string) bool {
+		_, ok := item.(*render.Message)
+		return ok
+	})
+	ctx.PutListPromise(messagesPrm)
+
+	b := avroBuilder{
+		ctx:         ctx,
+		pkgName:     pkgName,
+		messagesPrm: messagesPrm,
+		named:       make(map[string]common.GolangType),
+	}
+	ctx.Logger.Trace("Avro schema", "name", name)
+	golangType, err := b.buildTop(a.node, name)
+	if err != nil {
+		return err
+	}
+	ctx.Storage.AddObject(pkgName, path, golangType)
+	for _, item := range b.namedTypes {
+		if item.Type != golangType {
+			ctx.Storage.AddObject(pkgName, append(path, item.FullName), item.Type)
+		}
+	}
+
+	// Avro encoding depends on the schema, so register it to let the encoder find it by a value type
+	var schema any
+	if err = a.node.Decode(&schema); err != nil {
+		return types.CompileError{Err: fmt.Errorf("decode avro schema: %w", err), Path: ctx.PathStackRef()}
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return types.CompileError{Err: fmt.Errorf("marshal avro schema: %w", err), Path: ctx.PathStackRef()}
+	}
+	ctx.Storage.AddObject(pkgName, append(path, "$avroSchema"), &render.AvroSchema{
+		Type:     golangType,
+		Schema:   string(schemaJSON),
+		FullName: avroSchemaFullName(schema),
+	})
+	return nil
+}
+
+// avroType contains the fields of all kinds of Avro schema types
+type avroType struct {
+	Type        yaml.Node   `yaml:"type"`
+	Name        string      `yaml:"name"`
+	Namespace   string      `yaml:"namespace"`
+	Doc         string      `yaml:"doc"`
+	Fields      []avroField `yaml:"fields"`
+	Symbols     []string    `yaml:"symbols"`
+	Items       yaml.Node   `yaml:"items"`
+	Values      yaml.Node   `yaml:"values"`
+	Size        int         `yaml:"size"`
+	LogicalType string      `yaml:"logicalType"`
+
+	XGoName string `yaml:"x-go-name"`
+}
+
+type avroField struct {
+	Name string    `yaml:"name"`
+	Doc  string    `yaml:"doc"`
+	Type yaml.Node `yaml:"type"`
+
+	XGoName string `yaml:"x-go-name"`
+}
+
+type avroBuilder struct {
+	ctx         *common.CompileContext
+	pkgName     string
+	messagesPrm *render.ListPromise[*render.Message]
+	named       map[string]common.GolangType // Named types (records, enums, fixed) by full name
+	namedTypes  []avroNamedType              // Named types in order of declaration
+}
+
+type avroNamedType struct {
+	FullName string
+	Type     common.GolangType
+}
+
+// buildTop builds the top-level type of schema. This type is always a named Go type, because the Avro encoder in
+// generated code finds the schema by the type of value.
+func (b *avroBuilder) buildTop(node *yaml.Node, name string) (common.GolangType, error) {
+	if node.Kind == yaml.SequenceNode {
+		nonNull := lo.Reject(node.Content, func(item *yaml.Node, _ int) bool { return item.Value == "null" })
+		if len(nonNull) != 1 {
+			return nil, types.CompileError{
+				Err:  errors.New("top-level avro union must contain one type besides null"),
+				Path: b.ctx.PathStackRef(),
+			}
+		}
+		b.ctx.Logger.Warn(diagnostics.CodeUnsupportedFeature, "Top-level avro union is treated as its non-null type")
+		node = nonNull[0]
+	}
+
+	res, err := b.build(node, "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := res.(type) {
+	case *render.GoStruct, *render.GoTypeAlias:
+		return v, nil
+	case *render.GoArray:
+		v.Name, _ = lo.Coalesce(v.Name, name)
+		v.DirectRender = true
+		return v, nil
+	case *render.GoMap:
+		v.DirectRender = true
+		return v, nil
+	case *render.GoPointer:
+		return nil, types.CompileError{Err: errors.New("top-level avro decimal is not supported"), Path: b.ctx.PathStackRef()}
+	case *render.GoSimple:
+		if v.Import != "" {
+			return nil, types.CompileError{
+				Err:  fmt.Errorf("top-level avro type %s.%s is not supported", v.Import, v.Name),
+				Path: b.ctx.PathStackRef(),
+			}
+		}
+	}
+	return &render.GoTypeAlias{
+		BaseType: render.BaseType{
+			Name:         name,
+			DirectRender: true,
+			Import:       b.pkgName,
+		},
+		AliasedType: res,
+	}, nil
+}
+
+// build returns the Go type for the Avro type in node. The node can be a type name, a union or a complex type.
+// nameHint is used as type name for unnamed complex types.
+func (b *avroBuilder) build(node *yaml.Node, namespace, nameHint string) (common.GolangType, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return b.buildTypeName(node.Value, namespace)
+	case yaml.SequenceNode:
+		return b.buildUnion(node, namespace, nameHint)
+	case yaml.MappingNode:
+		var t avroType
+		if err := node.Decode(&t); err != nil {
+			return nil, types.CompileError{Err: fmt.Errorf("decode avro schema: %w", err), Path: b.ctx.PathStackRef()}
+		}
+		return b.buildComplex(&t, namespace, nameHint)
+	}
+	return nil, types.CompileError{Err: fmt.Errorf("unexpected avro schema node at line %d", node.Line), Path: b.ctx.PathStackRef()}
+}
+
+func (b *avroBuilder) buildTypeName(typeName, namespace string) (common.GolangType, error) {
+	switch typeName {
+	case "null":
+		return &render.GoSimple{Name: "any", IsIface: true}, nil
+	case "boolean":
+		return &render.GoSimple{Name: "bool"}, nil
+	case "int":
+		return &render.GoSimple{Name: "int32"}, nil
+	case "long":
+		return &render.GoSimple{Name: "int64"}, nil
+	case "float":
+		return &render.GoSimple{Name: "float32"}, nil
+	case "double":
+		return &render.GoSimple{Name: "float64"}, nil
+	case "bytes":
+		return &render.GoArray{ItemsType: &render.GoSimple{Name: "byte"}}, nil
+	case "string":
+		return &render.GoSimple{Name: "string"}, nil
+	}
+
+	// Reference to a named type, that must be defined before in the schema
+	if t, ok := b.named[avroFullName(typeName, namespace)]; ok {
+		return t, nil
+	}
+	if t, ok := b.named[typeName]; ok {
+		return t, nil
+	}
+	return nil, types.CompileError{Err: fmt.Errorf("unknown avro type %q", typeName), Path: b.ctx.PathStackRef()}
+}
+
+func (b *avroBuilder) buildUnion(node *yaml.Node, namespace, nameHint string) (common.GolangType, error) {
+	nullable := lo.ContainsBy(node.Content, func(item *yaml.Node) bool { return item.Value == "null" })
+	typs := lo.Reject(node.Content, func(item *yaml.Node, _ int) bool { return item.Value == "null" })
+	if len(typs) != 1 {
+		// Several types in union (or null only) -> 'any'
+		b.ctx.Logger.Trace("Avro union of several types is any")
+		for _, t := range typs {
+			// Named types declared in union are still should be generated and can be referenced later
+			if _, err := b.build(t, namespace, nameHint); err != nil {
+				return nil, err
+			}
+		}
+		return &render.GoSimple{Name: "any", IsIface: true}, nil
+	}
+
+	res, err := b.build(typs[0], namespace, nameHint)
+	if err != nil {
+		return nil, err
+	}
+	if nullable {
+		b.ctx.Logger.Trace("Avro union with null, make it pointer")
+		res = &render.GoPointer{Type: res}
+	}
+	return res, nil
+}
+
+func (b *avroBuilder) buildComplex(t *avroType, namespace, nameHint string) (common.GolangType, error) {
+	switch {
+	case strings.Contains(t.Name, "."): // Full name contains the namespace, e.g. "com.example.User"
+		namespace = t.Name[:strings.LastIndex(t.Name, ".")]
+	case t.Namespace != "":
+		namespace = t.Namespace
+	}
+	typeName := t.Type.Value
+	if t.Type.Kind != yaml.ScalarNode {
+		// Type is declared in a nested object or union, e.g. {"type": {"type": "array", "items": "string"}}
+		return b.build(&t.Type, namespace, nameHint)
+	}
+
+	if t.LogicalType != "" {
+		if res := avroLogicalType(t.LogicalType, typeName); res != nil {
+			b.ctx.Logger.Trace("Avro logical type", "logicalType", t.LogicalType, "type", typeName)
+			if t.Name != "" {
+				b.named[avroFullName(t.Name, namespace)] = res
+			}
+			return res, nil
+		}
+		b.ctx.Logger.Warn(diagnostics.CodeUnsupportedFeature, "Unknown avro logical type, use the underlying type", "logicalType", t.LogicalType)
+	}
+
+	switch typeName {
+	case "record", "error":
+		return b.buildRecord(t, namespace)
+	case "enum":
+		b.ctx.Logger.Trace("Avro enum", "name", t.Name)
+		res := &render.GoTypeAlias{
+			BaseType: render.BaseType{
+				Name:         avroGolangName(t),
+				Description:  utils.JoinNonemptyStrings("\n", t.Doc, "Symbols: "+strings.Join(t.Symbols, ", ")),
+				DirectRender: true,
+				Import:       b.pkgName,
+			},
+			AliasedType: &render.GoSimple{Name: "string"},
+		}
+		return b.putNamed(t, namespace, res), nil
+	case "fixed":
+		b.ctx.Logger.Trace("Avro fixed", "name", t.Name, "size", t.Size)
+		res := &render.GoArray{
+			BaseType: render.BaseType{
+				Name:         avroGolangName(t),
+				Description:  t.Doc,
+				DirectRender: true,
+				Import:       b.pkgName,
+			},
+			ItemsType: &render.GoSimple{Name: "byte"},
+			Size:      t.Size,
+		}
+		return b.putNamed(t, namespace, res), nil
+	case "array":
+		b.ctx.Logger.Trace("Avro array")
+		items, err := b.build(&t.Items, namespace, nameHint+"Item")
+		if err != nil {
+			return nil, err
+		}
+		return &render.GoArray{
+			BaseType:  render.BaseType{Name: nameHint, Import: b.pkgName},
+			ItemsType: items,
+		}, nil
+	case "map":
+		b.ctx.Logger.Trace("Avro map")
+		values, err := b.build(&t.Values, namespace, nameHint+"Value")
+		if err != nil {
+			return nil, err
+		}
+		return &render.GoMap{
+			BaseType:  render.BaseType{Name: nameHint, Import: b.pkgName},
+			KeyType:   &render.GoSimple{Name: "string"},
+			ValueType: values,
+		}, nil
+	}
+
+	// Primitive type written as object, e.g. {"type": "string"}
+	return b.buildTypeName(typeName, namespace)
+}
+
+func (b *avroBuilder) buildRecord(t *avroType, namespace string) (common.GolangType, error) {
+	b.ctx.Logger.Trace("Avro record", "name", t.Name)
+	res := &render.GoStruct{
+		BaseType: render.BaseType{
+			Name:         avroGolangName(t),
+			Description:  t.Doc,
+			DirectRender: true,
+			Import:       b.pkgName,
+		},
+	}
+	// Register the record before its fields, because they may reference it recursively
+	b.putNamed(t, namespace, res)
+
+	for _, field := range t.Fields {
+		b.ctx.Logger.Trace("Avro record field", "name", field.Name)
+		fieldName, _ := lo.Coalesce(field.XGoName, field.Name)
+		fieldType, err := b.build(&field.Type, namespace, res.Name+utils.ToGolangName(fieldName, true))
+		if err != nil {
+			return nil, err
+		}
+		var tags types.OrderedMap[string, string]
+		tags.Set("avro", field.Name)
+		res.Fields = append(res.Fields, render.GoStructField{
+			Name:        utils.ToGolangName(fieldName, true),
+			MarshalName: field.Name,
+			Description: field.Doc,
+			Type:        fieldType,
+			TagsSource:  b.messagesPrm,
+			ExtraTags:   tags,
+		})
+	}
+	return res, nil
+}
+
+// putNamed registers the named type to be referenced later in the schema
+func (b *avroBuilder) putNamed(t *avroType, namespace string, golangType common.GolangType) common.GolangType {
+	fullName := avroFullName(t.Name, namespace)
+	b.named[fullName] = golangType
+	b.namedTypes = append(b.namedTypes, avroNamedType{FullName: fullName, Type: golangType})
+	return golangType
+}
+
+func avroLogicalType(logicalType, typeName string) common.GolangType {
+	switch {
+	case logicalType == "date" && typeName == "int",
+		strings.HasSuffix(logicalType, "timestamp-millis") && typeName == "long",
+		strings.HasSuffix(logicalType, "timestamp-micros") && typeName == "long":
+		return &render.GoSimple{Name: "Time", Import: "time"}
+	case logicalType == "time-millis" && typeName == "int", logicalType == "time-micros" && typeName == "long":
+		return &render.GoSimple{Name: "Duration", Import: "time"}
+	case logicalType == "uuid" && typeName == "string":
+		return &render.GoSimple{Name: "string"}
+	case logicalType == "decimal" && (typeName == "bytes" || typeName == "fixed"):
+		return &render.GoPointer{Type: &render.GoSimple{Name: "Rat", Import: "math/big"}}
+	}
+	return nil
+}
+
+// avroSchemaFullName returns the full name of the named type defined by decoded schema, or empty string if the schema
+// is not a named type
+func avroSchemaFullName(schema any) string {
+	m, _ := schema.(map[string]any)
+	name, _ := m["name"].(string)
+	namespace, _ := m["namespace"].(string)
+	return avroFullName(name, namespace)
+}
+
+func avroFullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func avroGolangName(t *avroType) string {
+	if t.XGoName != "" {
+		return t.XGoName
+	}
+	name := t.Name[strings.LastIndex(t.Name, ".")+1:]
+	return utils.ToGolangName(name, true)
+}
+
+// yamlMappingValue returns the value node for a key in a mapping node, or nil if node is not a mapping or key not found
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
 	"github.com/xcnt/go-asyncapi/internal/render"
 	"github.com/xcnt/go-asyncapi/internal/types"
 	"gopkg.in/yaml.v3"
@@ -43,7 +44,7 @@ func (b *Bindings) build(
 		ctx.Logger.Trace("Bindings", "proto", e.Key)
 		builder, ok := ProtocolBuilders[e.Key]
 		if !ok {
-			ctx.Logger.Warn("Skip bindings protocol %q since it is not supported", "proto", e.Key)
+			ctx.Logger.Warn(diagnostics.CodeUnsupportedBinding, "Skip bindings protocol since it is not supported", "proto", e.Key)
 			continue
 		}
 
@@ -1,6 +1,8 @@
 package asyncapi
 
 import (
+	"strconv"
+
 	"github.com/xcnt/go-asyncapi/internal/specurl"
 	"github.com/samber/lo"
 
@@ -68,26 +70,7 @@ func (c Channel) build(ctx *common.CompileContext, channelKey string) (common.Re
 
 	// Channel parameters
 	if c.Parameters.Len() > 0 {
-		ctx.Logger.Trace("Channel parameters")
-		ctx.Logger.NextCallLevel()
-		res.ParametersStruct = &render.GoStruct{
-			BaseType: render.BaseType{
-				Name:         ctx.GenerateObjName(chName, "Parameters"),
-				DirectRender: true,
-				Import:       ctx.CurrentPackage(),
-			},
-		}
-		for _, paramName := range c.Parameters.Keys() {
-			ctx.Logger.Trace("Channel parameter", "name", paramName)
-			ref := ctx.PathStackRef("parameters", paramName)
-			prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
-			ctx.PutPromise(prm)
-			res.ParametersStruct.Fields = append(res.ParametersStruct.Fields, render.GoStructField{
-				Name: utils.ToGolangName(paramName, true),
-				Type: prm,
-			})
-		}
-		ctx.Logger.PrevCallLevel()
+		res.ParametersStruct = buildChannelParametersStruct(ctx, chName, c.Parameters.Keys())
 	}
 
 	// Servers which this channel is connected to
@@ -95,27 +78,10 @@ func (c Channel) build(ctx *common.CompileContext, channelKey string) (common.Re
 	if c.Servers != nil {
 		ctx.Logger.Trace("Channel servers", "names", *c.Servers)
 		res.ExplicitServerNames = *c.Servers
-		prms := lo.FilterMap(ctx.Storage.ActiveServers(), func(item string, _ int) (*render.Promise[*render.Server], bool) {
-			if lo.Contains(*c.Servers, item) {
-				ref := specurl.BuildRef("servers", item)
-				prm := render.NewPromise[*render.Server](ref, common.PromiseOriginInternal)
-				ctx.PutPromise(prm)
-				return prm, true
-			}
-			return nil, false
-		})
-		res.ServersPromises = prms
 	} else {
 		ctx.Logger.Trace("Channel for all servers")
-		prms := lo.Map(ctx.Storage.ActiveServers(), func(item string, _ int) *render.Promise[*render.Server] {
-			ref := specurl.BuildRef("servers", item)
-			prm := render.NewPromise[*render.Server](ref, common.PromiseOriginInternal)
-			ctx.PutPromise(prm)
-			return prm
-		})
-
-		res.ServersPromises = prms
 	}
+	res.ServersPromises = buildChannelServersPromises(ctx, c.Servers)
 
 	// Channel/operation bindings
 	var hasBindings bool
@@ -142,9 +108,8 @@ func (c Channel) build(ctx *common.CompileContext, channelKey string) (common.Re
 		}
 		if c.Publish.Message != nil {
 			ctx.Logger.Trace("Found publish operation message")
-			ref := ctx.PathStackRef("publish", "message")
-			res.PubMessagePromise = render.NewPromise[*render.Message](ref, common.PromiseOriginInternal)
-			ctx.PutPromise(res.PubMessagePromise)
+			res.PubMessagePromises = buildOperationMessagePromises(ctx, "publish", c.Publish.Message)
+			res.PubMessageUnion = buildChannelMessageUnion(ctx, chName, "PubMessage", len(res.PubMessagePromises))
 		}
 	}
 	if genSub && !c.Subscribe.XIgnore {
@@ -159,9 +124,8 @@ func (c Channel) build(ctx *common.CompileContext, channelKey string) (common.Re
 		}
 		if c.Subscribe.Message != nil {
 			ctx.Logger.Trace("Channel subscribe operation message")
-			ref := ctx.PathStackRef("subscribe", "message")
-			res.SubMessagePromise = render.NewPromise[*render.Message](ref, common.PromiseOriginInternal)
-			ctx.PutPromise(res.SubMessagePromise)
+			res.SubMessagePromises = buildOperationMessagePromises(ctx, "subscribe", c.Subscribe.Message)
+			res.SubMessageUnion = buildChannelMessageUnion(ctx, chName, "SubMessage", len(res.SubMessagePromises))
 		}
 	}
 	if hasBindings {
@@ -174,24 +138,107 @@ func (c Channel) build(ctx *common.CompileContext, channelKey string) (common.Re
 		}
 	}
 
-	// Build protocol-specific channels for all supported protocols
-	// Here we don't know yet which servers this channel is applied to, so we don't have the protocols list to compile.
-	// Servers will be known on rendering stage (after linking), but there we will already need to have proto
-	// channels to be compiled for certain protocols we want to render.
-	// As a solution, here we just build the proto channels for all supported protocols
+	if err := buildProtoChannels(ctx, &c, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// buildOperationMessagePromises returns promises to messages the operation carries. The message can be either a
+// single message or `oneOf` list of messages.
+func buildOperationMessagePromises(ctx *common.CompileContext, operation string, message *Message) []*render.Promise[*render.Message] {
+	refs := []string{ctx.PathStackRef(operation, "message")}
+	if len(message.OneOf) > 0 {
+		ctx.Logger.Trace("Operation message is oneOf", "count", len(message.OneOf))
+		refs = lo.Times(len(message.OneOf), func(i int) string {
+			return ctx.PathStackRef(operation, "message", "oneOf", strconv.Itoa(i))
+		})
+	}
+	return buildMessagePromises(ctx, refs)
+}
+
+func buildMessagePromises(ctx *common.CompileContext, refs []string) []*render.Promise[*render.Message] {
+	return lo.Map(refs, func(ref string, _ int) *render.Promise[*render.Message] {
+		prm := render.NewPromise[*render.Message](ref, common.PromiseOriginInternal)
+		ctx.PutPromise(prm)
+		return prm
+	})
+}
+
+// buildChannelMessageUnion returns an interface all messages of channel operation conform to. Returns nil if
+// operation has only one message.
+func buildChannelMessageUnion(ctx *common.CompileContext, chName, suffix string, messagesCount int) *render.GoInterface {
+	if messagesCount < 2 {
+		return nil
+	}
+	return &render.GoInterface{
+		BaseType: render.BaseType{
+			Name:         ctx.GenerateObjName(chName, suffix),
+			DirectRender: true,
+			Import:       ctx.CurrentPackage(),
+		},
+	}
+}
+
+func buildChannelParametersStruct(ctx *common.CompileContext, chName string, paramNames []string) *render.GoStruct {
+	ctx.Logger.Trace("Channel parameters")
+	ctx.Logger.NextCallLevel()
+	defer ctx.Logger.PrevCallLevel()
+
+	res := &render.GoStruct{
+		BaseType: render.BaseType{
+			Name:         ctx.GenerateObjName(chName, "Parameters"),
+			DirectRender: true,
+			Import:       ctx.CurrentPackage(),
+		},
+	}
+	for _, paramName := range paramNames {
+		ctx.Logger.Trace("Channel parameter", "name", paramName)
+		ref := ctx.PathStackRef("parameters", paramName)
+		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
+		ctx.PutPromise(prm)
+		res.Fields = append(res.Fields, render.GoStructField{
+			Name: utils.ToGolangName(paramName, true),
+			Type: prm,
+		})
+	}
+	return res
+}
+
+// buildChannelServersPromises returns promises to servers the channel is connected to. The nil serverNames means
+// "all servers"
+func buildChannelServersPromises(ctx *common.CompileContext, serverNames *[]string) []*render.Promise[*render.Server] {
+	return lo.FilterMap(ctx.Storage.ActiveServers(), func(item string, _ int) (*render.Promise[*render.Server], bool) {
+		if serverNames != nil && !lo.Contains(*serverNames, item) {
+			return nil, false
+		}
+		ref := specurl.BuildRef("servers", item)
+		prm := render.NewPromise[*render.Server](ref, common.PromiseOriginInternal)
+		ctx.PutPromise(prm)
+		return prm, true
+	})
+}
+
+// buildProtoChannels builds protocol-specific channels for all supported protocols.
+//
+// Here we don't know yet which servers this channel is applied to, so we don't have the protocols list to compile.
+// Servers will be known on rendering stage (after linking), but there we will already need to have proto
+// channels to be compiled for certain protocols we want to render.
+// As a solution, here we just build the proto channels for all supported protocols
+func buildProtoChannels(ctx *common.CompileContext, channel *Channel, res *render.Channel) error {
 	ctx.Logger.Trace("Prebuild the channels for every supported protocol")
 	for proto, b := range ProtocolBuilders {
 		ctx.Logger.Trace("Channel", "proto", proto)
 		ctx.Logger.NextCallLevel()
-		obj, err := b.BuildChannel(ctx, &c, res)
+		obj, err := b.BuildChannel(ctx, channel, res)
 		ctx.Logger.PrevCallLevel()
 		if err != nil {
-			return nil, err
+			return err
 		}
 		res.AllProtoChannels[proto] = obj
 	}
-
-	return res, nil
+	return nil
 }
 
 type Operation struct {
@@ -203,8 +250,7 @@ type Operation struct {
 	ExternalDocs *ExternalDocumentation `json:"externalDocs" yaml:"externalDocs"`
 	Bindings     *OperationBinding      `json:"bindings" yaml:"bindings"`
 	Traits       []OperationTrait       `json:"traits" yaml:"traits"`
-	// FIXME: can be either a message or map of messages?
-	Message *Message `json:"message" yaml:"message"`
+	Message      *Message               `json:"message" yaml:"message"` // Single message or several ones in `oneOf`
 
 	XIgnore bool `json:"x-ignore" yaml:"x-ignore"`
 }
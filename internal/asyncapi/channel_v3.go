@@ -0,0 +1,174 @@
+package asyncapi
+
+import (
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/render"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+// ChannelV3 is a channel object from AsyncAPI 3.x document
+type ChannelV3 struct {
+	Address      string                              `json:"address" yaml:"address"`
+	Messages     types.OrderedMap[string, Message]   `json:"messages" yaml:"messages"`
+	Title        string                              `json:"title" yaml:"title"`
+	Summary      string                              `json:"summary" yaml:"summary"`
+	Description  string                              `json:"description" yaml:"description"`
+	Servers      *[]Reference                        `json:"servers" yaml:"servers"`
+	Parameters   types.OrderedMap[string, Parameter] `json:"parameters" yaml:"parameters"`
+	Tags         []Tag                               `json:"tags" yaml:"tags"`
+	ExternalDocs *ExternalDocumentation              `json:"externalDocs" yaml:"externalDocs"`
+	Bindings     *ChannelBindings                    `json:"bindings" yaml:"bindings"`
+
+	XGoName string `json:"x-go-name" yaml:"x-go-name"`
+	XIgnore bool   `json:"x-ignore" yaml:"x-ignore"`
+
+	Ref string `json:"$ref" yaml:"$ref"`
+
+	// Operations applied to this channel, set by the root object before compilation
+	operations []channelOperationV3
+}
+
+func (c ChannelV3) Compile(ctx *common.CompileContext) error {
+	ctx.RegisterNameTop(ctx.Stack.Top().PathItem)
+	obj, err := c.build(ctx, ctx.Stack.Top().PathItem)
+	if err != nil {
+		return err
+	}
+	ctx.PutObject(obj)
+	return nil
+}
+
+func (c ChannelV3) build(ctx *common.CompileContext, channelKey string) (common.Renderer, error) {
+	_, isComponent := ctx.Stack.Top().Flags[common.SchemaTagComponent]
+	ignore := c.XIgnore ||
+		(!ctx.CompileOpts.GeneratePublishers && !ctx.CompileOpts.GenerateSubscribers) ||
+		!ctx.CompileOpts.ChannelOpts.IsAllowedName(channelKey)
+	if ignore {
+		ctx.Logger.Debug("Channel denoted to be ignored")
+		return &render.Channel{Dummy: true}, nil
+	}
+	if c.Ref != "" {
+		ctx.Logger.Trace("Ref", "$ref", c.Ref)
+		prm := render.NewRendererPromise(c.Ref, common.PromiseOriginUser)
+		// Set a channel to be rendered if we reference it from `channels` document section
+		prm.DirectRender = !isComponent
+		ctx.PutPromise(prm)
+		return prm, nil
+	}
+
+	chName, _ := lo.Coalesce(c.XGoName, channelKey)
+	// Render only the channels defined directly in `channels` document section, not in `components`
+	res := &render.Channel{
+		Name:                chName,
+		Address:             c.Address,
+		GolangName:          ctx.GenerateObjName(chName, ""),
+		RawName:             channelKey,
+		AllProtoChannels:    make(map[string]common.Renderer),
+		DirectRender:        !isComponent,
+		FallbackMessageType: &render.GoSimple{Name: "any", IsIface: true},
+	}
+
+	// Channel parameters
+	if c.Parameters.Len() > 0 {
+		res.ParametersStruct = buildChannelParametersStruct(ctx, chName, c.Parameters.Keys())
+	}
+
+	// Servers which this channel is connected to
+	// Empty servers field means "no servers", omitted servers field means "all servers"
+	var serverNames *[]string
+	if c.Servers != nil {
+		names := lo.FilterMap(*c.Servers, func(item Reference, _ int) (string, bool) {
+			name, ok := localObjectKey(item.Ref, "servers")
+			if !ok {
+				ctx.Logger.Warn(diagnostics.CodeUnsupportedFeature, "Channel server must be a reference to `servers` document section, skip it", "$ref", item.Ref)
+			}
+			return name, ok
+		})
+		ctx.Logger.Trace("Channel servers", "names", names)
+		res.ExplicitServerNames = names
+		serverNames = &names
+	} else {
+		ctx.Logger.Trace("Channel for all servers")
+	}
+	res.ServersPromises = buildChannelServersPromises(ctx, serverNames)
+
+	// Channel/operation bindings
+	var hasBindings bool
+	if c.Bindings != nil {
+		ctx.Logger.Trace("Found channel bindings")
+		hasBindings = true
+
+		ref := ctx.PathStackRef("bindings")
+		res.BindingsChannelPromise = render.NewPromise[*render.Bindings](ref, common.PromiseOriginInternal)
+		ctx.PutPromise(res.BindingsChannelPromise)
+	}
+
+	// AsyncAPI 2.x channel representation for protocol builders
+	protoChannel := Channel{Description: c.Description, Address: c.Address, XGoName: c.XGoName}
+
+	if pubOps := c.operationsByAction(operationActionSend); len(pubOps) > 0 && ctx.CompileOpts.GeneratePublishers {
+		res.Publisher = true
+		protoChannel.Publish = &Operation{Description: pubOps[0].Description}
+		if ref := pubOps[0].BindingsRef; ref != "" {
+			ctx.Logger.Trace("Found send operation bindings", "operation", pubOps[0].OperationKey)
+			hasBindings = true
+
+			res.BindingsPublishPromise = render.NewPromise[*render.Bindings](ref, common.PromiseOriginInternal)
+			ctx.PutPromise(res.BindingsPublishPromise)
+		}
+		if refs := c.operationsMessageRefs(ctx, pubOps); len(refs) > 0 {
+			ctx.Logger.Trace("Found send operation messages", "$ref", refs)
+			res.PubMessagePromises = buildMessagePromises(ctx, refs)
+			res.PubMessageUnion = buildChannelMessageUnion(ctx, chName, "PubMessage", len(refs))
+		}
+	}
+	if subOps := c.operationsByAction(operationActionReceive); len(subOps) > 0 && ctx.CompileOpts.GenerateSubscribers {
+		res.Subscriber = true
+		protoChannel.Subscribe = &Operation{Description: subOps[0].Description}
+		if ref := subOps[0].BindingsRef; ref != "" {
+			ctx.Logger.Trace("Found receive operation bindings", "operation", subOps[0].OperationKey)
+			hasBindings = true
+
+			res.BindingsSubscribePromise = render.NewPromise[*render.Bindings](ref, common.PromiseOriginInternal)
+			ctx.PutPromise(res.BindingsSubscribePromise)
+		}
+		if refs := c.operationsMessageRefs(ctx, subOps); len(refs) > 0 {
+			ctx.Logger.Trace("Found receive operation messages", "$ref", refs)
+			res.SubMessagePromises = buildMessagePromises(ctx, refs)
+			res.SubMessageUnion = buildChannelMessageUnion(ctx, chName, "SubMessage", len(refs))
+		}
+	}
+	if hasBindings {
+		res.BindingsStruct = &render.GoStruct{
+			BaseType: render.BaseType{
+				Name:         ctx.GenerateObjName(chName, "Bindings"),
+				DirectRender: true,
+				Import:       ctx.CurrentPackage(),
+			},
+		}
+	}
+
+	if err := buildProtoChannels(ctx, &protoChannel, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c ChannelV3) operationsByAction(action string) []channelOperationV3 {
+	return lo.Filter(c.operations, func(item channelOperationV3, _ int) bool { return item.Action == action })
+}
+
+// operationsMessageRefs returns references to messages the given operations use. If an operation doesn't set
+// messages explicitly, all messages of the channel are used.
+func (c ChannelV3) operationsMessageRefs(ctx *common.CompileContext, ops []channelOperationV3) []string {
+	return lo.Uniq(lo.FlatMap(ops, func(item channelOperationV3, _ int) []string {
+		if len(item.MessageRefs) == 0 {
+			return lo.Map(c.Messages.Keys(), func(key string, _ int) string { return ctx.PathStackRef("messages", key) })
+		}
+		return item.MessageRefs
+	}))
+}
@@ -0,0 +1,132 @@
+package asyncapi
+
+import (
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/render"
+)
+
+// conditionalBranches returns the `then` and `else` schemas with their keys, if the schema has `if`
+func (o Object) conditionalBranches() []lo.Tuple2[string, *Object] {
+	if o.If == nil {
+		return nil
+	}
+	var res []lo.Tuple2[string, *Object]
+	if o.Then != nil {
+		res = append(res, lo.T2("then", o.Then))
+	}
+	if o.Else != nil {
+		res = append(res, lo.T2("else", o.Else))
+	}
+	return res
+}
+
+// warnIgnoredConditions reports the conditional schemas, if the schema is not an object. Only object schemas get
+// the unmarshal methods, that check them.
+func (o Object) warnIgnoredConditions(ctx *common.CompileContext) {
+	fields := []lo.Tuple2[string, bool]{
+		lo.T2("not", o.Not != nil),
+		lo.T2("if", o.If != nil),
+	}
+	for _, f := range fields {
+		if f.B {
+			ctx.Logger.Warn(diagnostics.CodeUnsupportedField, "Schema field is supported only for objects, ignore it", "field", f.A)
+		}
+	}
+}
+
+// buildConditions returns the `if`/`then`/`else` and `not` schemas of object, which are checked on unmarshal. Returns
+// nil if object has no such schemas.
+func (o Object) buildConditions(ctx *common.CompileContext) *render.SchemaCheck {
+	var res render.SchemaCheck
+	if len(o.conditionalBranches()) > 0 {
+		ifCheck, ok1 := o.If.buildSchemaCheck(ctx)
+		thenCheck, ok2 := o.Then.buildSchemaCheck(ctx)
+		elseCheck, ok3 := o.Else.buildSchemaCheck(ctx)
+		if ok1 && ok2 && ok3 {
+			res.If, res.Then, res.Else = ifCheck, thenCheck, elseCheck
+		} else {
+			ctx.Logger.Warn(diagnostics.CodeUnsupportedField, "$ref in conditional schema is not supported, ignore the condition", "field", "if")
+		}
+	}
+	if o.Not != nil {
+		if notCheck, ok := o.Not.buildSchemaCheck(ctx); ok {
+			res.Not = notCheck
+		} else {
+			ctx.Logger.Warn(diagnostics.CodeUnsupportedField, "$ref in conditional schema is not supported, ignore the condition", "field", "not")
+		}
+	}
+	if res.If == nil && res.Not == nil {
+		return nil
+	}
+	return &res
+}
+
+// buildSchemaCheck converts the schema to the form checked on unmarshal. Returns false if the schema refers to other
+// schemas by $ref, since it can't be checked without resolving them.
+func (o *Object) buildSchemaCheck(ctx *common.CompileContext) (*render.SchemaCheck, bool) {
+	if o == nil {
+		return nil, true
+	}
+	if o.Ref != "" {
+		return nil, false
+	}
+	res := render.SchemaCheck{
+		Required:    o.Required,
+		Constraints: lo.FromPtr(o.buildConstraints(ctx)),
+	}
+	if o.Type != nil {
+		res.Type = o.Type.V1
+		if o.Type.Selector == 0 {
+			res.Type = []string{o.Type.V0}
+		}
+	}
+
+	values := o.Enum
+	if o.Const != nil {
+		values = append(values, *o.Const)
+	}
+	for _, item := range values {
+		v, err := decodeRawValue(item)
+		if err != nil {
+			ctx.Logger.Warn(diagnostics.CodeInvalidValue, "Cannot decode the enum value, ignore it", "err", err)
+			continue
+		}
+		res.Enum = append(res.Enum, v)
+	}
+
+	ok := true
+	check := func(obj *Object) *render.SchemaCheck {
+		c, objOk := obj.buildSchemaCheck(ctx)
+		ok = ok && objOk
+		return c
+	}
+	checks := func(objs []Object) []*render.SchemaCheck {
+		return lo.Map(objs, func(item Object, _ int) *render.SchemaCheck { return check(&item) })
+	}
+	for _, entry := range o.Properties.Entries() {
+		value := entry.Value
+		res.Properties.Set(entry.Key, check(&value))
+	}
+	if o.Items != nil && o.Items.Selector == 0 {
+		res.Items = check(&o.Items.V0)
+	}
+	res.AllOf, res.AnyOf, res.OneOf = checks(o.AllOf), checks(o.AnyOf), checks(o.OneOf)
+	res.Not = check(o.Not)
+	if len(o.conditionalBranches()) > 0 {
+		res.If, res.Then, res.Else = check(o.If), check(o.Then), check(o.Else)
+	}
+	return &res, ok
+}
+
+// typeConstraints returns the constraints checked by the Validate method of the type. The properties of `then` and
+// `else` schemas must hold only if `if` matches, so they are left to the conditions check of the parent object.
+func (o Object) typeConstraints(ctx *common.CompileContext) *render.Constraints {
+	stack := ctx.PathStack()
+	if n := len(stack); n >= 3 && lo.Contains([]string{"then", "else"}, stack[n-3]) && stack[n-2] == "properties" {
+		return &render.Constraints{}
+	}
+	return o.buildConstraints(ctx)
+}
@@ -0,0 +1,100 @@
+package asyncapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+// defaultValue returns the schema `default` value decoded as string, bool, json.Number, []any or map[string]any.
+// Returns nil if no default is set, or it is null or invalid.
+func (o Object) defaultValue(ctx *common.CompileContext) any {
+	if o.Default == nil {
+		return nil
+	}
+	res, err := decodeRawValue(*o.Default)
+	if err != nil {
+		ctx.Logger.Warn(diagnostics.CodeInvalidValue, "Cannot decode the default value, ignore it", "err", err)
+		return nil
+	}
+	return res
+}
+
+// decodeRawValue decodes the JSON or YAML value keeping the numbers as json.Number, so that they are rendered as is
+func decodeRawValue(raw types.Union2[json.RawMessage, yaml.Node]) (any, error) {
+	if raw.Selector == 1 {
+		return decodeYAMLNode(&raw.V1)
+	}
+	var res any
+	dec := json.NewDecoder(bytes.NewReader(raw.V0))
+	dec.UseNumber()
+	err := dec.Decode(&res)
+	return res, err
+}
+
+func decodeYAMLNode(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return decodeYAMLNode(node.Content[0])
+	case yaml.AliasNode:
+		return decodeYAMLNode(node.Alias)
+	case yaml.SequenceNode:
+		res := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := decodeYAMLNode(item)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, v)
+		}
+		return res, nil
+	case yaml.MappingNode:
+		res := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v, err := decodeYAMLNode(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			res[node.Content[i].Value] = v
+		}
+		return res, nil
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var v bool
+		err := node.Decode(&v)
+		return v, err
+	case "!!int":
+		var v int64
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case "!!float":
+		var v float64
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("number %s is not supported", node.Value)
+		}
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case "!!str", "!!timestamp", "!!binary":
+		return node.Value, nil
+	}
+	return nil, fmt.Errorf("unsupported YAML value of type %s", node.ShortTag())
+}
@@ -0,0 +1,50 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+func TestDecodeRawValue(t *testing.T) {
+	want := map[string]any{
+		"s":    "2024-01-02",
+		"i":    json.Number("10"),
+		"f":    json.Number("1.5"),
+		"b":    true,
+		"n":    nil,
+		"list": []any{json.Number("1"), "a"},
+	}
+	tests := []struct {
+		name string
+		raw  func(t *testing.T) types.Union2[json.RawMessage, yaml.Node]
+	}{
+		{"json", func(t *testing.T) types.Union2[json.RawMessage, yaml.Node] {
+			return types.Union2[json.RawMessage, yaml.Node]{
+				V0: json.RawMessage(`{"s": "2024-01-02", "i": 10, "f": 1.5, "b": true, "n": null, "list": [1, "a"]}`),
+			}
+		}},
+		{"yaml", func(t *testing.T) types.Union2[json.RawMessage, yaml.Node] {
+			var node yaml.Node
+			if err := yaml.Unmarshal([]byte("{s: 2024-01-02, i: 0xA, f: 1.5, b: true, n: null, list: [1, a]}"), &node); err != nil {
+				t.Fatal(err)
+			}
+			return types.Union2[json.RawMessage, yaml.Node]{V1: node, Selector: 1}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRawValue(tt.raw(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expect %#v, got %#v", want, got)
+			}
+		})
+	}
+}
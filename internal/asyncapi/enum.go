@@ -0,0 +1,182 @@
+package asyncapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/render"
+	"github.com/xcnt/go-asyncapi/internal/types"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+// enumValues returns the values of `enum`, or the `const` value as the only allowed one. `null` values are skipped.
+func (o Object) enumValues() ([]any, error) {
+	items := o.Enum
+	if len(items) == 0 && o.Const != nil {
+		items = append(items, *o.Const)
+	}
+	var res []any
+	for _, item := range items {
+		var v any
+		var err error
+		switch item.Selector {
+		case 0:
+			dec := json.NewDecoder(bytes.NewReader(item.V0))
+			dec.UseNumber()
+			err = dec.Decode(&v)
+		case 1:
+			err = item.V1.Decode(&v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+// enumSchemaType returns the jsonschema type that all enum values have, empty string if values have different types
+func enumSchemaType(values []any) string {
+	typs := lo.Uniq(lo.Map(values, func(item any, _ int) string {
+		switch v := item.(type) {
+		case string:
+			return "string"
+		case int:
+			return "integer"
+		case float64:
+			return lo.Ternary(v == math.Trunc(v), "integer", "number")
+		case json.Number:
+			return lo.Ternary(strings.ContainsAny(v.String(), ".eE"), "number", "integer")
+		}
+		return ""
+	}))
+	switch {
+	case len(typs) == 1:
+		return typs[0]
+	case len(typs) == 2 && lo.Contains(typs, "integer") && lo.Contains(typs, "number"):
+		return "number"
+	}
+	return ""
+}
+
+// convertEnumValue converts the enum value parsed from spec to Go value of given jsonschema type
+func convertEnumValue(value any, typeName string) (any, error) {
+	switch typeName {
+	case "string":
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case "integer":
+		switch v := value.(type) {
+		case int:
+			return int64(v), nil
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v), nil
+			}
+		case json.Number:
+			return v.Int64()
+		}
+	case "number":
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case json.Number:
+			return v.Float64()
+		}
+	}
+	return nil, fmt.Errorf("value %v is not %s", value, typeName)
+}
+
+// buildEnum returns the enum type with constant for every value. Returns nil if enum can't be generated for the
+// schema, so a plain type should be used.
+func (o Object) buildEnum(ctx *common.CompileContext, typeName string, underlyingType common.GolangType) *render.GoEnum {
+	simpleType, ok := underlyingType.(*render.GoSimple)
+	if !ok || simpleType.Import != "" || !lo.Contains([]string{"string", "integer", "number"}, typeName) {
+		ctx.Logger.Warn(diagnostics.CodeUnsupportedFeature, "Enum is not supported for this type, ignore it", "type", typeName)
+		return nil
+	}
+	values, err := o.enumValues()
+	if err != nil {
+		ctx.Logger.Warn(diagnostics.CodeInvalidValue, "Cannot parse enum values, ignore them", "err", err)
+		return nil
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	objName, _ := lo.Coalesce(o.XGoName, o.Title)
+	name := ctx.GenerateObjName(objName, "")
+	res := render.GoEnum{
+		BaseType: render.BaseType{
+			Name:         name,
+			Description:  o.Description,
+			DirectRender: true, // Enum constants must refer to the type by name
+			Import:       ctx.CurrentPackage(),
+			Constraints:  o.typeConstraints(ctx),
+			Default:      o.defaultValue(ctx),
+		},
+		UnderlyingType: simpleType,
+		Strict:         !ctx.CompileOpts.EnumAllowUnknown,
+	}
+	names := make(map[string]int)
+	for i, value := range values {
+		v, err := convertEnumValue(value, typeName)
+		if err != nil {
+			ctx.Logger.Warn(diagnostics.CodeInvalidValue, "Enum value doesn't match the schema type, ignore enum", "err", err)
+			return nil
+		}
+		valueName := fmt.Sprint(v)
+		switch {
+		case i < len(o.XEnumVarNames) && o.XEnumVarNames[i] != "":
+			valueName = o.XEnumVarNames[i]
+		case valueName == "":
+			valueName = "Empty"
+		case typeName != "string":
+			valueName = strings.NewReplacer("-", "Minus_", ".", "_", "+", "").Replace(valueName)
+		}
+		constName := enumConstName(name, valueName)
+		if n := names[constName]; n > 0 { // Different values may give the same name, e.g. "in-progress" and "in_progress"
+			names[constName]++
+			constName += strconv.Itoa(n + 1)
+		} else {
+			names[constName] = 1
+		}
+		res.Values = append(res.Values, render.GoEnumValue{Name: constName, Value: v})
+	}
+	ctx.Logger.Trace("Object is enum", "name", name, "values", len(res.Values))
+	return &res
+}
+
+// enumConstName returns the constant name for enum value, that is the type name followed by the value in camel case
+func enumConstName(typeName, valueName string) string {
+	// Prefix the value with a letter, because ToGolangName cuts the leading digits
+	return typeName + strings.TrimPrefix(utils.ToGolangName("v_"+valueName, true), "V")
+}
+
+// getDefaultEnumType returns the jsonschema type guessed by enum or const values, nil if it can't be guessed
+func (o Object) getDefaultEnumType() *types.Union2[string, []string] {
+	if len(o.Enum) == 0 && o.Const == nil {
+		return nil
+	}
+	values, err := o.enumValues()
+	if err != nil {
+		return nil
+	}
+	if typ := enumSchemaType(values); typ != "" {
+		return types.ToUnion2[string, []string](typ)
+	}
+	return nil
+}
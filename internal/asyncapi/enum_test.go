@@ -0,0 +1,52 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEnumSchemaType(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []any
+		want   string
+	}{
+		{"strings", []any{"a", "b"}, "string"},
+		{"yaml integers", []any{1, 2}, "integer"},
+		{"json integers", []any{json.Number("1"), json.Number("-2")}, "integer"},
+		{"integers and floats", []any{1, 2.5}, "number"},
+		{"json floats", []any{json.Number("1e3"), json.Number("0.5")}, "number"},
+		{"mixed", []any{"a", 1}, ""},
+		{"bool", []any{true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := enumSchemaType(tt.values); got != tt.want {
+				t.Errorf("expect %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEnumConstName(t *testing.T) {
+	tests := []struct {
+		typeName  string
+		valueName string
+		want      string
+	}{
+		{"Event1Kind", "a", "Event1KindA"},
+		{"Event1Code", "1", "Event1Code1"},
+		{"OrderStatus", "in-progress", "OrderStatusInProgress"},
+		{"Level", "Minus_1", "LevelMinus1"},
+		{"Ratio", "0_5", "Ratio05"},
+		{"Field", "user_id", "FieldUserID"},
+		{"Color", "Empty", "ColorEmpty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := enumConstName(tt.typeName, tt.valueName); got != tt.want {
+				t.Errorf("expect %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
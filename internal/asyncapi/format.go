@@ -0,0 +1,65 @@
+package asyncapi
+
+import (
+	"strings"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/render"
+)
+
+// formatType returns the Go type for a value of given jsonschema type and format. Returns nil if format is unknown or
+// is not applicable to the jsonschema type, so the default type should be used. The mapping can be overridden by user.
+func formatType(ctx *common.CompileContext, typeName, format string) common.GolangType {
+	if t, ok := ctx.CompileOpts.FormatTypes[format]; ok {
+		return parseGolangType(t)
+	}
+
+	switch typeName {
+	case "integer":
+		switch format {
+		case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+			return &render.GoSimple{Name: format}
+		}
+	case "number":
+		switch format {
+		case "float":
+			return &render.GoSimple{Name: "float32"}
+		case "double":
+			return &render.GoSimple{Name: "float64"}
+		}
+	case "string":
+		switch format {
+		case "date-time":
+			return &render.GoSimple{Name: "Time", Import: "time"}
+		case "date":
+			return &render.GoSimple{Name: "Date", Import: ctx.RuntimeModule("")}
+		case "duration":
+			return &render.GoSimple{Name: "Duration", Import: ctx.RuntimeModule("")}
+		case "uuid":
+			return &render.GoSimple{Name: "UUID", Import: ctx.RuntimeModule("")}
+		case "uri", "uri-reference", "iri", "iri-reference":
+			return &render.GoSimple{Name: "URL", Import: ctx.RuntimeModule("")}
+		case "byte", "binary":
+			return &render.GoSimple{Name: "Bytes", Import: ctx.RuntimeModule("")}
+		case "ipv4", "ipv6":
+			return &render.GoSimple{Name: "IP", Import: "net"}
+		}
+	}
+	return nil
+}
+
+// parseGolangType parses the Go type expression, such as "int64", "time.Time", "github.com/google/uuid.UUID",
+// "*net/url.URL" or "[]byte". Package of a named type is the import path, the part before the last dot.
+func parseGolangType(s string) common.GolangType {
+	switch {
+	case strings.HasPrefix(s, "*"):
+		return &render.GoPointer{Type: parseGolangType(s[1:])}
+	case strings.HasPrefix(s, "[]"):
+		return &render.GoArray{ItemsType: parseGolangType(s[2:])}
+	}
+	slash := strings.LastIndex(s, "/")
+	if dot := strings.LastIndex(s, "."); dot > slash {
+		return &render.GoSimple{Name: s[dot+1:], Import: s[:dot]}
+	}
+	return &render.GoSimple{Name: s}
+}
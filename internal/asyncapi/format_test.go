@@ -0,0 +1,29 @@
+package asyncapi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/render"
+)
+
+func TestParseGolangType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want common.GolangType
+	}{
+		{"int64", &render.GoSimple{Name: "int64"}},
+		{"time.Time", &render.GoSimple{Name: "Time", Import: "time"}},
+		{"github.com/google/uuid.UUID", &render.GoSimple{Name: "UUID", Import: "github.com/google/uuid"}},
+		{"*net/url.URL", &render.GoPointer{Type: &render.GoSimple{Name: "URL", Import: "net/url"}}},
+		{"[]byte", &render.GoArray{ItemsType: &render.GoSimple{Name: "byte"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := parseGolangType(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expect %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package asyncapi
+
+import (
+	"errors"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/render"
+	"github.com/xcnt/go-asyncapi/internal/specurl"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+// JSONSchemaRootKey is a path item the root schema of JSONSchemaDocument is compiled at
+const JSONSchemaRootKey = "$root"
+
+// JSONSchemaDocument is a standalone JSON Schema document, such as draft-07 schema file without `asyncapi` key. Its
+// root schema and the schemas in `definitions` and `$defs` become the models, that can be referenced from other
+// documents, e.g. `common.json` or `common.json#/definitions/Address`.
+type JSONSchemaDocument struct {
+	// Root contains only one root schema keyed by document name, so that the generated type gets the document name
+	Root        types.OrderedMap[string, Object] `json:"$root" yaml:"-" cgen:"directRender,components,pkgScope=models,marshal"`
+	Definitions types.OrderedMap[string, Object] `json:"definitions" yaml:"definitions" cgen:"directRender,components,pkgScope=models,marshal"`
+	Defs        types.OrderedMap[string, Object] `json:"$defs" yaml:"$defs" cgen:"directRender,components,pkgScope=models,marshal"`
+
+	docName string
+}
+
+// NewJSONSchemaDocument returns the object of a JSON Schema document
+func NewJSONSchemaDocument(docName string) *JSONSchemaDocument {
+	return &JSONSchemaDocument{docName: docName}
+}
+
+func (d *JSONSchemaDocument) UnmarshalYAML(value *yaml.Node) error {
+	var root Object
+	if err := value.Decode(&root); err != nil {
+		return err
+	}
+	type plainDocument JSONSchemaDocument
+	if err := value.Decode((*plainDocument)(d)); err != nil {
+		return err
+	}
+	// Definitions are compiled at document level, so that local refs like `#/definitions/Foo` point to them
+	root.Definitions = types.OrderedMap[string, Object]{}
+	root.Defs = types.OrderedMap[string, Object]{}
+	if !root.isDefinitionsOnly() {
+		d.Root.Set(d.docName, root)
+	}
+	return nil
+}
+
+func (d JSONSchemaDocument) Compile(ctx *common.CompileContext) error {
+	if d.docName == "" {
+		return types.CompileError{Err: errors.New("empty document name"), Path: ctx.PathStackRef()}
+	}
+	if d.Root.Len() == 0 {
+		ctx.Logger.Trace("JSON Schema document contains definitions only")
+		return nil
+	}
+
+	// Refs to the whole document (without pointer) refer to the root schema
+	pkgName := PackageScopeModels
+	if reusePkg, ok := ctx.CompileOpts.ReusePackages[pkgName]; ok {
+		pkgName = reusePkg
+	}
+	ctx.Logger.Trace("JSON Schema document root", "name", d.docName)
+	prm := render.NewGolangTypePromise(specurl.BuildRef(JSONSchemaRootKey, d.docName), common.PromiseOriginInternal)
+	ctx.PutPromise(prm)
+	ctx.Storage.AddObject(pkgName, nil, prm)
+	return nil
+}
+
+// isDefinitionsOnly returns true if schema is just a container for definitions and describes nothing itself
+func (o Object) isDefinitionsOnly() bool {
+	return o.Type == nil && o.Ref == "" && o.Properties.Len() == 0 && o.Items == nil && len(o.PrefixItems) == 0 &&
+		len(o.AllOf)+len(o.AnyOf)+len(o.OneOf) == 0 && o.AdditionalProperties == nil
+}
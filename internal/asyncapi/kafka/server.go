@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/xcnt/go-asyncapi/internal/asyncapi"
 	"github.com/xcnt/go-asyncapi/internal/common"
@@ -29,6 +30,14 @@ func (pb ProtoBuilder) BuildServerBindings(ctx *common.CompileContext, rawData t
 	if err = types.UnmarshalRawsUnion2(rawData, &bindings); err != nil {
 		return vals, jsonVals, types.CompileError{Err: err, Path: ctx.PathStackRef(), Proto: pb.ProtoName}
 	}
+	switch bindings.SchemaRegistryVendor {
+	case "", render.SchemaRegistryVendorConfluent, render.SchemaRegistryVendorApicurio:
+	default:
+		// Schema id wire format depends on vendor, others are not supported
+		err = fmt.Errorf("unsupported schemaRegistryVendor %q, possible values: %s, %s",
+			bindings.SchemaRegistryVendor, render.SchemaRegistryVendorConfluent, render.SchemaRegistryVendorApicurio)
+		return vals, jsonVals, types.CompileError{Err: err, Path: ctx.PathStackRef(), Proto: pb.ProtoName}
+	}
 	vals = render.ConstructGoValue(
 		bindings, nil, &render.GoSimple{Name: "ServerBindings", Import: ctx.RuntimeModule(pb.ProtoName)},
 	)
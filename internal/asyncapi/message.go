@@ -12,35 +12,79 @@ import (
 	"github.com/samber/lo"
 
 	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
 	"github.com/xcnt/go-asyncapi/internal/render"
 	"github.com/xcnt/go-asyncapi/internal/utils"
 )
 
 type Message struct {
-	MessageID     string                 `json:"messageId" yaml:"messageId"`
-	Headers       *Object                `json:"headers" yaml:"headers" cgen:"marshal"`
-	Payload       *Object                `json:"payload" yaml:"payload" cgen:"marshal"` // TODO: other formats
-	CorrelationID *CorrelationID         `json:"correlationId" yaml:"correlationId"`
-	SchemaFormat  string                 `json:"schemaFormat" yaml:"schemaFormat"`
-	ContentType   string                 `json:"contentType" yaml:"contentType"`
-	Name          string                 `json:"name" yaml:"name"`
-	Title         string                 `json:"title" yaml:"title"`
-	Summary       string                 `json:"summary" yaml:"summary"`
-	Description   string                 `json:"description" yaml:"description"`
-	Tags          []Tag                  `json:"tags" yaml:"tags"`
-	ExternalDocs  *ExternalDocumentation `json:"externalDocs" yaml:"externalDocs"`
-	Bindings      *MessageBindings       `json:"bindings" yaml:"bindings"`
-	Examples      []MessageExample       `json:"examples" yaml:"examples"`
-	Traits        []MessageTrait         `json:"traits" yaml:"traits"`
+	MessageID     string                            `json:"messageId" yaml:"messageId"`
+	Headers       *Object                           `json:"headers" yaml:"headers" cgen:"marshal"`
+	Payload       *types.Union2[Object, AvroSchema] `json:"payload" yaml:"-" cgen:"marshal"` // Decoded according to schemaFormat
+	CorrelationID *CorrelationID                    `json:"correlationId" yaml:"correlationId"`
+	SchemaFormat  string                            `json:"schemaFormat" yaml:"schemaFormat"`
+	ContentType   string                            `json:"contentType" yaml:"contentType"`
+	Name          string                            `json:"name" yaml:"name"`
+	Title         string                            `json:"title" yaml:"title"`
+	Summary       string                            `json:"summary" yaml:"summary"`
+	Description   string                            `json:"description" yaml:"description"`
+	Tags          []Tag                             `json:"tags" yaml:"tags"`
+	ExternalDocs  *ExternalDocumentation            `json:"externalDocs" yaml:"externalDocs"`
+	Bindings      *MessageBindings                  `json:"bindings" yaml:"bindings"`
+	Examples      []MessageExample                  `json:"examples" yaml:"examples"`
+	Traits        []MessageTrait                    `json:"traits" yaml:"traits"`
+	OneOf         []Message                         `json:"oneOf" yaml:"oneOf"` // Only in operation message, means several messages
 
-	XGoName string `json:"x-go-name" yaml:"x-go-name"`
-	XIgnore bool   `json:"x-ignore" yaml:"x-ignore"`
+	XGoName          string `json:"x-go-name" yaml:"x-go-name"`
+	XIgnore          bool   `json:"x-ignore" yaml:"x-ignore"`
+	XContentEncoding string `json:"x-content-encoding" yaml:"x-content-encoding"`
 
 	Ref string `json:"$ref" yaml:"$ref"`
 }
 
+func (m *Message) UnmarshalYAML(value *yaml.Node) error {
+	type plainMessage Message
+	if err := value.Decode((*plainMessage)(m)); err != nil {
+		return err
+	}
+
+	payload := yamlMappingValue(value, "payload")
+	if payload == nil {
+		return nil
+	}
+	// AsyncAPI 3 Multi Format Schema Object: {"schemaFormat": "...", "schema": {...}}
+	if schemaFormat, schema := yamlMappingValue(payload, "schemaFormat"), yamlMappingValue(payload, "schema"); schemaFormat != nil && schema != nil {
+		m.SchemaFormat = schemaFormat.Value
+		payload = schema
+	}
+
+	// Payload schema is decoded according to its format
+	m.Payload = &types.Union2[Object, AvroSchema]{}
+	switch {
+	case isAvroSchemaFormat(m.SchemaFormat):
+		m.Payload.Selector = 1
+		return payload.Decode(&m.Payload.V1)
+	case isProtobufSchemaFormat(m.SchemaFormat) && yamlMappingValue(payload, "$ref") == nil:
+		// Protobuf messages are defined only in .proto files
+		return fmt.Errorf("line %d: protobuf payload must be a $ref to a message in .proto file", payload.Line)
+	}
+	return payload.Decode(&m.Payload.V0)
+}
+
 func (m Message) Compile(ctx *common.CompileContext) error {
-	ctx.RegisterNameTop(ctx.Stack.Top().PathItem)
+	if len(m.OneOf) > 0 {
+		// Not a message itself, just a list of messages, which are compiled separately
+		ctx.Logger.Trace("Message is oneOf", "count", len(m.OneOf))
+		ctx.RegisterNameTop(ctx.Stack.Top().PathItem)
+		return nil
+	}
+
+	name := ctx.Stack.Top().PathItem
+	if items := ctx.Stack.Items(); len(items) > 1 && items[len(items)-2].PathItem == "oneOf" {
+		// Item of oneOf list has only index as path item, so use a message name if any
+		name, _ = lo.Coalesce(m.MessageID, m.Name, name)
+	}
+	ctx.RegisterNameTop(name)
 	obj, err := m.build(ctx, ctx.Stack.Top().PathItem)
 	if err != nil {
 		return err
@@ -94,8 +138,16 @@ func (m Message) build(ctx *common.CompileContext, messageKey string) (common.Re
 		PayloadType:         m.getPayloadType(ctx),
 		HeadersFallbackType: &render.GoMap{KeyType: &render.GoSimple{Name: "string"}, ValueType: &render.GoSimple{Name: "any", IsIface: true}},
 	}
+	obj.MessageID, _ = lo.Coalesce(m.MessageID, m.Name, msgName)
 	obj.ContentType, _ = lo.Coalesce(m.ContentType, ctx.Storage.DefaultContentType())
+	obj.SchemaFormat = m.SchemaFormat
+	obj.ContentEncoding = m.XContentEncoding
+	obj.CloudEvents = ctx.CompileOpts.CloudEvents
+	obj.CloudEventsSource = ctx.CompileOpts.CloudEventsSource
 	ctx.Logger.Trace(fmt.Sprintf("Message content type is %q", obj.ContentType))
+	if !ctx.CompileOpts.NoEncodingPackage && !render.HasContentTypeCodec(ctx.CompileOpts.Codecs, obj.ContentType) {
+		ctx.Logger.Warn(diagnostics.CodeUnsupportedFeature, "No encoder is set for content type, set it by --encoder/--decoder cli flags or encoding.Register function", "contentType", obj.ContentType)
+	}
 
 	// Lookup servers after linking to figure out all protocols the message is used in
 	prms := lo.Map(ctx.Storage.ActiveServers(), func(item string, _ int) *render.Promise[*render.Server] {
@@ -131,6 +183,18 @@ func (m Message) build(ctx *common.CompileContext, messageKey string) (common.Re
 		ref := ctx.PathStackRef("bindings")
 		obj.BindingsPromise = render.NewPromise[*render.Bindings](ref, common.PromiseOriginInternal)
 		ctx.PutPromise(obj.BindingsPromise)
+
+		// Bindings may require the payload schema to register it in schema registry (Kafka). The schema may be
+		// defined in a separate .avsc or .proto document
+		obj.PayloadSchemaPromise = render.NewListCbPromise[common.Renderer](func(item common.Renderer, _ []string) bool {
+			switch item.(type) {
+			case *render.AvroSchema, *render.ProtobufMessage:
+				return true
+			}
+			return false
+		})
+		obj.PayloadSchemaPromise.InAllDocuments = true
+		ctx.PutListPromise(obj.PayloadSchemaPromise)
 	}
 
 	// Link to CorrelationID if any
@@ -156,20 +220,39 @@ func (m Message) setStructFields(ctx *common.CompileContext, langMessage *render
 		ctx.Logger.Trace("Message headers has `any` type")
 		fields = append(fields, render.GoStructField{Name: "Headers", Type: langMessage.HeadersFallbackType})
 	}
+	inFields := append([]render.GoStructField{}, fields...)
+	if langMessage.CloudEvents != common.CloudEventsNone {
+		typ := &render.GoSimple{Name: "CloudEvent", Import: ctx.RuntimeModule("")}
+		fields = append(fields, render.GoStructField{
+			Name:        "CloudEvent",
+			Description: "The CloudEvents attributes, the empty ones are filled on marshal",
+			Type:        typ,
+		})
+		inFields = append(inFields, render.GoStructField{
+			Name:        "CloudEvent",
+			Description: "The CloudEvents attributes of the received event",
+			Type:        typ,
+		})
+	}
 
 	langMessage.OutStruct.Fields = fields
-	langMessage.InStruct.Fields = fields
+	langMessage.InStruct.Fields = inFields
 }
 
 func (m Message) getPayloadType(ctx *common.CompileContext) common.GolangType {
 	if m.Payload != nil {
-		ctx.Logger.Trace("Message payload has a concrete type")
+		ctx.Logger.Trace("Message payload has a concrete type", "schemaFormat", m.SchemaFormat)
 		ref := ctx.PathStackRef("payload")
 		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
 		ctx.PutPromise(prm)
 		return prm
 	}
 
+	if contentType, _ := lo.Coalesce(m.ContentType, ctx.Storage.DefaultContentType()); render.IsRawContentType(contentType) {
+		ctx.Logger.Trace("Message payload is raw bytes", "contentType", contentType)
+		return &render.GoArray{ItemsType: &render.GoSimple{Name: "byte"}}
+	}
+
 	ctx.Logger.Trace("Message payload has `any` type")
 	return &render.GoSimple{Name: "any", IsIface: true}
 }
@@ -4,14 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"regexp"
 	"strconv"
 
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
 	"github.com/xcnt/go-asyncapi/internal/types"
 
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/xcnt/go-asyncapi/internal/common"
 	"github.com/xcnt/go-asyncapi/internal/render"
+	"github.com/xcnt/go-asyncapi/internal/specurl"
 
 	"github.com/xcnt/go-asyncapi/internal/utils"
 	"github.com/samber/lo"
@@ -27,9 +31,10 @@ type Object struct {
 	Contains             *Object                                    `json:"contains" yaml:"contains"`
 	Default              *types.Union2[json.RawMessage, yaml.Node]  `json:"default" yaml:"default"`
 	Definitions          types.OrderedMap[string, Object]           `json:"definitions" yaml:"definitions"`
+	Defs                 types.OrderedMap[string, Object]           `json:"$defs" yaml:"$defs" cgen:"directRender"`
 	Deprecated           *bool                                      `json:"deprecated" yaml:"deprecated"`
 	Description          string                                     `json:"description" yaml:"description"`
-	Discriminator        string                                     `json:"discriminator" yaml:"discriminator"`
+	Discriminator        *types.Union2[string, Discriminator]       `json:"discriminator" yaml:"discriminator"` // Property name or OpenAPI discriminator object
 	Else                 *Object                                    `json:"else" yaml:"else"`
 	Enum                 []types.Union2[json.RawMessage, yaml.Node] `json:"enum" yaml:"enum"`
 	Examples             []types.Union2[json.RawMessage, yaml.Node] `json:"examples" yaml:"examples"`
@@ -37,8 +42,8 @@ type Object struct {
 	ExclusiveMinimum     *types.Union2[bool, json.Number]           `json:"exclusiveMinimum" yaml:"exclusiveMinimum"`
 	ExternalDocs         *ExternalDocumentation                     `json:"externalDocs" yaml:"externalDocs"`
 	Format               string                                     `json:"format" yaml:"format"`
-	If                   *Object                                    `json:"if" yaml:"if"`
-	Items                *types.Union2[Object, []Object]            `json:"items" yaml:"items"`
+	If                   *Object                                    `json:"if" yaml:"if" cgen:"noCompile"`
+	Items                *types.Union3[Object, []Object, bool]      `json:"items" yaml:"items"`
 	MaxItems             *int                                       `json:"maxItems" yaml:"maxItems"`
 	MaxLength            *int                                       `json:"maxLength" yaml:"maxLength"`
 	MaxProperties        *int                                       `json:"maxProperties" yaml:"maxProperties"`
@@ -48,10 +53,12 @@ type Object struct {
 	MinProperties        *int                                       `json:"minProperties" yaml:"minProperties"`
 	Minimum              *json.Number                               `json:"minimum" yaml:"minimum"`
 	MultipleOf           *json.Number                               `json:"multipleOf" yaml:"multipleOf"`
-	Not                  *Object                                    `json:"not" yaml:"not"`
+	Not                  *Object                                    `json:"not" yaml:"not" cgen:"noCompile"`
+	Nullable             *bool                                      `json:"nullable" yaml:"nullable"` // OpenAPI 3.0
 	OneOf                []Object                                   `json:"oneOf" yaml:"oneOf" cgen:"directRender"`
 	Pattern              string                                     `json:"pattern" yaml:"pattern"`
 	PatternProperties    types.OrderedMap[string, Object]           `json:"patternProperties" yaml:"patternProperties"` // Mapping regex->schema
+	PrefixItems          []Object                                   `json:"prefixItems" yaml:"prefixItems"`
 	Properties           types.OrderedMap[string, Object]           `json:"properties" yaml:"properties"`
 	PropertyNames        *Object                                    `json:"propertyNames" yaml:"propertyNames"`
 	ReadOnly             *bool                                      `json:"readOnly" yaml:"readOnly"`
@@ -59,7 +66,9 @@ type Object struct {
 	Then                 *Object                                    `json:"then" yaml:"then"`
 	Title                string                                     `json:"title" yaml:"title"`
 	UniqueItems          *bool                                      `json:"uniqueItems" yaml:"uniqueItems"`
+	WriteOnly            *bool                                      `json:"writeOnly" yaml:"writeOnly"`
 
+	XEnumVarNames []string                                                  `json:"x-enum-varnames" yaml:"x-enum-varnames"`
 	XNullable     *bool                                                     `json:"x-nullable" yaml:"x-nullable"`
 	XGoType       *types.Union2[string, xGoType]                            `json:"x-go-type" yaml:"x-go-type"`
 	XGoName       string                                                    `json:"x-go-name" yaml:"x-go-name"`
@@ -71,7 +80,10 @@ type Object struct {
 }
 
 func (o Object) Compile(ctx *common.CompileContext) error {
-	ctx.RegisterNameTop(ctx.Stack.Top().PathItem)
+	// The allOf schemas merged to the parent struct don't add their index to the names of the types inside
+	if items := ctx.Stack.Items(); len(items) < 3 || !items[len(items)-3].MergesAllOf || items[len(items)-2].PathItem != "allOf" {
+		ctx.RegisterNameTop(ctx.Stack.Top().PathItem)
+	}
 	obj, err := o.build(ctx, ctx.Stack.Top().Flags, ctx.Stack.Top().PathItem)
 	if err != nil {
 		return err
@@ -98,9 +110,17 @@ func (o Object) build(ctx *common.CompileContext, flags map[common.SchemaTag]str
 		o.Type = o.getDefaultObjectType(ctx)
 	}
 
-	if len(o.OneOf)+len(o.AnyOf)+len(o.AllOf) > 0 {
+	if len(o.OneOf)+len(o.AnyOf) > 0 {
 		ctx.Logger.Trace("Object is union struct")
-		return o.buildUnionStruct(ctx, flags) // TODO: process other items that can be set along with oneof/anyof/allof
+		o.warnIgnoredConditions(ctx)
+		return o.buildUnionStruct(ctx, flags) // TODO: process other items that can be set along with oneof/anyof
+	}
+	if len(o.AllOf) > 0 {
+		ctx.Logger.Trace("Object is allOf struct")
+		top := ctx.Stack.Top()
+		top.MergesAllOf = true
+		ctx.Stack.ReplaceTop(top)
+		return o.buildAllOfStruct(ctx, flags)
 	}
 
 	typeName, nullable, err := o.getTypeName(ctx)
@@ -108,18 +128,21 @@ func (o Object) build(ctx *common.CompileContext, flags map[common.SchemaTag]str
 		return nil, err
 	}
 
-	// TODO: "type": { "enum": [ "residential", "business" ] }
 	// One type: { "type": "object" }
 	golangType, err := o.buildGolangType(ctx, flags, typeName)
 	if err != nil {
 		return nil, err
 	}
 
-	nullable = nullable || lo.FromPtr(o.XNullable)
+	nullable = nullable || lo.FromPtr(o.XNullable) || lo.FromPtr(o.Nullable)
 	if nullable {
-		ctx.Logger.Trace("Object is nullable, make it pointer")
 		_, directRender := flags[common.SchemaTagDirectRender]
-		golangType = &render.GoPointer{Type: golangType, DirectRender: directRender}
+		if ctx.CompileOpts.NullableFields == common.NullableFieldsOptional {
+			ctx.Logger.Trace("Object is nullable, make it optional")
+			return &render.GoOptional{Type: golangType, DirectRender: directRender}, nil
+		}
+		ctx.Logger.Trace("Object is nullable, make it pointer")
+		golangType = &render.GoPointer{Type: golangType, DirectRender: directRender, Nullable: true}
 	}
 	return golangType, nil
 }
@@ -149,7 +172,7 @@ func (o Object) getTypeName(ctx *common.CompileContext) (typeName string, nullab
 }
 
 func (o Object) buildGolangType(ctx *common.CompileContext, flags map[common.SchemaTag]string, typeName string) (golangType common.GolangType, err error) {
-	var aliasedType *render.GoSimple
+	var aliasedType common.GolangType
 
 	if typeName == "object" {
 		if o.XGoType != nil && !o.XGoType.V1.Embedded {
@@ -174,8 +197,19 @@ func (o Object) buildGolangType(ctx *common.CompileContext, flags map[common.Sch
 		return f, nil
 	}
 
+	o.warnIgnoredConditions(ctx)
 	switch typeName {
 	case "array":
+		if o.isTuple() {
+			ctx.Logger.Trace("Object is tuple")
+			ctx.Logger.NextCallLevel()
+			golangType, err = o.buildTupleStruct(ctx, flags)
+			ctx.Logger.PrevCallLevel()
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
 		ctx.Logger.Trace("Object is array")
 		ctx.Logger.NextCallLevel()
 		golangType, err = o.buildLangArray(ctx, flags)
@@ -190,11 +224,9 @@ func (o Object) buildGolangType(ctx *common.CompileContext, flags map[common.Sch
 		ctx.Logger.Trace("Object is bool")
 		aliasedType = &render.GoSimple{Name: "bool"}
 	case "integer":
-		// TODO: "format:"
 		ctx.Logger.Trace("Object is int")
 		aliasedType = &render.GoSimple{Name: "int"}
 	case "number":
-		// TODO: "format:"
 		ctx.Logger.Trace("Object is float64")
 		aliasedType = &render.GoSimple{Name: "float64"}
 	case "string":
@@ -205,6 +237,21 @@ func (o Object) buildGolangType(ctx *common.CompileContext, flags map[common.Sch
 	}
 
 	if aliasedType != nil {
+		var transparent bool
+		if o.Format != "" {
+			if t := formatType(ctx, typeName, o.Format); t != nil {
+				ctx.Logger.Trace("Object format type", "format", o.Format, "type", t.TypeName())
+				aliasedType = t
+				// Named type must keep the marshal methods of the format type, such as time.Time
+				s, ok := t.(*render.GoSimple)
+				transparent = !ok || s.Import != ""
+			}
+		}
+		if len(o.Enum) > 0 || o.Const != nil {
+			if enum := o.buildEnum(ctx, typeName, aliasedType); enum != nil {
+				return enum, nil
+			}
+		}
 		_, directRender := flags[common.SchemaTagDirectRender]
 		golangType = &render.GoTypeAlias{
 			BaseType: render.BaseType{
@@ -212,8 +259,11 @@ func (o Object) buildGolangType(ctx *common.CompileContext, flags map[common.Sch
 				Description:  o.Description,
 				DirectRender: directRender,
 				Import:       ctx.CurrentPackage(),
+				Constraints:  o.typeConstraints(ctx),
+				Default:      o.defaultValue(ctx),
 			},
 			AliasedType: aliasedType,
+			Transparent: transparent,
 		}
 	}
 
@@ -222,11 +272,15 @@ func (o Object) buildGolangType(ctx *common.CompileContext, flags map[common.Sch
 
 // getDefaultObjectType is backwards compatible, guessing the user intention when they didn't specify a type.
 func (o Object) getDefaultObjectType(ctx *common.CompileContext) *types.Union2[string, []string] {
+	if t := o.getDefaultEnumType(); t != nil {
+		ctx.Logger.Trace("Object type is empty, determined by enum values", "type", t.V0)
+		return t
+	}
 	switch {
 	case o.Ref == "" && o.Properties.Len() > 0:
 		ctx.Logger.Trace("Object type is empty, determined `object` because of `properties` presence")
 		return types.ToUnion2[string, []string]("object")
-	case o.Items != nil: // TODO: fix type when AllOf, AnyOf, OneOf
+	case o.Items != nil || len(o.PrefixItems) > 0: // TODO: fix type when AllOf, AnyOf, OneOf
 		ctx.Logger.Trace("Object type is empty, determined `array` because of `items` presence")
 		return types.ToUnion2[string, []string]("array")
 	default:
@@ -237,6 +291,10 @@ func (o Object) getDefaultObjectType(ctx *common.CompileContext) *types.Union2[s
 
 func (o Object) buildLangStruct(ctx *common.CompileContext, flags map[common.SchemaTag]string) (*render.GoStruct, error) {
 	_, directRender := flags[common.SchemaTagDirectRender]
+	conditions := o.buildConditions(ctx)
+	// Struct with properties, that are not struct fields, or with conditional schemas has unmarshal methods, so
+	// it can't be an anonymous struct
+	directRender = directRender || o.PatternProperties.Len() > 0 || o.AdditionalProperties != nil || conditions != nil
 	objName, _ := lo.Coalesce(o.XGoName, o.Title)
 	res := render.GoStruct{
 		BaseType: render.BaseType{
@@ -244,10 +302,21 @@ func (o Object) buildLangStruct(ctx *common.CompileContext, flags map[common.Sch
 			Description:  o.Description,
 			DirectRender: directRender,
 			Import:       ctx.CurrentPackage(),
+			Constraints:  o.typeConstraints(ctx),
+			Default:      o.defaultValue(ctx),
 		},
+		RequiredProperties: o.Required,
+		Conditions:         conditions,
 	}
 	// TODO: cache the object name in case any sub-schemas recursively reference it
 
+	if prop := o.discriminatorProperty(); prop != "" {
+		res.DiscriminatorField = prop
+		res.DiscriminatorValue = o.discriminatorValue(ctx)
+		res.DiscriminatorSchema = ctx.Stack.Top().PathItem
+		ctx.Logger.Trace("Object discriminator", "property", res.DiscriminatorField, "value", res.DiscriminatorValue)
+	}
+
 	var messagesPrm *render.ListPromise[*render.Message]
 	_, isMarshal := flags[common.SchemaTagMarshal]
 	if isMarshal {
@@ -268,32 +337,63 @@ func (o Object) buildLangStruct(ctx *common.CompileContext, flags map[common.Sch
 	// regular properties
 	for _, entry := range o.Properties.Entries() {
 		ctx.Logger.Trace("Object property", "name", entry.Key)
-		ref := ctx.PathStackRef("properties", entry.Key)
-		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
-		ctx.PutPromise(prm)
+		required := lo.Contains(o.Required, entry.Key)
+		res.Fields = append(res.Fields, entry.Value.buildPropertyField(ctx, entry.Key, required, messagesPrm, "properties", entry.Key))
+	}
 
-		var langObj common.GolangType = prm
-		if lo.Contains(o.Required, entry.Key) {
-			langObj = &render.GoPointer{Type: langObj}
+	// properties from if/then/else branches are optional, since they are present only if the condition is met
+	for _, branch := range o.conditionalBranches() {
+		for _, entry := range branch.B.Properties.Entries() {
+			if lo.ContainsBy(res.Fields, func(item render.GoStructField) bool { return item.MarshalName == entry.Key }) {
+				continue
+			}
+			ctx.Logger.Trace("Object conditional property", "name", entry.Key, "branch", branch.A)
+			res.Fields = append(res.Fields, entry.Value.buildPropertyField(ctx, entry.Key, false, messagesPrm, branch.A, "properties", entry.Key))
 		}
+	}
 
-		propName, _ := lo.Coalesce(entry.Value.XGoName, entry.Key)
-		xTags, xTagNames, xTagVals := entry.Value.xGoTagsInfo(ctx)
+	// patternProperties, the properties with names matching a regex
+	for i, entry := range o.PatternProperties.Entries() {
+		ctx.Logger.Trace("Object pattern properties", "pattern", entry.Key)
+		if _, err := regexp.Compile(entry.Key); err != nil {
+			ctx.Logger.Warn(diagnostics.CodeInvalidValue, "Pattern is not supported by Go regexp, ignore it", "pattern", entry.Key, "err", err)
+			continue
+		}
+		ref := ctx.PathStackRef("patternProperties", entry.Key)
+		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
+		ctx.PutPromise(prm)
+		fieldName := "PatternProperties"
+		if o.PatternProperties.Len() > 1 {
+			fieldName += strconv.Itoa(i + 1)
+		}
+		fieldName, _ = lo.Coalesce(entry.Value.XGoName, fieldName)
+		xTags, _, _ := entry.Value.xGoTagsInfo(ctx)
 		f := render.GoStructField{
-			Name:           utils.ToGolangName(propName, true),
-			MarshalName:    entry.Key,
-			Description:    entry.Value.Description,
-			Type:           langObj,
-			TagsSource:     messagesPrm,
-			ExtraTags:      xTags,
-			ExtraTagNames:  xTagNames,
-			ExtraTagValues: xTagVals,
+			Name:        utils.ToGolangName(fieldName, true),
+			Description: entry.Value.Description,
+			Type: &render.GoMap{
+				BaseType: render.BaseType{
+					Name:         ctx.GenerateObjName(fieldName, ""),
+					Description:  entry.Value.Description,
+					DirectRender: false,
+					Import:       ctx.CurrentPackage(),
+				},
+				KeyType:   &render.GoSimple{Name: "string"},
+				ValueType: prm,
+			},
+			ExtraTags:  customMarshalTags(xTags),
+			KeyPattern: entry.Key,
 		}
 		res.Fields = append(res.Fields, f)
 	}
 
-	// additionalProperties with typed sub-schema
-	// TODO: unmarshal extra fields somehow somewhere to AdditionalProperties field
+	// additionalProperties, the properties that are not set by other fields
+	hasPatterns := lo.ContainsBy(res.Fields, func(item render.GoStructField) bool { return item.KeyPattern != "" })
+	if o.AdditionalProperties == nil && hasPatterns {
+		// Absent additionalProperties allows any properties, so the ones not matching the patterns are kept
+		ctx.Logger.Trace("Object additional properties are absent, keep the properties not matching the patterns")
+		res.Fields = append(res.Fields, anyPropertiesField(ctx, o.Title))
+	}
 	if o.AdditionalProperties != nil {
 		propName, _ := lo.Coalesce(o.AdditionalProperties.V0.XGoName, o.Title)
 		switch o.AdditionalProperties.Selector {
@@ -302,7 +402,7 @@ func (o Object) buildLangStruct(ctx *common.CompileContext, flags map[common.Sch
 			ref := ctx.PathStackRef("additionalProperties")
 			prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
 			ctx.PutPromise(prm)
-			xTags, xTagNames, xTagVals := o.AdditionalProperties.V0.xGoTagsInfo(ctx)
+			xTags, _, _ := o.AdditionalProperties.V0.xGoTagsInfo(ctx)
 			f := render.GoStructField{
 				Name:        "AdditionalProperties",
 				Description: o.AdditionalProperties.V0.Description,
@@ -316,45 +416,176 @@ func (o Object) buildLangStruct(ctx *common.CompileContext, flags map[common.Sch
 					KeyType:   &render.GoSimple{Name: "string"},
 					ValueType: prm,
 				},
-				ExtraTags:      xTags,
-				ExtraTagNames:  xTagNames,
-				ExtraTagValues: xTagVals,
+				ExtraTags:  customMarshalTags(xTags),
+				Additional: true,
 			}
 			res.Fields = append(res.Fields, f)
 		case 1:
 			ctx.Logger.Trace("Object additional properties as boolean flag")
-			if o.AdditionalProperties.V1 { // "additionalProperties: true" -- allow any additional properties
-				valTyp := render.GoTypeAlias{
-					BaseType: render.BaseType{
-						Name:         ctx.GenerateObjName(propName, "AdditionalPropertiesValue"),
-						Description:  "",
-						DirectRender: false,
-						Import:       ctx.CurrentPackage(),
-					},
-					AliasedType: &render.GoSimple{Name: "any", IsIface: true},
-				}
-				f := render.GoStructField{
-					Name: "AdditionalProperties",
-					Type: &render.GoMap{
-						BaseType: render.BaseType{
-							Name:         ctx.GenerateObjName(propName, "AdditionalProperties"),
-							Description:  "",
-							DirectRender: false,
-							Import:       ctx.CurrentPackage(),
-						},
-						KeyType:   &render.GoSimple{Name: "string"},
-						ValueType: &valTyp,
-					},
-					TagsSource: messagesPrm,
-				}
-				res.Fields = append(res.Fields, f)
+			if !o.AdditionalProperties.V1 { // "additionalProperties: false" -- no additional properties allowed
+				res.NoAdditionalProperties = true
+				break
 			}
+			// "additionalProperties: true" -- allow any additional properties
+			res.Fields = append(res.Fields, anyPropertiesField(ctx, propName))
 		}
 	}
 
 	return &res, nil
 }
 
+// anyPropertiesField builds the additionalProperties map field, that keeps the properties of any value
+func anyPropertiesField(ctx *common.CompileContext, propName string) render.GoStructField {
+	valTyp := render.GoTypeAlias{
+		BaseType: render.BaseType{
+			Name:         ctx.GenerateObjName(propName, "AdditionalPropertiesValue"),
+			Description:  "",
+			DirectRender: false,
+			Import:       ctx.CurrentPackage(),
+		},
+		AliasedType: &render.GoSimple{Name: "any", IsIface: true},
+	}
+	return render.GoStructField{
+		Name: "AdditionalProperties",
+		Type: &render.GoMap{
+			BaseType: render.BaseType{
+				Name:         ctx.GenerateObjName(propName, "AdditionalProperties"),
+				Description:  "",
+				DirectRender: false,
+				Import:       ctx.CurrentPackage(),
+			},
+			KeyType:   &render.GoSimple{Name: "string"},
+			ValueType: &valTyp,
+		},
+		ExtraTags:  customMarshalTags(types.OrderedMap[string, string]{}),
+		Additional: true,
+	}
+}
+
+// buildPropertyField builds the struct field of the property, which schema is at the given path relative to the
+// current object. Required property is a value, optional property is a pointer or a value omitted if empty, depending
+// on --optional-fields.
+func (o Object) buildPropertyField(
+	ctx *common.CompileContext,
+	name string,
+	required bool,
+	messagesPrm *render.ListPromise[*render.Message],
+	path ...string,
+) render.GoStructField {
+	ref := ctx.PathStackRef(path...)
+	prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
+	ctx.PutPromise(prm)
+
+	// OpenAPI: readOnly and writeOnly properties are present in one direction only, so `required` doesn't apply
+	readOnly, writeOnly := lo.FromPtr(o.ReadOnly), lo.FromPtr(o.WriteOnly)
+	required = required && !readOnly && !writeOnly
+	var langObj common.GolangType = prm
+	if !required {
+		switch ctx.CompileOpts.OptionalFields {
+		case common.OptionalFieldsPointer:
+			langObj = &render.GoPointer{Type: langObj}
+		case common.OptionalFieldsOmitEmpty:
+			// The struct is a pointer anyway, since `omitempty` never omits it
+			langObj = &render.GoPointer{Type: langObj, OmitEmpty: true}
+		}
+	}
+
+	propName, _ := lo.Coalesce(o.XGoName, name)
+	xTags, xTagNames, xTagVals := o.xGoTagsInfo(ctx)
+	description := o.Description
+	if readOnly || writeOnly {
+		ctx.Logger.Trace("Object property is read-only or write-only", "readOnly", readOnly, "writeOnly", writeOnly)
+		description = utils.JoinNonemptyStrings("\n", description, lo.Ternary(readOnly, "Read-only", "Write-only"))
+	}
+	var fieldDefault any
+	if o.Ref != "" { // Inline schema keeps the default in its type, $ref may have the sibling default
+		fieldDefault = o.defaultValue(ctx)
+	}
+	return render.GoStructField{
+		Name:           utils.ToGolangName(propName, true),
+		MarshalName:    name,
+		Description:    description,
+		Type:           langObj,
+		TagsSource:     messagesPrm,
+		ExtraTags:      xTags,
+		ExtraTagNames:  xTagNames,
+		ExtraTagValues: xTagVals,
+		Required:       required,
+		OmitEmpty:      !required,
+		Default:        fieldDefault,
+	}
+}
+
+// discriminatorProperty returns the discriminator property name if schema has `discriminator`, either as a string or
+// as OpenAPI discriminator object
+func (o Object) discriminatorProperty() string {
+	if o.Discriminator == nil {
+		return ""
+	}
+	if o.Discriminator.Selector == 1 {
+		return o.Discriminator.V1.PropertyName
+	}
+	return o.Discriminator.V0
+}
+
+// discriminatorMapping returns the OpenAPI discriminator mapping, property value -> schema name or ref
+func (o Object) discriminatorMapping() types.OrderedMap[string, string] {
+	if o.Discriminator == nil || o.Discriminator.Selector != 1 {
+		return types.OrderedMap[string, string]{}
+	}
+	return o.Discriminator.V1.Mapping
+}
+
+// discriminatorValue returns the discriminator property value that denotes this schema, if OpenAPI discriminator
+// mapping points to this schema. Otherwise, the property const value or the schema name is used, that is determined
+// after linking, see render.GoStruct.
+func (o Object) discriminatorValue(ctx *common.CompileContext) string {
+	name := ctx.Stack.Top().PathItem
+	for _, e := range o.discriminatorMapping().Entries() {
+		if e.Value == name || specurl.Parse(e.Value).MatchPointer(ctx.PathStack()) {
+			return e.Key
+		}
+	}
+	return ""
+}
+
+// variantDiscriminators returns the discriminator property values denoting each oneOf and anyOf schema, that are the
+// keys from OpenAPI discriminator mapping, and the names of referenced schemas (empty for inline schemas). If
+// mapping doesn't mention a schema, its discriminator value is determined after linking: this is the const value of
+// its discriminator property if any, or its name otherwise, see render.UnionStruct.
+func (o Object) variantDiscriminators() (values [][]string, names []string) {
+	mapping := o.discriminatorMapping()
+	for _, item := range append(append([]Object{}, o.OneOf...), o.AnyOf...) {
+		if item.Ref == "" {
+			values = append(values, nil)
+			names = append(names, "")
+			continue
+		}
+		schemaName := path.Base(item.Ref)
+		values = append(values, lo.FilterMap(mapping.Entries(), func(e lo.Entry[string, string], _ int) (string, bool) {
+			return e.Key, e.Value == item.Ref || e.Value == schemaName
+		}))
+		names = append(names, schemaName)
+	}
+	return
+}
+
+// buildAllOfStruct builds the struct from the schema own properties, the allOf schemas are merged to it by linker,
+// when all refs are resolved
+func (o Object) buildAllOfStruct(ctx *common.CompileContext, flags map[common.SchemaTag]string) (*render.GoStruct, error) {
+	res, err := o.buildLangStruct(ctx, flags)
+	if err != nil {
+		return nil, err
+	}
+	res.AllOf = lo.Map(o.AllOf, func(item Object, index int) render.AllOfPart {
+		ref := ctx.PathStackRef("allOf", strconv.Itoa(index))
+		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
+		ctx.PutPromise(prm)
+		return render.AllOfPart{Type: prm, Inline: item.Ref == ""}
+	})
+	return res, nil
+}
+
 func (o Object) buildLangArray(ctx *common.CompileContext, flags map[common.SchemaTag]string) (*render.GoArray, error) {
 	_, directRender := flags[common.SchemaTagDirectRender]
 	objName, _ := lo.Coalesce(o.XGoName, o.Title)
@@ -364,6 +595,8 @@ func (o Object) buildLangArray(ctx *common.CompileContext, flags map[common.Sche
 			Description:  o.Description,
 			DirectRender: directRender,
 			Import:       ctx.CurrentPackage(),
+			Constraints:  o.typeConstraints(ctx),
+			Default:      o.defaultValue(ctx),
 		},
 		ItemsType: nil,
 	}
@@ -375,8 +608,8 @@ func (o Object) buildLangArray(ctx *common.CompileContext, flags map[common.Sche
 		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
 		ctx.PutPromise(prm)
 		res.ItemsType = prm
-	case o.Items == nil || o.Items.Selector == 1: // No items or Several types for each item sequentially
-		ctx.Logger.Trace("Object items (zero or several types)")
+	default: // No items or any items
+		ctx.Logger.Trace("Object items (any type)")
 		valTyp := render.GoTypeAlias{
 			BaseType: render.BaseType{
 				Name:         ctx.GenerateObjName(objName, "ItemsItemValue"),
@@ -401,18 +634,106 @@ func (o Object) buildLangArray(ctx *common.CompileContext, flags map[common.Sche
 	return &res, nil
 }
 
+// isTuple returns true if array items have different types depending on their position
+func (o Object) isTuple() bool {
+	return len(o.PrefixItems) > 0 || o.Items != nil && o.Items.Selector == 1
+}
+
+// buildTupleStruct builds the struct, which fields are the array items in order. Schema may be either draft 2020-12
+// (prefixItems, items for the rest of items) or earlier (items as array, additionalItems for the rest).
+func (o Object) buildTupleStruct(ctx *common.CompileContext, flags map[common.SchemaTag]string) (*render.GoStruct, error) {
+	objName, _ := lo.Coalesce(o.XGoName, o.Title)
+	res := render.GoStruct{
+		BaseType: render.BaseType{
+			Name:        ctx.GenerateObjName(objName, ""),
+			Description: o.Description,
+			// Tuple has marshal methods, so it can't be an anonymous struct
+			DirectRender: true,
+			Import:       ctx.CurrentPackage(),
+			Constraints:  o.typeConstraints(ctx),
+			Default:      o.defaultValue(ctx),
+		},
+		Tuple: &render.TupleItems{},
+	}
+
+	itemsKey, items, restKey, rest := "prefixItems", o.PrefixItems, "items", o.Items
+	if len(items) == 0 {
+		itemsKey, items, restKey = "items", o.Items.V1, "additionalItems"
+		rest = nil
+		if o.AdditionalItems != nil {
+			rest = &types.Union3[Object, []Object, bool]{V0: o.AdditionalItems.V0, V2: o.AdditionalItems.V1, Selector: lo.Ternary[uint8](o.AdditionalItems.Selector == 0, 0, 2)}
+		}
+	}
+	// Like in JSON Schema, the items are optional unless minItems requires them
+	if o.MinItems != nil {
+		res.Tuple.MinItems = lo.Clamp(*o.MinItems, 0, len(items))
+	}
+
+	for i, item := range items {
+		ctx.Logger.Trace("Object tuple item", "index", i)
+		ref := ctx.PathStackRef(itemsKey, strconv.Itoa(i))
+		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
+		ctx.PutPromise(prm)
+		var typ common.GolangType = prm
+		if i >= res.Tuple.MinItems {
+			typ = &render.GoPointer{Type: prm} // Optional item
+		}
+		name, _ := lo.Coalesce(item.XGoName, item.Title, "Item"+strconv.Itoa(i))
+		res.Fields = append(res.Fields, render.GoStructField{
+			Name:        utils.ToGolangName(name, true),
+			Description: item.Description,
+			Type:        typ,
+		})
+	}
+
+	var restType common.GolangType
+	switch {
+	case rest == nil: // The rest of items are ignored
+	case rest.Selector == 0:
+		ctx.Logger.Trace("Object tuple rest items as an object")
+		ref := ctx.PathStackRef(restKey)
+		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
+		ctx.PutPromise(prm)
+		restType = prm
+	case rest.Selector == 2 && rest.V2: // Any items
+		restType = &render.GoSimple{Name: "any", IsIface: true}
+	case rest.Selector == 2 && !rest.V2: // No more items allowed
+		res.Tuple.NoAdditional = true
+	}
+	if restType != nil {
+		res.Tuple.Additional = true
+		res.Fields = append(res.Fields, render.GoStructField{
+			Name: "AdditionalItems",
+			Type: &render.GoArray{
+				BaseType: render.BaseType{
+					Name:         ctx.GenerateObjName(objName, "AdditionalItems"),
+					DirectRender: false,
+					Import:       ctx.CurrentPackage(),
+				},
+				ItemsType: restType,
+			},
+		})
+	}
+
+	return &res, nil
+}
+
 func (o Object) buildUnionStruct(ctx *common.CompileContext, flags map[common.SchemaTag]string) (*render.UnionStruct, error) {
 	_, directRender := flags[common.SchemaTagDirectRender]
+	polymorphic := len(o.AllOf) == 0
 	objName, _ := lo.Coalesce(o.XGoName, o.Title)
 	res := render.UnionStruct{
 		GoStruct: render.GoStruct{
 			BaseType: render.BaseType{
-				Name:         ctx.GenerateObjName(objName, ""),
-				Description:  o.Description,
-				DirectRender: directRender,
+				Name:        ctx.GenerateObjName(objName, ""),
+				Description: o.Description,
+				// Polymorphic union has methods, so it can't be an anonymous struct
+				DirectRender: directRender || polymorphic,
 				Import:       ctx.CurrentPackage(),
+				Constraints:  o.typeConstraints(ctx),
 			},
 		},
+		Polymorphic: polymorphic,
 	}
 
 	// Collect all messages to retrieve struct field tags
@@ -422,6 +743,12 @@ func (o Object) buildUnionStruct(ctx *common.CompileContext, flags map[common.Sc
 	})
 	ctx.PutListPromise(messagesPrm)
 
+	if prop := o.discriminatorProperty(); prop != "" && len(o.OneOf)+len(o.AnyOf) > 0 {
+		ctx.Logger.Trace("Object union discriminator", "property", prop)
+		res.VariantDiscriminator = prop
+		res.VariantValues, res.VariantNames = o.variantDiscriminators()
+	}
+
 	res.Fields = lo.Times(len(o.OneOf), func(index int) render.GoStructField {
 		ref := ctx.PathStackRef("oneOf", strconv.Itoa(index))
 		prm := render.NewGolangTypePromise(ref, common.PromiseOriginInternal)
@@ -444,6 +771,20 @@ func (o Object) buildUnionStruct(ctx *common.CompileContext, flags map[common.Sc
 	return &res, nil
 }
 
+// customMarshalTags returns the tags for a map field with properties that are not struct fields. Such field is
+// marshaled by the custom struct methods, so it's excluded from marshaling by struct tags. It's excluded from XML
+// as well, because encoding/xml doesn't support maps.
+func customMarshalTags(xTags types.OrderedMap[string, string]) types.OrderedMap[string, string] {
+	var res types.OrderedMap[string, string]
+	res.Set("json", "-")
+	res.Set("yaml", "-")
+	res.Set("xml", "-")
+	for _, e := range xTags.Entries() {
+		res.Set(e.Key, e.Value)
+	}
+	return res
+}
+
 func (o Object) xGoTagsInfo(ctx *common.CompileContext) (xTags types.OrderedMap[string, string], xTagNames []string, xTagValues []string) {
 	if o.XGoTags != nil {
 		switch o.XGoTags.Selector {
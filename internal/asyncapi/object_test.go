@@ -0,0 +1,75 @@
+package asyncapi
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestVariantDiscriminators(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     string
+		wantValues [][]string
+		wantNames  []string
+	}{
+		{
+			"schema names",
+			`{oneOf: [{$ref: "#/components/schemas/Cat"}, {$ref: "#/components/schemas/Dog"}], discriminator: petType}`,
+			[][]string{nil, nil},
+			[]string{"Cat", "Dog"},
+		},
+		{
+			"mapping",
+			`{oneOf: [{$ref: "#/components/schemas/Cat"}, {$ref: "#/components/schemas/Dog"}],
+			  discriminator: {propertyName: petType, mapping: {cat: "#/components/schemas/Cat", kitty: Cat}}}`,
+			[][]string{{"cat", "kitty"}, nil},
+			[]string{"Cat", "Dog"},
+		},
+		{
+			"inline",
+			`{anyOf: [{properties: {kind: {const: circle}}}, {properties: {kind: {type: string}}}], discriminator: kind}`,
+			[][]string{nil, nil},
+			[]string{"", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o Object
+			if err := yaml.Unmarshal([]byte(tt.schema), &o); err != nil {
+				t.Fatal(err)
+			}
+			values, names := o.variantDiscriminators()
+			// Values of schemas without mapping are determined after linking, so nil and empty lists are the same
+			if fmt.Sprint(values) != fmt.Sprint(tt.wantValues) || !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("expect %v %q, got %v %q", tt.wantValues, tt.wantNames, values, names)
+			}
+		})
+	}
+}
+
+func TestIsTuple(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   bool
+	}{
+		{"items schema", `{type: array, items: {type: string}}`, false},
+		{"items true", `{type: array, items: true}`, false},
+		{"items list", `{type: array, items: [{type: string}, {type: integer}], additionalItems: false}`, true},
+		{"prefixItems", `{type: array, prefixItems: [{type: string}], items: false}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o Object
+			if err := yaml.Unmarshal([]byte(tt.schema), &o); err != nil {
+				t.Fatal(err)
+			}
+			if got := o.isTuple(); got != tt.want {
+				t.Errorf("expect %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package asyncapi
+
+import (
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+// OpenAPI is an OpenAPI 3.x document. Only schemas are taken from it, so that they can be referenced from other
+// documents, e.g. `openapi.yaml#/components/schemas/Order`.
+type OpenAPI struct {
+	OpenAPI    string                           `json:"openapi" yaml:"openapi"`
+	Components OpenAPIComponents                `json:"components" yaml:"components"`
+	Defs       types.OrderedMap[string, Object] `json:"$defs" yaml:"$defs" cgen:"directRender,components,pkgScope=models,marshal"` // OpenAPI 3.1
+}
+
+func (o OpenAPI) Compile(ctx *common.CompileContext) error {
+	ctx.Logger.Trace("OpenAPI document", "version", o.OpenAPI)
+	return nil
+}
+
+type OpenAPIComponents struct {
+	Schemas types.OrderedMap[string, Object] `json:"schemas" yaml:"schemas" cgen:"directRender,components,pkgScope=models,marshal"`
+}
+
+// Discriminator is the OpenAPI discriminator object. See https://spec.openapis.org/oas/v3.0.3#discriminator-object
+type Discriminator struct {
+	PropertyName string                           `json:"propertyName" yaml:"propertyName"`
+	Mapping      types.OrderedMap[string, string] `json:"mapping" yaml:"mapping"` // Property value -> schema name or ref
+}
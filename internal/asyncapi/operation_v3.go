@@ -0,0 +1,56 @@
+package asyncapi
+
+const (
+	operationActionSend    = "send"
+	operationActionReceive = "receive"
+)
+
+// OperationV3 is an operation object from AsyncAPI 3.x document. Unlike AsyncAPI 2.x, operations are defined in a
+// separate `operations` document section and refer to a channel they are applied to.
+type OperationV3 struct {
+	Action       string                 `json:"action" yaml:"action"`
+	Channel      Reference              `json:"channel" yaml:"channel"`
+	Title        string                 `json:"title" yaml:"title"`
+	Summary      string                 `json:"summary" yaml:"summary"`
+	Description  string                 `json:"description" yaml:"description"`
+	Tags         []Tag                  `json:"tags" yaml:"tags"`
+	ExternalDocs *ExternalDocumentation `json:"externalDocs" yaml:"externalDocs"`
+	Bindings     *OperationBinding      `json:"bindings" yaml:"bindings"`
+	Messages     []Reference            `json:"messages" yaml:"messages"`
+	Reply        *OperationReplyV3      `json:"reply" yaml:"reply"`
+
+	XIgnore bool `json:"x-ignore" yaml:"x-ignore"`
+
+	Ref string `json:"$ref" yaml:"$ref"`
+}
+
+// OperationReplyV3 describes the reply part of request/reply operation
+type OperationReplyV3 struct {
+	Address  *OperationReplyAddressV3 `json:"address" yaml:"address"`
+	Channel  *Reference               `json:"channel" yaml:"channel"`
+	Messages []Reference              `json:"messages" yaml:"messages"`
+
+	Ref string `json:"$ref" yaml:"$ref"`
+}
+
+type OperationReplyAddressV3 struct {
+	Description string `json:"description" yaml:"description"`
+	Location    string `json:"location" yaml:"location"`
+
+	Ref string `json:"$ref" yaml:"$ref"`
+}
+
+// Reference is an object that only contains a reference to another object
+type Reference struct {
+	Ref string `json:"$ref" yaml:"$ref"`
+}
+
+// channelOperationV3 is an operation bound to a particular channel. It is collected from `operations` document
+// section on the root object compilation, before channels get compiled.
+type channelOperationV3 struct {
+	OperationKey string
+	Action       string
+	Description  string
+	BindingsRef  string   // Empty if operation has no bindings
+	MessageRefs  []string // Empty means "all messages of the channel"
+}
@@ -0,0 +1,255 @@
+package asyncapi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/protobuf"
+	"github.com/xcnt/go-asyncapi/internal/render"
+	"github.com/xcnt/go-asyncapi/internal/types"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+const protobufSchemaFormatPrefix = "application/vnd.google.protobuf"
+
+// isProtobufSchemaFormat returns true if schemaFormat denotes the protobuf definitions, such as
+// "application/vnd.google.protobuf;version=3"
+func isProtobufSchemaFormat(schemaFormat string) bool {
+	return strings.HasPrefix(schemaFormat, protobufSchemaFormatPrefix)
+}
+
+// ProtobufFile is a standalone .proto file with message definitions. Only proto3 syntax is supported. Messages and
+// enums are referenced by their names, e.g. `user.proto#/User` or `user.proto#/User/Address` for nested ones.
+type ProtobufFile struct {
+	file    *protobuf.File
+	source  string // File contents, registered as message schema in schema registry
+	docName string
+}
+
+// NewProtobufDocument returns the root object of a .proto file
+func NewProtobufDocument(docName string) *ProtobufFile {
+	return &ProtobufFile{docName: docName}
+}
+
+func (p *ProtobufFile) UnmarshalText(data []byte) error {
+	f, err := protobuf.Parse(data)
+	if err != nil {
+		return fmt.Errorf("parse proto file %s: %w", p.docName, err)
+	}
+	p.file = f
+	p.source = string(data)
+	return nil
+}
+
+func (p ProtobufFile) Compile(ctx *common.CompileContext) error {
+	if p.file == nil {
+		return types.CompileError{Err: errors.New("empty proto file"), Path: ctx.PathStackRef()}
+	}
+	pkgName := PackageScopeModels
+	if reusePkg, ok := ctx.CompileOpts.ReusePackages[pkgName]; ok {
+		pkgName = reusePkg
+	}
+	// Struct fields get the tags for all content types the messages use, besides the `protobuf` tag
+	messagesPrm := render.NewListCbPromise[*render.Message](func(item common.Renderer, _ []string) bool {
+		_, ok := item.(*render.Message)
+		return ok
+	})
+	ctx.PutListPromise(messagesPrm)
+
+	b := protobufBuilder{
+		ctx:         ctx,
+		pkgName:     pkgName,
+		protoPkg:    p.file.Package,
+		source:      p.source,
+		messagesPrm: messagesPrm,
+		types:       make(map[string]common.GolangType),
+		enums:       make(map[string]bool),
+	}
+	ctx.Logger.Trace("Protobuf file", "name", p.docName, "package", p.file.Package)
+	// Declare all types first, because the fields may reference the types declared later in file
+	b.declare(nil, p.file.Messages, p.file.Enums)
+	return b.buildMessages(nil, nil, p.file.Messages)
+}
+
+type protobufBuilder struct {
+	ctx         *common.CompileContext
+	pkgName     string
+	protoPkg    string
+	source      string
+	messagesPrm *render.ListPromise[*render.Message]
+	types       map[string]common.GolangType // Messages and enums by full name inside the file, e.g. "Outer.Inner"
+	enums       map[string]bool              // Full names of enums
+}
+
+func (b *protobufBuilder) declare(scope []string, messages []*protobuf.Message, enums []*protobuf.Enum) {
+	for _, e := range enums {
+		path := append(append([]string{}, scope...), e.Name)
+		b.ctx.Logger.Trace("Protobuf enum", "name", strings.Join(path, "."))
+		res := &render.GoTypeAlias{
+			BaseType: render.BaseType{
+				Name:         utils.ToGolangName(strings.Join(path, "_"), true),
+				DirectRender: true,
+				Import:       b.pkgName,
+			},
+			AliasedType: &render.GoSimple{Name: "int32"},
+		}
+		b.types[strings.Join(path, ".")] = res
+		b.enums[strings.Join(path, ".")] = true
+		b.ctx.Storage.AddObject(b.pkgName, path, res)
+
+		protoEnum := render.ProtobufEnum{Type: res}
+		valuePrefix := utils.ToGolangName(e.Name, true)
+		for _, v := range e.Values {
+			protoEnum.Values = append(protoEnum.Values, render.ProtobufEnumValue{
+				Name:      res.Name + strings.TrimPrefix(utils.ToGolangName(v.Name, true), valuePrefix),
+				ProtoName: v.Name,
+				Number:    v.Number,
+			})
+		}
+		b.ctx.Storage.AddObject(b.pkgName, append(path, "$protobuf"), &protoEnum)
+	}
+	for _, m := range messages {
+		path := append(append([]string{}, scope...), m.Name)
+		b.ctx.Logger.Trace("Protobuf message", "name", strings.Join(path, "."))
+		res := &render.GoStruct{
+			BaseType: render.BaseType{
+				Name:         utils.ToGolangName(strings.Join(path, "_"), true),
+				DirectRender: true,
+				Import:       b.pkgName,
+			},
+		}
+		b.types[strings.Join(path, ".")] = res
+		b.ctx.Storage.AddObject(b.pkgName, path, res)
+		b.declare(path, m.Messages, m.Enums)
+	}
+}
+
+func (b *protobufBuilder) buildMessages(scope []string, scopeIndexes []int, messages []*protobuf.Message) error {
+	for i, m := range messages {
+		path := append(append([]string{}, scope...), m.Name)
+		indexes := append(append([]int{}, scopeIndexes...), i)
+		strct := b.types[strings.Join(path, ".")].(*render.GoStruct)
+		protoMessage := render.ProtobufMessage{
+			Struct:         strct,
+			FullName:       strings.Join(append([]string{b.protoPkg}, path...), "."),
+			MessageIndexes: indexes,
+			FileSchema:     b.source,
+		}
+		if b.protoPkg == "" {
+			protoMessage.FullName = strings.Join(path, ".")
+		}
+
+		for _, f := range m.Fields {
+			b.ctx.Logger.Trace("Protobuf field", "message", strings.Join(path, "."), "name", f.Name)
+			field, golangType, err := b.buildField(path, f)
+			if err != nil {
+				return err
+			}
+			var tags types.OrderedMap[string, string]
+			tags.Set("protobuf", strconv.Itoa(f.Number))
+			var description string
+			if f.Oneof != "" {
+				description = "Oneof " + f.Oneof
+			}
+			strct.Fields = append(strct.Fields, render.GoStructField{
+				Name:        field.FieldName,
+				MarshalName: f.Name,
+				Description: description,
+				Type:        golangType,
+				TagsSource:  b.messagesPrm,
+				ExtraTags:   tags,
+			})
+			protoMessage.Fields = append(protoMessage.Fields, field)
+		}
+		b.ctx.Storage.AddObject(b.pkgName, append(path, "$protobuf"), &protoMessage)
+
+		if err := b.buildMessages(path, indexes, m.Messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *protobufBuilder) buildField(scope []string, f *protobuf.Field) (render.ProtobufField, common.GolangType, error) {
+	res := render.ProtobufField{
+		FieldName: utils.ToGolangName(f.Name, true),
+		Number:    f.Number,
+		Repeated:  f.Repeated,
+		Oneof:     f.Oneof,
+	}
+	elemType, kind, err := b.resolveType(scope, f.Type)
+	if err != nil {
+		return res, nil, err
+	}
+	res.Kind, res.Type = kind, elemType
+
+	switch {
+	case f.MapKey != "":
+		keyType, keyKind, err := b.resolveType(scope, f.MapKey)
+		if err != nil {
+			return res, nil, err
+		}
+		if _, ok := protobuf.ScalarGoType(keyKind); !ok || keyKind == "bytes" || keyKind == "float" || keyKind == "double" {
+			return res, nil, types.CompileError{
+				Err:  fmt.Errorf("field %s: type %s cannot be a map key", f.Name, f.MapKey),
+				Path: b.ctx.PathStackRef(),
+			}
+		}
+		res.MapKey = &render.ProtobufField{Number: 1, Kind: keyKind, Type: keyType}
+		res.MapValue = &render.ProtobufField{Number: 2, Kind: kind, Type: elemType}
+		res.Kind, res.Type = "", nil
+		return res, &render.GoMap{KeyType: keyType, ValueType: elemType}, nil
+	case f.Repeated:
+		return res, &render.GoArray{ItemsType: elemType}, nil
+	case kind == render.ProtobufKindMessage:
+		// Singular message field is always a pointer, like in the official protobuf generated code
+		res.Presence = true
+		return res, &render.GoPointer{Type: elemType}, nil
+	case f.Optional || f.Oneof != "":
+		// Scalar field with explicit presence. Bytes are nil-able themselves
+		res.Presence = true
+		if kind == "bytes" {
+			return res, elemType, nil
+		}
+		return res, &render.GoPointer{Type: elemType}, nil
+	}
+	return res, elemType, nil
+}
+
+// resolveType returns the Go type and field kind of protobuf type name. Message and enum names are resolved
+// according to protobuf scoping rules: the innermost scope is searched first.
+func (b *protobufBuilder) resolveType(scope []string, typeName string) (common.GolangType, string, error) {
+	if t, ok := protobuf.ScalarGoType(typeName); ok {
+		if typeName == "bytes" {
+			return &render.GoArray{ItemsType: &render.GoSimple{Name: "byte"}}, typeName, nil
+		}
+		return &render.GoSimple{Name: t}, typeName, nil
+	}
+
+	var candidates []string
+	if strings.HasPrefix(typeName, ".") { // Fully-qualified name
+		candidates = []string{strings.TrimPrefix(typeName[1:], b.protoPkg+".")}
+	} else {
+		for i := len(scope); i >= 0; i-- {
+			candidates = append(candidates, strings.Join(append(append([]string{}, scope[:i]...), typeName), "."))
+		}
+		if b.protoPkg != "" && strings.HasPrefix(typeName, b.protoPkg+".") {
+			candidates = append(candidates, strings.TrimPrefix(typeName, b.protoPkg+"."))
+		}
+	}
+	for _, c := range candidates {
+		if t, ok := b.types[c]; ok {
+			b.ctx.Logger.Trace("Protobuf type resolved", "type", typeName, "fullName", c)
+			return t, lo.Ternary(b.enums[c], render.ProtobufKindEnum, render.ProtobufKindMessage), nil
+		}
+	}
+	return nil, "", types.CompileError{
+		Err:  fmt.Errorf("unknown protobuf type %q (imports are not supported)", typeName),
+		Path: b.ctx.PathStackRef(),
+	}
+}
@@ -1,6 +1,7 @@
 package asyncapi
 
 import (
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
 	"github.com/xcnt/go-asyncapi/internal/specurl"
 	"github.com/samber/lo"
 
@@ -102,7 +103,7 @@ func (s Server) build(ctx *common.CompileContext, serverKey string) (common.Rend
 
 	protoBuilder, ok := ProtocolBuilders[s.Protocol]
 	if !ok {
-		ctx.Logger.Warn("Skip unsupported server protocol", "proto", s.Protocol)
+		ctx.Logger.Warn(diagnostics.CodeUnsupportedProto, "Skip unsupported server protocol", "proto", s.Protocol)
 	} else {
 		var err error
 		ctx.Logger.Trace("Server", "proto", protoBuilder.ProtocolName())
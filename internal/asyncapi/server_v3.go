@@ -0,0 +1,53 @@
+package asyncapi
+
+import (
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+// ServerV3 is a server object from AsyncAPI 3.x document
+type ServerV3 struct {
+	Host            string                                   `json:"host" yaml:"host"`
+	Protocol        string                                   `json:"protocol" yaml:"protocol"`
+	ProtocolVersion string                                   `json:"protocolVersion" yaml:"protocolVersion"`
+	Pathname        string                                   `json:"pathname" yaml:"pathname"`
+	Title           string                                   `json:"title" yaml:"title"`
+	Summary         string                                   `json:"summary" yaml:"summary"`
+	Description     string                                   `json:"description" yaml:"description"`
+	Variables       types.OrderedMap[string, ServerVariable] `json:"variables" yaml:"variables"`
+	Tags            []Tag                                    `json:"tags" yaml:"tags"`
+	Bindings        *ServerBindings                          `json:"bindings" yaml:"bindings"`
+
+	XGoName string `json:"x-go-name" yaml:"x-go-name"`
+	XIgnore bool   `json:"x-ignore" yaml:"x-ignore"`
+
+	Ref string `json:"$ref" yaml:"$ref"`
+}
+
+func (s ServerV3) Compile(ctx *common.CompileContext) error {
+	ctx.RegisterNameTop(ctx.Stack.Top().PathItem)
+	obj, err := s.toServer().build(ctx, ctx.Stack.Top().PathItem)
+	if err != nil {
+		return err
+	}
+	ctx.PutObject(obj)
+
+	return nil
+}
+
+// toServer converts the server to AsyncAPI 2.x representation, which is the same in terms of code generation
+// except the url, that is split into host and pathname in 3.x
+func (s ServerV3) toServer() *Server {
+	return &Server{
+		URL:             s.Host + s.Pathname,
+		Protocol:        s.Protocol,
+		ProtocolVersion: s.ProtocolVersion,
+		Description:     s.Description,
+		Variables:       s.Variables,
+		Tags:            s.Tags,
+		Bindings:        s.Bindings,
+		XGoName:         s.XGoName,
+		XIgnore:         s.XIgnore,
+		Ref:             s.Ref,
+	}
+}
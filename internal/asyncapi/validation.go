@@ -0,0 +1,78 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/render"
+)
+
+// buildConstraints returns the validation keywords of schema, that are checked by the generated Validate method.
+// The keywords with invalid values are skipped with a warning.
+func (o Object) buildConstraints(ctx *common.CompileContext) *render.Constraints {
+	res := render.Constraints{
+		MinLength:     o.MinLength,
+		MaxLength:     o.MaxLength,
+		MinItems:      o.MinItems,
+		MaxItems:      o.MaxItems,
+		UniqueItems:   lo.FromPtr(o.UniqueItems),
+		MinProperties: o.MinProperties,
+		MaxProperties: o.MaxProperties,
+	}
+
+	if o.Pattern != "" {
+		// JSON Schema uses ECMA 262 regex dialect, Go regexp understands the most common subset of it
+		if _, err := regexp.Compile(o.Pattern); err != nil {
+			ctx.Logger.Warn(diagnostics.CodeInvalidValue, "Pattern is not supported by Go regexp, ignore it", "pattern", o.Pattern, "err", err)
+		} else {
+			res.Pattern = o.Pattern
+		}
+	}
+
+	checkNumber := func(field string, n *json.Number) *json.Number {
+		if n == nil {
+			return nil
+		}
+		if _, err := n.Float64(); err != nil {
+			ctx.Logger.Warn(diagnostics.CodeInvalidValue, "Value is not a number, ignore it", "field", field, "value", n.String())
+			return nil
+		}
+		return n
+	}
+	res.Minimum = checkNumber("minimum", o.Minimum)
+	res.Maximum = checkNumber("maximum", o.Maximum)
+	if m := checkNumber("multipleOf", o.MultipleOf); m != nil {
+		if v, _ := m.Float64(); v > 0 {
+			res.MultipleOf = m
+		} else {
+			ctx.Logger.Warn(diagnostics.CodeInvalidValue, "multipleOf must be greater than 0, ignore it", "value", m.String())
+		}
+	}
+
+	// Draft 4 has boolean exclusiveMinimum/exclusiveMaximum, that make minimum/maximum exclusive. Since draft 6,
+	// they are numbers themselves
+	if o.ExclusiveMinimum != nil {
+		if o.ExclusiveMinimum.Selector == 0 {
+			if o.ExclusiveMinimum.V0 {
+				res.Minimum, res.ExclusiveMinimum = nil, res.Minimum
+			}
+		} else {
+			res.ExclusiveMinimum = checkNumber("exclusiveMinimum", &o.ExclusiveMinimum.V1)
+		}
+	}
+	if o.ExclusiveMaximum != nil {
+		if o.ExclusiveMaximum.Selector == 0 {
+			if o.ExclusiveMaximum.V0 {
+				res.Maximum, res.ExclusiveMaximum = nil, res.Maximum
+			}
+		} else {
+			res.ExclusiveMaximum = checkNumber("exclusiveMaximum", &o.ExclusiveMaximum.V1)
+		}
+	}
+
+	return &res
+}
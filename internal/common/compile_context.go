@@ -24,8 +24,9 @@ type CompilationStorage interface {
 	AddObject(pkgName string, stack []string, obj Renderer)
 	RegisterProtocol(protoName string)
 	AddExternalSpecPath(specPath *specurl.URL)
-	AddPromise(p ObjectPromise)
+	AddPromise(p ObjectPromise, stack []string)
 	AddListPromise(p ObjectListPromise)
+	SourcePosition(path []string) *types.SourcePosition
 
 	SetDefaultContentType(contentType string)
 	DefaultContentType() string
@@ -48,7 +49,48 @@ type CompileOpts struct {
 	RuntimeModule       string
 	GeneratePublishers  bool
 	GenerateSubscribers bool
-}
+	EnumAllowUnknown    bool              // Don't reject the values not in enum on marshal and unmarshal
+	FormatTypes         map[string]string // Go types for schema formats that override the defaults, e.g. "uuid" -> "github.com/google/uuid.UUID"
+	OptionalFields      OptionalFieldsMode
+	NullableFields      NullableFieldsMode
+	CloudEvents         CloudEventsMode
+	CloudEventsSource   string // Default CloudEvents source attribute of messages
+	// Codecs are the encoders and decoders set by user, by content type or suffix pattern, e.g. "*+cbor"
+	Codecs map[string]ContentTypeCodec
+}
+
+// ContentTypeCodec is the encoder and decoder constructors for a content type. Constructors are set as full names,
+// e.g. "github.com/fxamacker/cbor/v2.NewEncoder". Encoder constructor gets io.Writer and returns a value with
+// `Encode(v any) error` method, decoder one gets io.Reader and returns a value with `Decode(v any) error` method.
+type ContentTypeCodec struct {
+	Encoder string
+	Decoder string
+}
+
+// OptionalFieldsMode is how the struct fields of optional (not required) properties are generated
+type OptionalFieldsMode string
+
+const (
+	OptionalFieldsPointer   OptionalFieldsMode = "pointer"   // Pointer, nil if property is absent
+	OptionalFieldsOmitEmpty OptionalFieldsMode = "omitempty" // Value, absent property is the zero value. Structs are pointers
+)
+
+// NullableFieldsMode is how the nullable types (that allow the null value) are generated
+type NullableFieldsMode string
+
+const (
+	NullableFieldsPointer  NullableFieldsMode = "pointer"  // Pointer, nil is null
+	NullableFieldsOptional NullableFieldsMode = "optional" // run.Optional[T] value, that is null if not set
+)
+
+// CloudEventsMode is how the messages are mapped to CloudEvents
+type CloudEventsMode string
+
+const (
+	CloudEventsNone       CloudEventsMode = ""           // Messages are not CloudEvents
+	CloudEventsStructured CloudEventsMode = "structured" // Event attributes and data are in payload in JSON format
+	CloudEventsBinary     CloudEventsMode = "binary"     // Event attributes are in headers, data is payload
+)
 
 type ObjectCompileOpts struct {
 	Enable       bool
@@ -73,6 +115,8 @@ type ContextStackItem struct {
 	Flags          map[SchemaTag]string
 	PackageName    string
 	RegisteredName string
+	// MergesAllOf is set for a schema, which allOf schemas are merged into it. Their nested types are named after it.
+	MergesAllOf bool
 }
 
 func NewCompileContext(specPath *specurl.URL, compileOpts CompileOpts) *CompileContext {
@@ -105,7 +149,7 @@ func (c *CompileContext) PutPromise(p ObjectPromise) {
 	if ref.IsExternal() {
 		c.Storage.AddExternalSpecPath(ref)
 	}
-	c.Storage.AddPromise(p)
+	c.Storage.AddPromise(p, c.PathStack())
 }
 
 func (c *CompileContext) PutListPromise(p ObjectListPromise) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
 	"github.com/xcnt/go-asyncapi/internal/types"
 )
 
@@ -20,7 +21,17 @@ func (c *CompilerLogger) Fatal(msg string, err error) {
 	c.logger.Error(msg, "path", c.ctx.PathStackRef())
 }
 
-func (c *CompilerLogger) Warn(msg string, args ...any) {
+// Warn logs the warning and also stores it as diagnostic with given code
+func (c *CompilerLogger) Warn(code diagnostics.Code, msg string, args ...any) {
+	var source *types.SourcePosition
+	pointer := c.ctx.PathStackRef()
+	if c.ctx.Storage != nil {
+		if source = c.ctx.Storage.SourcePosition(c.ctx.PathStack()); source != nil {
+			pointer = "" // Prefer the pointer within spec file
+		}
+	}
+	diagnostics.Add(diagnostics.SeverityWarning, code, source, pointer, diagnostics.FormatMessage(msg, args...))
+
 	args = append(args, "path", c.ctx.PathStackRef())
 	c.logger.Warn(msg, args...)
 }
@@ -19,4 +19,5 @@ type ObjectListPromise interface {
 	AssignList(objs []any)
 	Assigned() bool
 	FindCallback() func(item Renderer, path []string) bool
+	AllDocuments() bool
 }
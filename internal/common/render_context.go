@@ -44,6 +44,8 @@ type RenderOpts struct {
 	TargetDir     string
 	PackageScope  PackageScope
 	FileScope     FileScope
+	// ValidateMessages makes the message envelope marshal/unmarshal methods validate the message against the schema
+	ValidateMessages bool
 }
 
 type RenderContext struct {
@@ -11,4 +11,7 @@ const (
 	SchemaTagComponent SchemaTag = "components"
 	// SchemaTagMarshal marks that an object is meant to be marshaled/unmarshaled. Inherited by nested objects
 	SchemaTagMarshal SchemaTag = "marshal"
+	// SchemaTagNoCompile marks that an object and its nested objects produce no code, e.g. `if` and `not` schemas,
+	// that are only checked against the data on unmarshal
+	SchemaTagNoCompile SchemaTag = "noCompile"
 )
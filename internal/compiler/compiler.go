@@ -2,7 +2,7 @@ package compiler
 
 import (
 	"bytes"
-	"encoding/json"
+	"encoding"
 	"fmt"
 	"io"
 	"path"
@@ -24,6 +24,7 @@ const fallbackContentType = "application/json" // Default content type if it omi
 type Object struct {
 	Object common.Renderer
 	Path   []string
+	Source *types.SourcePosition // Position in spec file the object is defined at, nil if unknown
 }
 
 func NewModule(specURL *specurl.URL) *Module {
@@ -33,6 +34,7 @@ func NewModule(specURL *specurl.URL) *Module {
 		objects:            make(map[string][]Object), // Object by rendered code package
 		defaultContentType: fallbackContentType,
 		protocols:          make(map[string]int),
+		promiseSources:     make(map[common.ObjectPromise]*types.SourcePosition),
 	}
 }
 
@@ -44,19 +46,22 @@ type Module struct {
 	// Set on parsing
 	parsedSpecKind SpecKind
 	parsedSpec     compiledObject
+	source         []byte     // Spec file contents
+	root           *yaml.Node // Parsed YAML/JSON document, nil for other formats
 
 	// Set during compilation
 	objects            map[string][]Object // Objects by package
 	defaultContentType string
 	protocols          map[string]int
 	promises           []common.ObjectPromise
+	promiseSources     map[common.ObjectPromise]*types.SourcePosition
 	listPromises       []common.ObjectListPromise
 	activeServers      []string // Servers in `servers` document section
 	activeChannels     []string // Channels in `channels` document section
 }
 
 func (c *Module) AddObject(pkgName string, stack []string, obj common.Renderer) {
-	c.objects[pkgName] = append(c.objects[pkgName], Object{Object: obj, Path: stack})
+	c.objects[pkgName] = append(c.objects[pkgName], Object{Object: obj, Path: stack, Source: c.SourcePosition(stack)})
 }
 
 func (c *Module) RegisterProtocol(protoName string) {
@@ -67,8 +72,12 @@ func (c *Module) AddExternalSpecPath(specPath *specurl.URL) {
 	c.externalSpecs = append(c.externalSpecs, specPath)
 }
 
-func (c *Module) AddPromise(p common.ObjectPromise) {
+func (c *Module) AddPromise(p common.ObjectPromise, stack []string) {
 	c.promises = append(c.promises, p)
+	// Only the user promises are made for `$ref` in spec, the internal ones point to the objects generated by the tool
+	if p.Origin() == common.PromiseOriginUser {
+		c.promiseSources[p] = c.SourcePosition(append(append([]string{}, stack...), "$ref"))
+	}
 }
 
 func (c *Module) AddListPromise(p common.ObjectListPromise) {
@@ -123,7 +132,7 @@ func (c *Module) ListPromises() []common.ObjectListPromise {
 	return c.listPromises
 }
 
-func (c *Module) Load(specFileResolver SpecFileResolver) error {
+func (c *Module) Load(specFileResolver SpecFileResolver, allowRemoteRefs bool) error {
 	c.logger.Debug("Resolve and load a spec", "specURL", c.specURL)
 	buf, err := c.readSpec(c.specURL, specFileResolver)
 	if err != nil {
@@ -131,10 +140,47 @@ func (c *Module) Load(specFileResolver SpecFileResolver) error {
 	}
 
 	c.logger.Trace("Received data", "bytes", len(buf), "data", string(buf))
-	specKind, spec, err := c.decodeSpecFile(c.specURL.SpecID, bytes.NewReader(buf))
+	c.source = buf
+	specKind, spec, ok := specKindByExtension(c.specURL.SpecID)
+	if v, isText := spec.(encoding.TextUnmarshaler); ok && isText {
+		// Not a YAML/JSON document, e.g. protobuf file, so it parses itself
+		if err = v.UnmarshalText(buf); err != nil {
+			return fmt.Errorf("decode spec: %w", err)
+		}
+		c.logger.Debug("Spec parsed", "specURL", c.specURL, "kind", specKind)
+		c.parsedSpecKind = specKind
+		c.parsedSpec = spec
+		return nil
+	}
+
+	root, err := parseSpecFile(c.specURL.SpecID, bytes.NewReader(buf))
 	if err != nil {
 		return err
 	}
+	if !ok {
+		if specKind, spec, err = guessSpecKind(c.specURL.SpecID, root); err != nil {
+			return fmt.Errorf("guess spec kind: %w", err)
+		}
+	}
+
+	if traitPaths, targetPrecedence := specTraitPaths(spec); len(traitPaths) > 0 {
+		c.logger.Debug("Apply traits", "specURL", c.specURL)
+		tr := traitsResolver{
+			root:             root,
+			fileResolver:     specFileResolver,
+			allowRemoteRefs:  allowRemoteRefs,
+			logger:           c.logger,
+			targetPrecedence: targetPrecedence,
+		}
+		if err = tr.Apply(traitPaths); err != nil {
+			return fmt.Errorf("apply traits: %w", err)
+		}
+	}
+
+	if err = root.Decode(spec); err != nil {
+		return fmt.Errorf("decode spec %s: %w", c.specURL.SpecID, err)
+	}
+	c.root = root
 	c.logger.Debug("Spec parsed", "specURL", c.specURL, "kind", specKind)
 	c.parsedSpecKind = specKind
 	c.parsedSpec = spec
@@ -165,13 +211,14 @@ func (c *Module) Compile(ctx *common.CompileContext) error {
 	c.logger.Debug("Compile a spec", "specURL", c.specURL, "kind", c.parsedSpecKind)
 	c.logger.Trace("Compile the root component", "specURL", c.specURL)
 	if err := c.parsedSpec.Compile(ctx); err != nil {
-		return fmt.Errorf("root component in %s schema: %w", c.parsedSpecKind, err)
+		return fmt.Errorf("root component in %s schema: %w", c.parsedSpecKind, c.withErrorSource(err))
 	}
 	c.logger.Trace("Compile nested components", "specURL", c.specURL)
 	if err := WalkAndCompile(ctx, reflect.ValueOf(c.parsedSpec)); err != nil {
-		return fmt.Errorf("spec: %w", err)
+		return fmt.Errorf("spec: %w", c.withErrorSource(err))
 	}
-	if !ctx.CompileOpts.NoEncodingPackage {
+	// Only AsyncAPI documents contain messages, so other documents don't need the encoding package
+	if !ctx.CompileOpts.NoEncodingPackage && c.parsedSpecKind == SpecKindAsyncapi {
 		c.logger.Trace("Compile the encoding package", "specURL", c.specURL)
 		if err := EncodingCompile(ctx); err != nil {
 			return fmt.Errorf("encoding package: %w", err)
@@ -180,6 +227,10 @@ func (c *Module) Compile(ctx *common.CompileContext) error {
 	return nil
 }
 
+func (c *Module) SpecKind() SpecKind {
+	return c.parsedSpecKind
+}
+
 func (c *Module) ExternalSpecs() []*specurl.URL {
 	return c.externalSpecs
 }
@@ -191,32 +242,17 @@ func (c *Module) Stats() string {
 	)
 }
 
-func (c *Module) decodeSpecFile(specPath string, data io.ReadSeeker) (SpecKind, compiledObject, error) {
+// parseSpecFile parses a spec file to the yaml node tree. JSON is a subset of YAML, so JSON files are parsed the same way.
+func parseSpecFile(specPath string, data io.Reader) (*yaml.Node, error) {
 	switch path.Ext(specPath) {
-	case ".yaml", ".yml":
-		c.logger.Debug("Found YAML spec file", "specPath", specPath)
-		schemaKind, spec, err := guessSpecKind(yaml.NewDecoder(data))
-		if err != nil {
-			return "", nil, fmt.Errorf("guess spec kind: %w", err)
-		}
-		if _, err = data.Seek(0, io.SeekStart); err != nil {
-			return "", nil, fmt.Errorf("file seek: %w", err)
-		}
-		err = yaml.NewDecoder(data).Decode(spec)
-		return schemaKind, spec, err
-	case ".json":
-		c.logger.Debug("Found JSON spec file", "specPath", specPath)
-		schemaKind, spec, err := guessSpecKind(json.NewDecoder(data))
-		if err != nil {
-			return "", nil, fmt.Errorf("guess spec kind: %w", err)
-		}
-		if _, err = data.Seek(0, io.SeekStart); err != nil {
-			return "", nil, fmt.Errorf("file seek: %w", err)
-		}
-		err = json.NewDecoder(data).Decode(spec)
-		return schemaKind, spec, err
+	case ".yaml", ".yml", ".json", ".avsc": // Avro schema files are JSON
+	default:
+		return nil, fmt.Errorf("cannot determine format of a spec file: unknown filename extension: %s", specPath)
 	}
 
-	return "", nil, fmt.Errorf("cannot determine format of a spec file: unknown filename extension: %s", specPath)
+	var res yaml.Node
+	if err := yaml.NewDecoder(data).Decode(&res); err != nil {
+		return nil, fmt.Errorf("parse spec file %s: %w", specPath, err)
+	}
+	return &res, nil
 }
-
@@ -0,0 +1,112 @@
+package compiler
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/specurl"
+)
+
+type stubFileResolver map[string]string
+
+func (s stubFileResolver) Resolve(specPath *specurl.URL) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(s[specPath.SpecID])), nil
+}
+
+func TestModuleCompileSchemaDocument(t *testing.T) {
+	tests := []struct {
+		name      string
+		specID    string
+		data      string
+		wantKind  SpecKind
+		wantPaths []string
+		noPaths   []string
+	}{
+		{
+			"jsonschema with root and definitions",
+			"schemas/user.json",
+			`{
+				"$schema": "http://json-schema.org/draft-07/schema#",
+				"type": "object",
+				"properties": {"address": {"$ref": "#/definitions/Address"}},
+				"definitions": {"Address": {"type": "object"}},
+				"$defs": {"Role": {"type": "string"}}
+			}`,
+			SpecKindJsonschema,
+			[]string{"", "$root/user", "$root/user/properties/address", "definitions/Address", "$defs/Role"},
+			nil,
+		},
+		{
+			"jsonschema with definitions only",
+			"common.yaml",
+			"definitions:\n  Tag: {type: string}\n",
+			SpecKindJsonschema,
+			[]string{"definitions/Tag"},
+			nil,
+		},
+		{
+			"openapi components",
+			"openapi.yaml",
+			"openapi: 3.1.0\n" +
+				"paths: {}\n" +
+				"components:\n" +
+				"  schemas:\n" +
+				"    Order:\n" +
+				"      type: object\n" +
+				"      properties: {id: {type: string, readOnly: true}, note: {type: string, nullable: true}}\n" +
+				"    Pet:\n" +
+				"      oneOf: [{$ref: '#/components/schemas/Order'}]\n" +
+				"      discriminator: {propertyName: kind, mapping: {order: '#/components/schemas/Order'}}\n" +
+				"$defs:\n" +
+				"  Money: {type: number}\n",
+			SpecKindOpenapi,
+			[]string{"components/schemas/Order", "components/schemas/Order/properties/id", "components/schemas/Pet", "$defs/Money"},
+			nil,
+		},
+		{
+			"conditional schemas",
+			"address.yaml",
+			"definitions:\n" +
+				"  Address:\n" +
+				"    type: object\n" +
+				"    properties: {country: {type: string}}\n" +
+				"    if: {properties: {country: {const: US}}}\n" +
+				"    then: {required: [zip], properties: {zip: {enum: ['1', '2']}}}\n" +
+				"    not: {required: [country]}\n",
+			SpecKindJsonschema,
+			[]string{"definitions/Address", "definitions/Address/properties/country", "definitions/Address/then/properties/zip"},
+			[]string{"definitions/Address/if/properties/country", "definitions/Address/not"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specURL := specurl.Parse(tt.specID)
+			m := NewModule(specURL)
+			if err := m.Load(stubFileResolver{tt.specID: tt.data}, false); err != nil {
+				t.Fatalf("unexpected load error: %v", err)
+			}
+			if m.SpecKind() != tt.wantKind {
+				t.Errorf("expect kind %v, got %v", tt.wantKind, m.SpecKind())
+			}
+			ctx := common.NewCompileContext(specURL, common.CompileOpts{ModelOpts: common.ObjectCompileOpts{Enable: true}})
+			if err := m.Compile(ctx); err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+			paths := lo.Map(m.AllObjects(), func(item Object, _ int) string { return strings.Join(item.Path, "/") })
+			for _, p := range tt.wantPaths {
+				if !lo.Contains(paths, p) {
+					t.Errorf("expect object at %q, got objects at %q", p, paths)
+				}
+			}
+			for _, p := range tt.noPaths {
+				if lo.Contains(paths, p) {
+					t.Errorf("expect no object at %q, got objects at %q", p, paths)
+				}
+			}
+		})
+	}
+}
@@ -25,8 +25,10 @@ func buildEncoderDecoder(ctx *common.CompileContext) (*render.EncodingEncode, *r
 	return &render.EncodingEncode{
 			AllMessages:        allMessagesPrm,
 			DefaultContentType: ctx.Storage.DefaultContentType(),
+			Codecs:             ctx.CompileOpts.Codecs,
 		}, &render.EncodingDecode{
 			AllMessages:        allMessagesPrm,
 			DefaultContentType: ctx.Storage.DefaultContentType(),
+			Codecs:             ctx.CompileOpts.Codecs,
 		}
 }
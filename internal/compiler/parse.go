@@ -1,6 +1,10 @@
 package compiler
 
 import (
+	"fmt"
+	"path"
+	"strings"
+
 	"github.com/xcnt/go-asyncapi/internal/asyncapi"
 )
 
@@ -10,6 +14,8 @@ const (
 	SpecKindAsyncapi   SpecKind = "asyncapi"
 	SpecKindJsonschema SpecKind = "jsonschema"
 	SpecKindOpenapi    SpecKind = "openapi"
+	SpecKindAvro       SpecKind = "avro"
+	SpecKindProtobuf   SpecKind = "protobuf"
 )
 
 type specTypeTest struct {
@@ -21,18 +27,49 @@ type anyDecoder interface {
 	Decode(v any) error
 }
 
-func guessSpecKind(decoder anyDecoder) (SpecKind, compiledObject, error) {
+// specKindByExtension returns the kind of spec files which are told apart only by filename extension, such as Avro
+// schema files, whose root may be not an object, or protobuf files, which are not YAML at all.
+func specKindByExtension(specPath string) (SpecKind, compiledObject, bool) {
+	ext := path.Ext(specPath)
+	docName := strings.TrimSuffix(path.Base(specPath), ext)
+	switch ext {
+	case ".avsc":
+		return SpecKindAvro, asyncapi.NewAvroSchemaDocument(docName), true
+	case ".proto":
+		return SpecKindProtobuf, asyncapi.NewProtobufDocument(docName), true
+	}
+	return "", nil, false
+}
+
+func guessSpecKind(specPath string, decoder anyDecoder) (SpecKind, compiledObject, error) {
 	test := specTypeTest{}
 
 	if err := decoder.Decode(&test); err != nil {
 		return "", nil, err
 	}
 	switch {
+	case strings.HasPrefix(test.Asyncapi, "3."):
+		return SpecKindAsyncapi, &asyncapi.AsyncAPIV3{}, nil
 	case test.Asyncapi != "":
 		return SpecKindAsyncapi, &asyncapi.AsyncAPI{}, nil
+	case strings.HasPrefix(test.Openapi, "3."):
+		return SpecKindOpenapi, &asyncapi.OpenAPI{}, nil
 	case test.Openapi != "":
-		panic("openapi not implemented")
+		return "", nil, fmt.Errorf("openapi version %q is not supported, only 3.x documents can be referenced", test.Openapi)
 	}
-	panic("jsonschema not implemented")
 	// Assume that some data is jsonschema, TODO: maybe it's better to match more strict?
+	docName := strings.TrimSuffix(path.Base(specPath), path.Ext(specPath))
+	return SpecKindJsonschema, asyncapi.NewJSONSchemaDocument(docName), nil
+}
+
+// specTraitPaths returns the paths to objects in a spec that may contain traits and whether the traits merge
+// mechanism prefers values of target object over the trait values. The latter is true for AsyncAPI 3.x.
+func specTraitPaths(spec compiledObject) ([][]string, bool) {
+	switch spec.(type) {
+	case *asyncapi.AsyncAPIV3:
+		return traitPathsV3, true
+	case *asyncapi.AsyncAPI:
+		return traitPathsV2, false
+	}
+	return nil, false
 }
@@ -0,0 +1,89 @@
+package compiler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xcnt/go-asyncapi/internal/asyncapi"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGuessSpecKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantKind SpecKind
+		want     compiledObject
+	}{
+		{
+			"asyncapi 2.6",
+			"asyncapi: 2.6.0",
+			SpecKindAsyncapi,
+			&asyncapi.AsyncAPI{},
+		},
+		{
+			"asyncapi 3.0",
+			"asyncapi: 3.0.0",
+			SpecKindAsyncapi,
+			&asyncapi.AsyncAPIV3{},
+		},
+		{
+			"openapi 3.1",
+			"openapi: 3.1.0",
+			SpecKindOpenapi,
+			&asyncapi.OpenAPI{},
+		},
+		{
+			"jsonschema",
+			"$schema: http://json-schema.org/draft-07/schema#\ntype: object",
+			SpecKindJsonschema,
+			&asyncapi.JSONSchemaDocument{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, got, err := guessSpecKind("schemas/user.yaml", yaml.NewDecoder(strings.NewReader(tt.input)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if kind != tt.wantKind {
+				t.Errorf("expect kind %v, got %v", tt.wantKind, kind)
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+				t.Errorf("expect %T, got %T", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGuessSpecKindUnsupportedOpenapi(t *testing.T) {
+	if _, _, err := guessSpecKind("swagger.yaml", yaml.NewDecoder(strings.NewReader("openapi: 2.0"))); err == nil {
+		t.Error("expect error, got nil")
+	}
+}
+
+func TestSpecKindByExtension(t *testing.T) {
+	tests := []struct {
+		name     string
+		specPath string
+		wantKind SpecKind
+		wantOk   bool
+	}{
+		{"avro schema", "schemas/user.avsc", SpecKindAvro, true},
+		{"protobuf", "protos/user.proto", SpecKindProtobuf, true},
+		{"yaml", "asyncapi.yaml", "", false},
+		{"json", "schemas/user.json", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, _, ok := specKindByExtension(tt.specPath)
+			if ok != tt.wantOk {
+				t.Fatalf("expect ok %v, got %v", tt.wantOk, ok)
+			}
+			if kind != tt.wantKind {
+				t.Errorf("expect kind %v, got %v", tt.wantKind, kind)
+			}
+		})
+	}
+}
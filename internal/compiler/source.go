@@ -0,0 +1,96 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/asyncapi"
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/specurl"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+// SourcePosition returns the position in spec file of an entity on the given path. If the path is not present in
+// file as is (e.g. it points to an object generated by the tool), the position of the nearest parent is returned.
+// Returns nil if the spec is not a YAML/JSON document.
+func (c *Module) SourcePosition(path []string) *types.SourcePosition {
+	if c.root == nil {
+		return nil
+	}
+	if c.parsedSpecKind == SpecKindJsonschema && len(path) >= 2 && path[0] == asyncapi.JSONSchemaRootKey {
+		path = path[2:] // Root schema of JSON Schema document is the document itself
+	}
+	line, column := nearestNodePosition(c.root, path)
+	res := types.NewSourcePosition(c.specURL.SpecID, line, column, c.source)
+	res.Pointer = specurl.BuildRef(path...)
+	return res
+}
+
+// PromiseSource returns the position in spec file of the ref the promise was made for, nil if unknown
+func (c *Module) PromiseSource(p common.ObjectPromise) *types.SourcePosition {
+	return c.promiseSources[p]
+}
+
+// withErrorSource sets the spec file position to the compile error, if it's not set yet
+func (c *Module) withErrorSource(err error) error {
+	var cErr types.CompileError
+	if !errors.As(err, &cErr) || cErr.Source != nil {
+		return err
+	}
+	ref := specurl.Parse(cErr.Path)
+	path := make([]string, 0, len(ref.Pointer))
+	for _, p := range ref.Pointer {
+		item, e := url.PathUnescape(p)
+		if e != nil {
+			return err
+		}
+		path = append(path, item)
+	}
+	if cErr.Source = c.SourcePosition(path); cErr.Source == nil {
+		return err
+	}
+	if _, ok := err.(types.CompileError); ok {
+		return cErr
+	}
+	return fmt.Errorf("%s: %w", cErr.Source, err) // Keep the messages the compile error is wrapped with
+}
+
+// nearestNodePosition returns the position of the node on the given path or the nearest parent node if the path
+// could not be found in document. For mapping value, the position of its key is returned.
+func nearestNodePosition(doc *yaml.Node, path []string) (line, column int) {
+	node := doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line, column = node.Line, node.Column
+	for _, item := range path {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == item {
+					line, column = node.Content[i].Line, node.Content[i].Column
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(item); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+				line, column = next.Line, next.Column
+			}
+		}
+		if next == nil {
+			return
+		}
+		node = next
+	}
+	return
+}
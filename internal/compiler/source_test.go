@@ -0,0 +1,63 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+const sourceTestDoc = `asyncapi: 2.6.0
+channels:
+  users:
+    publish:
+      message:
+        payload:
+          $ref: '#/components/schemas/User'
+servers: [{url: localhost}]
+`
+
+func TestNearestNodePosition(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       []string
+		wantLine   int
+		wantColumn int
+	}{
+		{"root", nil, 1, 1},
+		{"mapping key", []string{"channels", "users"}, 3, 3},
+		{"ref", []string{"channels", "users", "publish", "message", "payload", "$ref"}, 7, 11},
+		{"sequence item", []string{"servers", "0"}, 8, 11},
+		{"nearest parent", []string{"channels", "users", "subscribe", "message"}, 3, 3},
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(sourceTestDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, column := nearestNodePosition(&doc, tt.path)
+			if line != tt.wantLine || column != tt.wantColumn {
+				t.Errorf("expect %d:%d, got %d:%d", tt.wantLine, tt.wantColumn, line, column)
+			}
+		})
+	}
+}
+
+func TestSourcePositionSnippet(t *testing.T) {
+	pos := types.NewSourcePosition("spec.yaml", 7, 11, []byte(sourceTestDoc))
+	want := strings.Join([]string{
+		"5 |       message:",
+		"6 |         payload:",
+		"7 |           $ref: '#/components/schemas/User'",
+		"  |           ^",
+	}, "\n")
+	if got := pos.Snippet(); got != want {
+		t.Errorf("expect snippet:\n%s\ngot:\n%s", want, got)
+	}
+	if got := pos.String(); got != "spec.yaml:7:11" {
+		t.Errorf("expect position spec.yaml:7:11, got %s", got)
+	}
+}
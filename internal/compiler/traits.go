@@ -0,0 +1,301 @@
+package compiler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/specurl"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+const traitsKey = "traits"
+
+// Paths to objects that may contain traits. "*" matches any key or index.
+var (
+	traitPathsV2 = [][]string{
+		{"components", "messages", "*"},
+		{"channels", "*", "publish"},
+		{"channels", "*", "publish", "message"},
+		{"channels", "*", "publish", "message", "oneOf", "*"},
+		{"channels", "*", "subscribe"},
+		{"channels", "*", "subscribe", "message"},
+		{"channels", "*", "subscribe", "message", "oneOf", "*"},
+		{"components", "channels", "*", "publish"},
+		{"components", "channels", "*", "publish", "message"},
+		{"components", "channels", "*", "publish", "message", "oneOf", "*"},
+		{"components", "channels", "*", "subscribe"},
+		{"components", "channels", "*", "subscribe", "message"},
+		{"components", "channels", "*", "subscribe", "message", "oneOf", "*"},
+	}
+	traitPathsV3 = [][]string{
+		{"components", "messages", "*"},
+		{"channels", "*", "messages", "*"},
+		{"components", "channels", "*", "messages", "*"},
+		{"operations", "*"},
+		{"components", "operations", "*"},
+	}
+)
+
+// traitsResolver applies the message and operation traits to objects they are defined in. Traits may be defined
+// inline or be references to objects in the current document or in external files.
+type traitsResolver struct {
+	root             *yaml.Node
+	fileResolver     SpecFileResolver
+	allowRemoteRefs  bool
+	logger           *types.Logger
+	externalDocs     map[string]*yaml.Node // Parsed external documents by spec id
+	targetPrecedence bool                  // If true, a trait doesn't override values of the target object
+}
+
+func (r *traitsResolver) Apply(paths [][]string) error {
+	for _, p := range paths {
+		for _, obj := range findNodes(r.root, p) {
+			if err := r.applyObjectTraits(obj.node, obj.path); err != nil {
+				return types.CompileError{Err: err, Path: specurl.BuildRef(append(obj.path, traitsKey)...)}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *traitsResolver) applyObjectTraits(obj *yaml.Node, path []string) error {
+	traitsNode := mappingValue(obj, traitsKey)
+	if traitsNode == nil {
+		return nil
+	}
+	if traitsNode.Kind != yaml.SequenceNode {
+		return fmt.Errorf("traits must be a list, got %s", nodeKindName(traitsNode.Kind))
+	}
+	r.logger.Debug("Apply traits", "path", specurl.BuildRef(path...), "count", len(traitsNode.Content))
+
+	// Object itself without traits
+	target := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: obj.Line, Column: obj.Column}
+	for i := 0; i < len(obj.Content); i += 2 {
+		if obj.Content[i].Value != traitsKey {
+			target.Content = append(target.Content, obj.Content[i], obj.Content[i+1])
+		}
+	}
+
+	var res *yaml.Node
+	if !r.targetPrecedence {
+		res = target
+	}
+	for i, item := range traitsNode.Content {
+		trait, err := r.resolveTrait(item)
+		if err != nil {
+			return fmt.Errorf("trait #%d: %w", i, err)
+		}
+		if trait.Kind != yaml.MappingNode {
+			return fmt.Errorf("trait #%d must be an object, got %s", i, nodeKindName(trait.Kind))
+		}
+		res = mergePatch(res, trait)
+	}
+	if r.targetPrecedence {
+		res = mergePatch(res, target)
+	}
+
+	*obj = *res
+	return nil
+}
+
+// resolveTrait follows the trait references until an object is found
+func (r *traitsResolver) resolveTrait(trait *yaml.Node) (*yaml.Node, error) {
+	doc := r.root
+	specID := ""
+	var visited []string
+	for {
+		ref := mappingValue(trait, "$ref")
+		if ref == nil {
+			return trait, nil
+		}
+		u := specurl.Parse(ref.Value)
+		if u.IsExternal() {
+			specID = u.SpecID
+			var err error
+			if doc, err = r.externalDoc(u); err != nil {
+				return nil, err
+			}
+		}
+		refKey := specID + u.String()
+		if lo.Contains(visited, refKey) {
+			return nil, fmt.Errorf("ref loop: %s", strings.Join(append(visited, refKey), " -> "))
+		}
+		visited = append(visited, refKey)
+
+		r.logger.Trace("Trait ref", "$ref", ref.Value)
+		found, err := findPointer(doc, u.Pointer)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %q: %w", ref.Value, err)
+		}
+		trait = found
+	}
+}
+
+func (r *traitsResolver) externalDoc(u *specurl.URL) (*yaml.Node, error) {
+	if doc, ok := r.externalDocs[u.SpecID]; ok {
+		return doc, nil
+	}
+	if u.IsRemote() && !r.allowRemoteRefs {
+		return nil, fmt.Errorf(
+			"%s: external requests are forbidden by default for security reasons, use --allow-remote-refs flag to allow them",
+			u.SpecID,
+		)
+	}
+	r.logger.Debug("Load external traits document", "specURL", u.SpecID)
+	data, err := r.fileResolver.Resolve(u)
+	if err != nil {
+		return nil, fmt.Errorf("resolve spec %q: %w", u.SpecID, err)
+	}
+	defer data.Close()
+
+	doc, err := parseSpecFile(u.SpecID, data)
+	if err != nil {
+		return nil, err
+	}
+	if r.externalDocs == nil {
+		r.externalDocs = make(map[string]*yaml.Node)
+	}
+	r.externalDocs[u.SpecID] = doc
+	return doc, nil
+}
+
+// mergePatch applies the patch to the target according to JSON Merge Patch algorithm (RFC 7386). Target may be nil.
+func mergePatch(target, patch *yaml.Node) *yaml.Node {
+	if patch.Kind == yaml.AliasNode {
+		patch = patch.Alias
+	}
+	if patch.Kind != yaml.MappingNode {
+		return patch
+	}
+	if target == nil || target.Kind != yaml.MappingNode {
+		target = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: patch.Line, Column: patch.Column}
+	} else {
+		// Don't modify the original node, since it may be a shared trait
+		t := *target
+		t.Content = append([]*yaml.Node(nil), target.Content...)
+		target = &t
+	}
+
+	for i := 0; i < len(patch.Content); i += 2 {
+		key, val := patch.Content[i], patch.Content[i+1]
+		idx := -1
+		for j := 0; j < len(target.Content); j += 2 {
+			if target.Content[j].Value == key.Value {
+				idx = j
+				break
+			}
+		}
+		if val.Kind == yaml.ScalarNode && val.Tag == "!!null" {
+			if idx >= 0 {
+				target.Content = append(target.Content[:idx], target.Content[idx+2:]...)
+			}
+			continue
+		}
+		if idx >= 0 {
+			target.Content[idx+1] = mergePatch(target.Content[idx+1], val)
+		} else {
+			target.Content = append(target.Content, key, mergePatch(nil, val))
+		}
+	}
+	return target
+}
+
+type foundNode struct {
+	node *yaml.Node
+	path []string
+}
+
+// findNodes returns all nodes in document matched the given path. "*" in path matches any key or index.
+func findNodes(doc *yaml.Node, path []string) []foundNode {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	res := []foundNode{{node: doc}}
+	for _, item := range path {
+		var next []foundNode
+		for _, f := range res {
+			switch f.node.Kind {
+			case yaml.MappingNode:
+				for i := 0; i < len(f.node.Content); i += 2 {
+					if k := f.node.Content[i].Value; item == "*" || item == k {
+						next = append(next, foundNode{node: f.node.Content[i+1], path: append(append([]string(nil), f.path...), k)})
+					}
+				}
+			case yaml.SequenceNode:
+				for i, n := range f.node.Content {
+					if k := strconv.Itoa(i); item == "*" || item == k {
+						next = append(next, foundNode{node: n, path: append(append([]string(nil), f.path...), k)})
+					}
+				}
+			}
+		}
+		res = next
+	}
+	return lo.Filter(res, func(item foundNode, _ int) bool { return item.node.Kind == yaml.MappingNode })
+}
+
+// findPointer returns a node the escaped JSON pointer points to
+func findPointer(doc *yaml.Node, pointer []string) (*yaml.Node, error) {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	node := doc
+	for _, p := range pointer {
+		item, err := url.PathUnescape(p)
+		if err != nil {
+			return nil, fmt.Errorf("unescape %q: %w", p, err)
+		}
+		item = strings.NewReplacer("~1", "/", "~0", "~").Replace(item)
+
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			next = mappingValue(node, item)
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(item); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("%q not found", item)
+		}
+		node = next
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func nodeKindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.DocumentNode:
+		return "document"
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.MappingNode:
+		return "object"
+	case yaml.ScalarNode:
+		return "scalar"
+	case yaml.AliasNode:
+		return "alias"
+	}
+	return "unknown"
+}
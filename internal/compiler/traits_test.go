@@ -0,0 +1,104 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+func TestTraitsResolverApply(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		targetPrecedence bool
+		want             string
+	}{
+		{
+			"trait overrides target",
+			`
+components:
+  messageTraits:
+    common:
+      contentType: application/yaml
+      headers: {type: object, properties: {traceId: {type: string}}}
+  messages:
+    foo:
+      contentType: application/json
+      headers: {properties: {spanId: {type: string}}}
+      traits:
+        - $ref: '#/components/messageTraits/common'
+`,
+			false,
+			`contentType: application/yaml
+headers: {properties: {spanId: {type: string}, traceId: {type: string}}, type: object}
+`,
+		},
+		{
+			"target overrides trait",
+			`
+components:
+  messageTraits:
+    common:
+      contentType: application/yaml
+      headers: {type: object, properties: {traceId: {type: string}}}
+  messages:
+    foo:
+      contentType: application/json
+      headers: {properties: {spanId: {type: string}}}
+      traits:
+        - $ref: '#/components/messageTraits/common'
+`,
+			true,
+			`contentType: application/json
+headers: {type: object, properties: {traceId: {type: string}, spanId: {type: string}}}
+`,
+		},
+		{
+			"null removes value",
+			`
+components:
+  messages:
+    foo:
+      name: foo
+      summary: bar
+      traits:
+        - summary: null
+        - name: baz
+`,
+			false,
+			`name: baz
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseSpecFile("spec.yaml", strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tr := traitsResolver{root: root, logger: types.NewLogger(""), targetPrecedence: tt.targetPrecedence}
+			if err = tr.Apply(traitPathsV2); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := findPointer(root, []string{"components", "messages", "foo"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var gotVal, wantVal any
+			if err = got.Decode(&gotVal); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err = yaml.Unmarshal([]byte(tt.want), &wantVal); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			gotBuf, _ := yaml.Marshal(gotVal)
+			wantBuf, _ := yaml.Marshal(wantVal)
+			if string(gotBuf) != string(wantBuf) {
+				t.Errorf("expect %s, got %s", wantBuf, gotBuf)
+			}
+		})
+	}
+}
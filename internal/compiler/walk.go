@@ -65,7 +65,11 @@ func WalkAndCompile(ctx *common.CompileContext, object reflect.Value) error {
 				continue
 			}
 
-			pushStack(ctx, getFieldJSONName(fld), parseTags(fld))
+			tags := parseTags(fld)
+			if _, ok := tags[common.SchemaTagNoCompile]; ok {
+				continue
+			}
+			pushStack(ctx, getFieldJSONName(fld), tags)
 			if err := traverse(ctx, fldVal); err != nil {
 				return err
 			}
@@ -0,0 +1,104 @@
+// Package diagnostics collects the warnings and errors found during the code generation, so that they can be
+// reported in machine-readable form, e.g. to annotate a pull request in CI.
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Code is a kind of diagnostic, stable across versions to be used in the tools which process the diagnostics
+type Code string
+
+const (
+	CodeError              Code = "error"
+	CodeCompileError       Code = "compile-error"
+	CodeDanglingRef        Code = "dangling-ref"
+	CodeRefLoop            Code = "ref-loop"
+	CodeRenderError        Code = "render-error"
+	CodeUnsupportedProto   Code = "unsupported-protocol"
+	CodeUnsupportedBinding Code = "unsupported-bindings"
+	CodeUnsupportedField   Code = "unsupported-field"
+	CodeUnsupportedFeature Code = "unsupported-feature"
+	CodeInvalidValue       Code = "invalid-value"
+)
+
+var codeDescriptions = map[Code]string{
+	CodeError:              "General error, e.g. spec file read error",
+	CodeCompileError:       "Spec object compilation failed",
+	CodeDanglingRef:        "Ref points to nothing",
+	CodeRefLoop:            "Refs point to each other in a loop",
+	CodeRenderError:        "Error occurred during rendering the code",
+	CodeUnsupportedProto:   "Server protocol is not supported, server is skipped",
+	CodeUnsupportedBinding: "Bindings protocol is not supported, bindings are ignored",
+	CodeUnsupportedField:   "Spec field is not supported and ignored",
+	CodeUnsupportedFeature: "Spec construct is not supported, it is replaced or skipped",
+	CodeInvalidValue:       "Field value is not suitable, the fallback is used",
+}
+
+// Diagnostic is a single warning or error
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     Code     `json:"code"`
+	File     string   `json:"file,omitempty"`
+	Pointer  string   `json:"pointer,omitempty"` // JSON pointer in file, e.g. "#/components/schemas/Foo"
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+var collected []Diagnostic
+
+// Add stores the diagnostic. The source position is optional. If pointer is empty, it's taken from source position.
+func Add(severity Severity, code Code, source *types.SourcePosition, pointer, message string) {
+	d := Diagnostic{Severity: severity, Code: code, Pointer: pointer, Message: message}
+	if source != nil {
+		d.File, d.Line, d.Column = source.File, source.Line, source.Column
+		d.Pointer, _ = lo.Coalesce(d.Pointer, source.Pointer)
+	}
+	collected = append(collected, d)
+}
+
+// AddError stores an error. If error refers to the position in spec file, it's used as diagnostic location.
+func AddError(code Code, pointer string, err error) {
+	source := types.ErrorSource(err)
+	var cErr types.CompileError
+	if errors.As(err, &cErr) {
+		code = CodeCompileError
+		if source == nil {
+			pointer = cErr.Path
+		}
+	}
+	Add(SeverityError, code, source, pointer, err.Error())
+}
+
+// All returns all diagnostics collected so far
+func All() []Diagnostic {
+	return collected
+}
+
+// Count returns the number of diagnostics with given severity
+func Count(severity Severity) int {
+	return lo.CountBy(collected, func(item Diagnostic) bool { return item.Severity == severity })
+}
+
+// FormatMessage makes a diagnostic message from log message and its key-value arguments
+func FormatMessage(msg string, args ...any) string {
+	parts := []string{msg}
+	for i := 0; i+1 < len(args); i += 2 {
+		parts = append(parts, fmt.Sprintf("%v=%v", args[i], args[i+1]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,71 @@
+package diagnostics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+func TestBuildSARIF(t *testing.T) {
+	tests := []struct {
+		name          string
+		diagnostic    Diagnostic
+		wantLocations []sarifLocation
+	}{
+		{
+			"full location",
+			Diagnostic{SeverityWarning, CodeUnsupportedProto, "spec.yaml", "#/servers/foo", 3, 5, "msg"},
+			[]sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "spec.yaml"},
+					Region:           &sarifRegion{StartLine: 3, StartColumn: 5},
+				},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "#/servers/foo"}},
+			}},
+		},
+		{
+			"file without position",
+			Diagnostic{SeverityError, CodeError, "spec.yaml", "", 0, 0, "msg"},
+			[]sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "spec.yaml"}},
+			}},
+		},
+		{
+			"no location",
+			Diagnostic{SeverityError, CodeError, "", "", 0, 0, "msg"},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := buildSARIF([]Diagnostic{tt.diagnostic})
+			rules := log.Runs[0].Tool.Driver.Rules
+			if len(rules) != 1 || rules[0].ID != string(tt.diagnostic.Code) {
+				t.Errorf("expect single rule %q, got %v", tt.diagnostic.Code, rules)
+			}
+			res := log.Runs[0].Results
+			if len(res) != 1 || res[0].Level != string(tt.diagnostic.Severity) || res[0].RuleID != string(tt.diagnostic.Code) {
+				t.Fatalf("unexpected results %v", res)
+			}
+			if !reflect.DeepEqual(res[0].Locations, tt.wantLocations) {
+				t.Errorf("expect locations %+v, got %+v", tt.wantLocations, res[0].Locations)
+			}
+		})
+	}
+}
+
+func TestAddError(t *testing.T) {
+	t.Cleanup(func() { collected = nil })
+	source := types.NewSourcePosition("spec.yaml", 2, 3, nil)
+	source.Pointer = "#/components/schemas/Foo"
+	AddError(CodeError, "", types.CompileError{Err: errors.New("unknown type"), Path: "#/$root/x/components/schemas/Foo", Source: source})
+
+	want := Diagnostic{SeverityError, CodeCompileError, "spec.yaml", "#/components/schemas/Foo", 2, 3, ""}
+	got := All()[0]
+	got.Message = ""
+	if got != want {
+		t.Errorf("expect %+v, got %+v", want, got)
+	}
+}
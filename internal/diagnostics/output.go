@@ -0,0 +1,126 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName     = "go-asyncapi"
+	toolURI      = "https://github.com/xcnt/go-asyncapi"
+)
+
+// WriteJSON writes all diagnostics as JSON array
+func WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(lo.Ternary(collected == nil, []Diagnostic{}, collected))
+}
+
+// WriteSARIF writes all diagnostics as SARIF 2.1.0 log, which is supported by code scanning tools,
+// e.g. GitHub code scanning
+func WriteSARIF(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(buildSARIF(collected))
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+func buildSARIF(items []Diagnostic) sarifLog {
+	codes := lo.Uniq(lo.Map(items, func(item Diagnostic, _ int) Code { return item.Code }))
+	slices.Sort(codes)
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationURI: toolURI,
+			Rules: lo.Map(codes, func(item Code, _ int) sarifRule {
+				return sarifRule{ID: string(item), ShortDescription: sarifMessage{Text: codeDescriptions[item]}}
+			}),
+		}},
+		Results: make([]sarifResult, 0, len(items)),
+	}
+
+	for _, item := range items {
+		res := sarifResult{RuleID: string(item.Code), Level: string(item.Severity), Message: sarifMessage{Text: item.Message}}
+		var loc sarifLocation
+		if item.File != "" {
+			loc.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: item.File}}
+			if item.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: item.Line, StartColumn: item.Column}
+			}
+		}
+		if item.Pointer != "" {
+			loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: item.Pointer}}
+		}
+		if loc.PhysicalLocation != nil || loc.LogicalLocations != nil {
+			res.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	return sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+}
@@ -1,9 +1,12 @@
 package linker
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
+	"golang.org/x/exp/slices"
+
 	"github.com/xcnt/go-asyncapi/internal/specurl"
 
 	"github.com/xcnt/go-asyncapi/internal/types"
@@ -13,12 +16,28 @@ import (
 	"github.com/samber/lo"
 
 	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/render"
 )
 
 type ObjectSource interface {
 	AllObjects() []compiler.Object // TODO: make this as interface and move promise.go to linker
 	Promises() []common.ObjectPromise
 	ListPromises() []common.ObjectListPromise
+	SpecKind() compiler.SpecKind
+	PromiseSource(p common.ObjectPromise) *types.SourcePosition
+}
+
+// DanglingRef is a ref that could not be resolved
+type DanglingRef struct {
+	Ref    string
+	Source *types.SourcePosition // Position of the ref in spec file, nil if unknown
+}
+
+func (d DanglingRef) String() string {
+	if d.Source != nil {
+		return fmt.Sprintf("%s (%s)", d.Ref, d.Source)
+	}
+	return d.Ref
 }
 
 func AssignRefs(sources map[string]ObjectSource) {
@@ -65,7 +84,7 @@ func AssignListPromises(sources map[string]ObjectSource) {
 				if p.Assigned() {
 					continue // Assigned on previous iterations
 				}
-				if res, ok := resolveListPromise(p, srcSpecID, sources); ok {
+				if res, ok := resolveListPromise(p, srcSpecID, sources, nil); ok {
 					targets := strings.Join(
 						lo.Map(lo.Slice(res, 0, 2), func(item common.Renderer, _ int) string { return item.String() }),
 						", ",
@@ -87,6 +106,81 @@ func AssignListPromises(sources map[string]ObjectSource) {
 	}
 }
 
+// PrepareRecursiveTypes makes the recursive types possible in Go code. The anonymous types referring to themselves
+// (e.g. inline message payload with a ref to itself) become named types, and the struct fields that close the cycle
+// of structs containing each other by value become pointers. The anonymous structs with field defaults become named
+// types as well, since they need the unmarshal methods to set the defaults. Must be called when all refs are resolved.
+func PrepareRecursiveTypes(sources map[string]ObjectSource) {
+	logger := types.NewLogger("Linking 🔗")
+	specIDs := lo.Keys(sources)
+	slices.Sort(specIDs)
+	roots := lo.FlatMap(specIDs, func(specID string, _ int) []common.GolangType {
+		return lo.FilterMap(sources[specID].AllObjects(), func(obj compiler.Object, _ int) (common.GolangType, bool) {
+			v, ok := obj.Object.(common.GolangType)
+			return v, ok
+		})
+	})
+
+	for _, typ := range render.NameRecursiveTypes(roots) {
+		logger.Debug("Recursive anonymous type becomes named", "type", typ.TypeName())
+	}
+	for _, field := range render.PointerizeRecursiveFields(roots) {
+		logger.Debug("Recursive struct field becomes pointer", "field", field)
+	}
+	for _, typ := range render.NameDefaultedStructs(roots) {
+		logger.Debug("Anonymous struct with defaults becomes named", "type", typ.TypeName())
+	}
+}
+
+// FlattenAllOf merges the allOf schemas to the structs they are set in. The nested allOf structs are merged first.
+// Returns error if schemas have the conflicting properties or refer to each other in allOf in a loop. Must be called
+// when all refs are resolved.
+func FlattenAllOf(sources map[string]ObjectSource) error {
+	logger := types.NewLogger("Linking 🔗")
+	specIDs := lo.Keys(sources)
+	slices.Sort(specIDs)
+	objects := make(map[*render.GoStruct]compiler.Object)
+	var structs []*render.GoStruct
+	for _, specID := range specIDs {
+		for _, obj := range sources[specID].AllObjects() {
+			if v, ok := obj.Object.(*render.GoStruct); ok && len(v.AllOf) > 0 {
+				objects[v] = obj
+				structs = append(structs, v)
+			}
+		}
+	}
+
+	var stack []*render.GoStruct
+	var flatten func(s *render.GoStruct) error
+	flatten = func(s *render.GoStruct) error {
+		obj := objects[s]
+		if lo.Contains(stack, s) {
+			return types.CompileError{Err: errors.New("allOf schemas refer to each other in a loop"), Path: specurl.BuildRef(obj.Path...), Source: obj.Source}
+		}
+		stack = append(stack, s)
+		defer func() { stack = stack[:len(stack)-1] }()
+		for _, item := range s.AllOfStructs() {
+			if err := flatten(item); err != nil {
+				return err
+			}
+		}
+		if len(s.AllOf) == 0 {
+			return nil // Merged already
+		}
+		logger.Debug("Merge allOf schemas", "struct", s.Name, "count", len(s.AllOf))
+		if err := s.MergeAllOf(); err != nil {
+			return types.CompileError{Err: err, Path: specurl.BuildRef(obj.Path...), Source: obj.Source}
+		}
+		return nil
+	}
+	for _, s := range structs {
+		if err := flatten(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func DanglingPromisesCount(sources map[string]ObjectSource) int {
 	c := lo.SumBy(lo.Values(sources), func(item ObjectSource) int {
 		return lo.CountBy(item.ListPromises(), func(p common.ObjectListPromise) bool { return !p.Assigned() })
@@ -94,14 +188,91 @@ func DanglingPromisesCount(sources map[string]ObjectSource) int {
 	return c + len(DanglingRefs(sources))
 }
 
-func DanglingRefs(sources map[string]ObjectSource) []string {
-	return lo.FlatMap(lo.Values(sources), func(src ObjectSource, _ int) []string {
-		return lo.FilterMap(src.Promises(), func(p common.ObjectPromise, _ int) (string, bool) {
-			return p.Ref(), !p.Assigned()
+func DanglingRefs(sources map[string]ObjectSource) []DanglingRef {
+	specIDs := lo.Keys(sources)
+	slices.Sort(specIDs)
+	return lo.FlatMap(specIDs, func(specID string, _ int) []DanglingRef {
+		src := sources[specID]
+		dangling := lo.Reject(src.Promises(), func(p common.ObjectPromise, _ int) bool { return p.Assigned() })
+		// The object with dangling `$ref` is not compiled, so the internal promises made for it dangle as well.
+		// They are reported only if there is no user ref, that explains them.
+		userRefs := lo.FilterMap(dangling, func(p common.ObjectPromise, _ int) (string, bool) {
+			if s := src.PromiseSource(p); s != nil && p.Origin() == common.PromiseOriginUser {
+				return s.Pointer, true
+			}
+			return "", false
+		})
+		return lo.FilterMap(dangling, func(p common.ObjectPromise, _ int) (DanglingRef, bool) {
+			if p.Origin() == common.PromiseOriginInternal {
+				explained := lo.ContainsBy(userRefs, func(ref string) bool { return strings.HasPrefix(ref, p.Ref()+"/") })
+				return DanglingRef{Ref: p.Ref()}, !explained
+			}
+			return DanglingRef{Ref: p.Ref(), Source: src.PromiseSource(p)}, true
 		})
 	})
 }
 
+// RefLoops returns the chains of refs which end up in a loop of refs pointing to each other, so they can never be
+// resolved, e.g. ["#/components/schemas/A", "#/components/schemas/B", "#/components/schemas/A"]. Every loop is
+// reported once.
+func RefLoops(sources map[string]ObjectSource) [][]string {
+	var res [][]string
+	seen := make(map[string]bool)
+	specIDs := lo.Keys(sources)
+	slices.Sort(specIDs)
+	for _, srcSpecID := range specIDs {
+		for _, p := range sources[srcSpecID].Promises() {
+			if p.Assigned() || p.FindCallback() != nil {
+				continue
+			}
+			chain, targets, loopStart := refChain(p, srcSpecID, sources)
+			if loopStart < 0 {
+				continue
+			}
+			loop := slices.Clone(targets[loopStart:])
+			slices.Sort(loop)
+			if key := strings.Join(loop, " "); !seen[key] {
+				seen[key] = true
+				res = append(res, chain)
+			}
+		}
+	}
+	return res
+}
+
+// refChain follows the unresolved refs starting from the given promise. Returns the refs chain, the absolute refs
+// of the chain items and the index the loop starts at, -1 if the chain has no loop.
+func refChain(p common.ObjectPromise, srcSpecID string, sources map[string]ObjectSource) ([]string, []string, int) {
+	var chain, targets []string
+	startSpecID := srcSpecID
+	for {
+		ref := specurl.Parse(p.Ref())
+		if ref.IsExternal() {
+			srcSpecID = ref.SpecID
+		}
+		target := specurl.URL{SpecID: srcSpecID, Pointer: ref.Pointer}
+		if idx := lo.IndexOf(targets, target.String()); idx >= 0 {
+			return append(chain, chain[idx]), targets, idx
+		}
+		targets = append(targets, target.String())
+		chain = append(chain, lo.Ternary(srcSpecID == startSpecID, p.Ref(), target.String()))
+
+		src, ok := sources[srcSpecID]
+		if !ok {
+			return chain, targets, -1
+		}
+		found := lo.Filter(src.AllObjects(), func(obj compiler.Object, _ int) bool { return ref.MatchPointer(obj.Path) })
+		if len(found) != 1 {
+			return chain, targets, -1
+		}
+		next, ok := found[0].Object.(common.ObjectPromise)
+		if !ok || next.Assigned() || next.FindCallback() != nil {
+			return chain, targets, -1
+		}
+		p = next
+	}
+}
+
 func Stats(sources map[string]ObjectSource) string {
 	promises := lo.FlatMap(lo.Values(sources), func(item ObjectSource, _ int) []common.ObjectPromise { return item.Promises() })
 	listPromises := lo.FlatMap(lo.Values(sources), func(item ObjectSource, _ int) []common.ObjectListPromise { return item.ListPromises() })
@@ -117,7 +288,9 @@ func Stats(sources map[string]ObjectSource) string {
 	)
 }
 
-// TODO: detect ref loops to avoid infinite recursion
+// resolvePromise returns the object the promise points to. If it points to another promise, the latter is followed
+// only if it has been assigned already, so the promises pointing to each other in a loop remain unassigned, see
+// RefLoops.
 // TODO: external refs can not be resolved at first time -- leave them unresolved
 func resolvePromise(p common.ObjectPromise, srcSpecID string, sources map[string]ObjectSource) (common.Renderer, bool) {
 	tgtSpecID := srcSpecID
@@ -136,6 +309,9 @@ func resolvePromise(p common.ObjectPromise, srcSpecID string, sources map[string
 		cb = qcb
 	}
 	found := lo.Filter(srcObjects, func(obj compiler.Object, _ int) bool { return cb(obj.Object, obj.Path) })
+	if len(found) == 0 {
+		return nil, false // Leave it dangling to be reported
+	}
 	if len(found) != 1 {
 		panic(fmt.Sprintf("Ref %q must point to one object, but %d objects found", p.Ref(), len(found)))
 	}
@@ -156,14 +332,21 @@ func resolvePromise(p common.ObjectPromise, srcSpecID string, sources map[string
 	}
 }
 
-// TODO: detect ref loops to avoid infinite recursion
-func resolveListPromise(p common.ObjectListPromise, srcSpecID string, sources map[string]ObjectSource) ([]common.Renderer, bool) {
+// resolveListPromise returns the objects the list promise points to. If the list promises found are followed as
+// well, parents contains the list promises being resolved on the upper levels, to skip the ones pointing to each
+// other in a loop.
+func resolveListPromise(p common.ObjectListPromise, srcSpecID string, sources map[string]ObjectSource, parents []common.ObjectListPromise) ([]common.Renderer, bool) {
 	// Exclude links from selection in order to avoid duplicates in list
 	cb := func(obj common.Renderer, _ []string) bool { return !isPromise(obj) }
 	if qcb := p.FindCallback(); qcb != nil {
 		cb = qcb
 	}
 	srcObjects := sources[srcSpecID].AllObjects()
+	if sources[srcSpecID].SpecKind() != compiler.SpecKindAsyncapi || p.AllDocuments() {
+		// Schema documents contain only models, so their list promises (e.g. messages the model is used in, to make
+		// struct tags) are resolved by objects from all documents
+		srcObjects = lo.FlatMap(lo.Values(sources), func(item ObjectSource, _ int) []compiler.Object { return item.AllObjects() })
+	}
 	found := lo.Filter(srcObjects, func(obj compiler.Object, _ int) bool {
 		return cb(obj.Object, obj.Path)
 	})
@@ -181,10 +364,13 @@ func resolveListPromise(p common.ObjectListPromise, srcSpecID string, sources ma
 			}
 			results = append(results, resolved)
 		case common.ObjectListPromise:
+			if v == p || lo.Contains(parents, v) {
+				continue // Loop, the objects of this promise are being collected already
+			}
 			if !v.Assigned() {
 				return results, false
 			}
-			resolved, ok := resolveListPromise(v, srcSpecID, sources)
+			resolved, ok := resolveListPromise(v, srcSpecID, sources, append(parents, p))
 			if !ok {
 				return results, false
 			}
@@ -0,0 +1,160 @@
+package linker
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/compiler"
+	"github.com/xcnt/go-asyncapi/internal/render"
+	"github.com/xcnt/go-asyncapi/internal/types"
+)
+
+type stubSource struct {
+	objects  []compiler.Object
+	promises []common.ObjectPromise
+}
+
+func (s stubSource) AllObjects() []compiler.Object            { return s.objects }
+func (s stubSource) Promises() []common.ObjectPromise         { return s.promises }
+func (s stubSource) ListPromises() []common.ObjectListPromise { return nil }
+func (s stubSource) SpecKind() compiler.SpecKind              { return compiler.SpecKindAsyncapi }
+func (s stubSource) PromiseSource(common.ObjectPromise) *types.SourcePosition {
+	return nil
+}
+
+func newStubSource(objects map[string]common.Renderer) stubSource {
+	var res stubSource
+	for path, obj := range objects {
+		res.objects = append(res.objects, compiler.Object{Object: obj, Path: []string{"components", "schemas", path}})
+		if p, ok := obj.(common.ObjectPromise); ok {
+			res.promises = append(res.promises, p)
+		}
+	}
+	return res
+}
+
+func TestRefLoops(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects map[string]common.Renderer
+		want    [][]string
+	}{
+		{
+			"loop",
+			map[string]common.Renderer{
+				"A": render.NewGolangTypePromise("#/components/schemas/B", common.PromiseOriginUser),
+				"B": render.NewGolangTypePromise("#/components/schemas/A", common.PromiseOriginUser),
+			},
+			[][]string{{"#/components/schemas/A", "#/components/schemas/B", "#/components/schemas/A"}},
+		},
+		{
+			"self-reference",
+			map[string]common.Renderer{
+				"A": render.NewGolangTypePromise("#/components/schemas/A", common.PromiseOriginUser),
+			},
+			[][]string{{"#/components/schemas/A", "#/components/schemas/A"}},
+		},
+		{
+			"no loop",
+			map[string]common.Renderer{
+				"A": render.NewGolangTypePromise("#/components/schemas/B", common.PromiseOriginUser),
+				"B": render.NewGolangTypePromise("#/components/schemas/C", common.PromiseOriginUser),
+				"C": &render.GoSimple{Name: "string"},
+			},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources := map[string]ObjectSource{"spec.yaml": newStubSource(tt.objects)}
+			AssignRefs(sources)
+			got := RefLoops(sources)
+			// Loop may be reported starting from any ref in it
+			if len(got) != len(tt.want) || len(got) > 0 && len(got[0]) != len(tt.want[0]) {
+				t.Errorf("expect %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrepareRecursiveTypes(t *testing.T) {
+	node := &render.GoStruct{BaseType: render.BaseType{Name: "Node", DirectRender: true}}
+	parentPrm := render.NewGolangTypePromise("#/components/schemas/Node", common.PromiseOriginUser)
+	childrenPrm := render.NewGolangTypePromise("#/components/schemas/Node", common.PromiseOriginUser)
+	node.Fields = []render.GoStructField{
+		{Name: "Parent", Type: parentPrm},
+		{Name: "Children", Type: &render.GoArray{ItemsType: childrenPrm}},
+	}
+	inline := &render.GoStruct{BaseType: render.BaseType{Name: "Inline"}}
+	inlinePrm := render.NewGolangTypePromise("#/components/schemas/Inline", common.PromiseOriginUser)
+	inline.Fields = []render.GoStructField{{Name: "Items", Type: &render.GoArray{ItemsType: inlinePrm}}}
+
+	sources := map[string]ObjectSource{"spec.yaml": stubSource{
+		objects: []compiler.Object{
+			{Object: node, Path: []string{"components", "schemas", "Node"}},
+			{Object: inline, Path: []string{"components", "schemas", "Inline"}},
+		},
+		promises: []common.ObjectPromise{parentPrm, childrenPrm, inlinePrm},
+	}}
+	AssignRefs(sources)
+	PrepareRecursiveTypes(sources)
+
+	if typ := reflect.TypeOf(node.Fields[0].Type); typ != reflect.TypeOf(&render.GoPointer{}) {
+		t.Errorf("expect Node.Parent to be pointer, got %v", typ)
+	}
+	if typ := reflect.TypeOf(node.Fields[1].Type); typ != reflect.TypeOf(&render.GoArray{}) {
+		t.Errorf("expect Node.Children to be left as array, got %v", typ)
+	}
+	if !inline.DirectRender {
+		t.Error("expect recursive anonymous struct to become named")
+	}
+}
+
+func TestFlattenAllOf(t *testing.T) {
+	str := &render.GoSimple{Name: "string"}
+	newStruct := func(name string, required []string, fields ...render.GoStructField) *render.GoStruct {
+		return &render.GoStruct{BaseType: render.BaseType{Name: name, DirectRender: true}, Fields: fields, RequiredProperties: required}
+	}
+	basePrm := render.NewGolangTypePromise("#/components/schemas/Base", common.PromiseOriginUser)
+	base := newStruct("Base", nil, render.GoStructField{Name: "ID", MarshalName: "id", Type: &render.GoPointer{Type: str}, OmitEmpty: true})
+	inline := newStruct("Inline", []string{"id"}, render.GoStructField{Name: "Name", MarshalName: "name", Type: str})
+	event := newStruct("Event", nil, render.GoStructField{Name: "Note", MarshalName: "note", Type: str})
+	event.AllOf = []render.AllOfPart{{Type: basePrm}, {Type: inline, Inline: true}}
+
+	conflictPrm := render.NewGolangTypePromise("#/components/schemas/Base", common.PromiseOriginUser)
+	conflict := newStruct("Conflict", nil, render.GoStructField{Name: "ID", MarshalName: "id", Type: &render.GoSimple{Name: "int"}})
+	conflict.AllOf = []render.AllOfPart{{Type: conflictPrm}}
+
+	newSources := func(objects ...compiler.Object) map[string]ObjectSource {
+		return map[string]ObjectSource{"spec.yaml": stubSource{objects: objects, promises: []common.ObjectPromise{basePrm, conflictPrm}}}
+	}
+	baseObj := compiler.Object{Object: base, Path: []string{"components", "schemas", "Base"}}
+
+	sources := newSources(baseObj, compiler.Object{Object: event, Path: []string{"components", "schemas", "Event"}})
+	AssignRefs(sources)
+	if err := FlattenAllOf(sources); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(event.Fields))
+	for _, f := range event.Fields {
+		names = append(names, f.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"ID", "Name", "Note"}) {
+		t.Errorf("expect fields [ID Name Note], got %v", names)
+	}
+	if f := event.Fields[0]; !f.Required || f.OmitEmpty || f.Type != str {
+		t.Error("expect Event.ID to be required value")
+	}
+	if inline.DirectRender {
+		t.Error("expect inline allOf schema not to be rendered")
+	}
+
+	sources = newSources(baseObj, compiler.Object{Object: conflict, Path: []string{"components", "schemas", "Conflict"}})
+	AssignRefs(sources)
+	var cErr types.CompileError
+	if err := FlattenAllOf(sources); !errors.As(err, &cErr) || cErr.Path != "#/components/schemas/Conflict" {
+		t.Errorf("expect compile error for Conflict, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Package protobuf contains a parser of Protocol Buffers (proto3) files. It recognizes only the definitions the code
+// generation needs: messages, enums, fields, oneofs and maps. Options, services, reserved ranges and extensions are
+// skipped.
+package protobuf
+
+type File struct {
+	Syntax   string
+	Package  string
+	Imports  []string
+	Messages []*Message
+	Enums    []*Enum
+}
+
+type Message struct {
+	Name     string
+	Fields   []*Field
+	Messages []*Message // Nested messages
+	Enums    []*Enum    // Nested enums
+}
+
+type Field struct {
+	Name     string
+	Type     string // Scalar type name or message/enum reference, for map fields this is a value type
+	MapKey   string // Key type if field is map
+	Number   int
+	Repeated bool
+	Optional bool   // Field has `optional` label
+	Oneof    string // Name of oneof the field belongs to, if any
+}
+
+type Enum struct {
+	Name   string
+	Values []EnumValue
+}
+
+type EnumValue struct {
+	Name   string
+	Number int
+}
+
+// scalarGoTypes maps the protobuf scalar value types to Go types
+var scalarGoTypes = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"int64":    "int64",
+	"uint32":   "uint32",
+	"uint64":   "uint64",
+	"sint32":   "int32",
+	"sint64":   "int64",
+	"fixed32":  "uint32",
+	"fixed64":  "uint64",
+	"sfixed32": "int32",
+	"sfixed64": "int64",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "[]byte",
+}
+
+// ScalarGoType returns the Go type for a protobuf scalar type. Returns false if typ is not a scalar type, i.e. it's
+// a message or enum reference.
+func ScalarGoType(typ string) (string, bool) {
+	res, ok := scalarGoTypes[typ]
+	return res, ok
+}
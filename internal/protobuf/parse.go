@@ -0,0 +1,488 @@
+package protobuf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parse parses the proto3 file contents
+func Parse(data []byte) (*File, error) {
+	p := parser{lexer: lexer{src: []rune(string(data)), line: 1, col: 1}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	return p.parseFile()
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind      tokenKind
+	value     string
+	line, col int
+}
+
+type lexer struct {
+	src       []rune
+	pos       int
+	line, col int
+}
+
+func (l *lexer) peekRune(offset int) rune {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+func (l *lexer) advance() rune {
+	r := l.src[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return r
+}
+
+func (l *lexer) skipSpaceAndComments() error {
+	for l.pos < len(l.src) {
+		switch r := l.peekRune(0); {
+		case unicode.IsSpace(r):
+			l.advance()
+		case r == '/' && l.peekRune(1) == '/':
+			for l.pos < len(l.src) && l.peekRune(0) != '\n' {
+				l.advance()
+			}
+		case r == '/' && l.peekRune(1) == '*':
+			line, col := l.line, l.col
+			l.advance()
+			l.advance()
+			for !(l.peekRune(0) == '*' && l.peekRune(1) == '/') {
+				if l.pos >= len(l.src) {
+					return fmt.Errorf("%d:%d: unterminated comment", line, col)
+				}
+				l.advance()
+			}
+			l.advance()
+			l.advance()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (l *lexer) nextToken() (token, error) {
+	if err := l.skipSpaceAndComments(); err != nil {
+		return token{}, err
+	}
+	tok := token{line: l.line, col: l.col}
+	if l.pos >= len(l.src) {
+		tok.kind = tokenEOF
+		return tok, nil
+	}
+
+	var bld strings.Builder
+	switch r := l.peekRune(0); {
+	case unicode.IsLetter(r) || r == '_':
+		tok.kind = tokenIdent
+		for l.pos < len(l.src) && (unicode.IsLetter(l.peekRune(0)) || unicode.IsDigit(l.peekRune(0)) || l.peekRune(0) == '_') {
+			bld.WriteRune(l.advance())
+		}
+	case unicode.IsDigit(r):
+		// Integer or float literal, floats are met only in options, so they are not parsed precisely
+		tok.kind = tokenNumber
+		for l.pos < len(l.src) {
+			c := l.peekRune(0)
+			isExpSign := (c == '+' || c == '-') && strings.ContainsRune("eE", l.src[l.pos-1])
+			if !unicode.IsDigit(c) && !unicode.IsLetter(c) && c != '.' && !isExpSign {
+				break
+			}
+			bld.WriteRune(l.advance())
+		}
+	case r == '"' || r == '\'':
+		tok.kind = tokenString
+		quote := l.advance()
+		for {
+			if l.pos >= len(l.src) || l.peekRune(0) == '\n' {
+				return token{}, fmt.Errorf("%d:%d: unterminated string", tok.line, tok.col)
+			}
+			c := l.advance()
+			if c == quote {
+				break
+			}
+			if c == '\\' && l.pos < len(l.src) {
+				c = l.advance()
+			}
+			bld.WriteRune(c)
+		}
+	default:
+		tok.kind = tokenSymbol
+		bld.WriteRune(l.advance())
+	}
+	tok.value = bld.String()
+	return tok, nil
+}
+
+type parser struct {
+	lexer lexer
+	tok   token
+}
+
+func (p *parser) next() (err error) {
+	p.tok, err = p.lexer.nextToken()
+	return
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%d:%d: %s", p.tok.line, p.tok.col, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) is(value string) bool {
+	return p.tok.kind != tokenString && p.tok.value == value
+}
+
+func (p *parser) expect(value string) error {
+	if !p.is(value) {
+		return p.errorf("expected %q, got %q", value, p.tok.value)
+	}
+	return p.next()
+}
+
+func (p *parser) expectKind(kind tokenKind, what string) (string, error) {
+	if p.tok.kind != kind {
+		return "", p.errorf("expected %s, got %q", what, p.tok.value)
+	}
+	v := p.tok.value
+	return v, p.next()
+}
+
+// fullIdent reads the dot-separated identifier, such as `foo.Bar` or `.foo.Bar`
+func (p *parser) fullIdent() (string, error) {
+	var bld strings.Builder
+	if p.is(".") {
+		bld.WriteString(".")
+		if err := p.next(); err != nil {
+			return "", err
+		}
+	}
+	for {
+		v, err := p.expectKind(tokenIdent, "identifier")
+		if err != nil {
+			return "", err
+		}
+		bld.WriteString(v)
+		if !p.is(".") {
+			return bld.String(), nil
+		}
+		bld.WriteString(".")
+		if err = p.next(); err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *parser) integer() (int, error) {
+	sign := 1
+	if p.is("-") {
+		sign = -1
+		if err := p.next(); err != nil {
+			return 0, err
+		}
+	}
+	if p.tok.kind != tokenNumber {
+		return 0, p.errorf("expected integer, got %q", p.tok.value)
+	}
+	v, err := strconv.ParseInt(p.tok.value, 0, 64)
+	if err != nil {
+		return 0, p.errorf("bad integer %q", p.tok.value)
+	}
+	return sign * int(v), p.next()
+}
+
+// skipStatement skips tokens until the end of statement, considering the nested brackets, e.g. in options
+func (p *parser) skipStatement() error {
+	depth := 0
+	for {
+		switch {
+		case p.tok.kind == tokenEOF:
+			return p.errorf("unexpected end of file")
+		case p.tok.kind == tokenSymbol && strings.Contains("{[(", p.tok.value):
+			depth++
+		case p.tok.kind == tokenSymbol && strings.Contains("}])", p.tok.value):
+			depth--
+			if depth == 0 && p.tok.value == "}" {
+				return p.next() // Block statement, e.g. `service Foo {...}`
+			}
+		case p.is(";") && depth == 0:
+			return p.next()
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *parser) parseFile() (*File, error) {
+	res := File{}
+	for p.tok.kind != tokenEOF {
+		var err error
+		switch {
+		case p.is("syntax") || p.is("edition"):
+			if err = p.next(); err != nil {
+				return nil, err
+			}
+			if err = p.expect("="); err != nil {
+				return nil, err
+			}
+			if res.Syntax, err = p.expectKind(tokenString, "syntax string"); err != nil {
+				return nil, err
+			}
+			if res.Syntax != "proto3" {
+				return nil, fmt.Errorf("syntax %q is not supported, only proto3 is", res.Syntax)
+			}
+			err = p.expect(";")
+		case p.is("package"):
+			if err = p.next(); err != nil {
+				return nil, err
+			}
+			if res.Package, err = p.fullIdent(); err != nil {
+				return nil, err
+			}
+			err = p.expect(";")
+		case p.is("import"):
+			if err = p.next(); err != nil {
+				return nil, err
+			}
+			if p.is("weak") || p.is("public") {
+				if err = p.next(); err != nil {
+					return nil, err
+				}
+			}
+			var imp string
+			if imp, err = p.expectKind(tokenString, "import path"); err != nil {
+				return nil, err
+			}
+			res.Imports = append(res.Imports, imp)
+			err = p.expect(";")
+		case p.is("message"):
+			var m *Message
+			if m, err = p.parseMessage(); err == nil {
+				res.Messages = append(res.Messages, m)
+			}
+		case p.is("enum"):
+			var e *Enum
+			if e, err = p.parseEnum(); err == nil {
+				res.Enums = append(res.Enums, e)
+			}
+		case p.is(";"):
+			err = p.next()
+		case p.is("option") || p.is("service") || p.is("extend"):
+			err = p.skipStatement()
+		default:
+			return nil, p.errorf("unexpected %q", p.tok.value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if res.Syntax == "" {
+		return nil, fmt.Errorf("syntax is not set, only proto3 is supported")
+	}
+	return &res, nil
+}
+
+func (p *parser) parseMessage() (*Message, error) {
+	if err := p.expect("message"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectKind(tokenIdent, "message name")
+	if err != nil {
+		return nil, err
+	}
+	res := Message{Name: name}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.is("}") {
+		switch {
+		case p.is("message"):
+			var m *Message
+			if m, err = p.parseMessage(); err == nil {
+				res.Messages = append(res.Messages, m)
+			}
+		case p.is("enum"):
+			var e *Enum
+			if e, err = p.parseEnum(); err == nil {
+				res.Enums = append(res.Enums, e)
+			}
+		case p.is("oneof"):
+			var fields []*Field
+			if fields, err = p.parseOneof(); err == nil {
+				res.Fields = append(res.Fields, fields...)
+			}
+		case p.is(";"):
+			err = p.next()
+		case p.is("option") || p.is("reserved") || p.is("extensions") || p.is("extend"):
+			err = p.skipStatement()
+		case p.tok.kind == tokenEOF:
+			return nil, p.errorf("unexpected end of file in message %q", name)
+		default:
+			var f *Field
+			if f, err = p.parseField(); err == nil {
+				res.Fields = append(res.Fields, f)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &res, p.next()
+}
+
+func (p *parser) parseOneof() ([]*Field, error) {
+	if err := p.expect("oneof"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectKind(tokenIdent, "oneof name")
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	var res []*Field
+	for !p.is("}") {
+		switch {
+		case p.is("option"):
+			err = p.skipStatement()
+		case p.is(";"):
+			err = p.next()
+		case p.tok.kind == tokenEOF:
+			return nil, p.errorf("unexpected end of file in oneof %q", name)
+		default:
+			var f *Field
+			if f, err = p.parseField(); err == nil {
+				f.Oneof = name
+				res = append(res, f)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, p.next()
+}
+
+func (p *parser) parseField() (*Field, error) {
+	var res Field
+	var err error
+	switch {
+	case p.is("repeated"):
+		res.Repeated = true
+		err = p.next()
+	case p.is("optional"):
+		res.Optional = true
+		err = p.next()
+	case p.is("required"):
+		return nil, p.errorf("required fields are not supported in proto3")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.is("map") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if err = p.expect("<"); err != nil {
+			return nil, err
+		}
+		if res.MapKey, err = p.fullIdent(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+		if res.Type, err = p.fullIdent(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(">"); err != nil {
+			return nil, err
+		}
+	} else if res.Type, err = p.fullIdent(); err != nil {
+		return nil, err
+	}
+
+	if res.Name, err = p.expectKind(tokenIdent, "field name"); err != nil {
+		return nil, err
+	}
+	if err = p.expect("="); err != nil {
+		return nil, err
+	}
+	if res.Number, err = p.integer(); err != nil {
+		return nil, err
+	}
+	if p.is("[") { // Field options
+		return &res, p.skipStatement()
+	}
+	return &res, p.expect(";")
+}
+
+func (p *parser) parseEnum() (*Enum, error) {
+	if err := p.expect("enum"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectKind(tokenIdent, "enum name")
+	if err != nil {
+		return nil, err
+	}
+	res := Enum{Name: name}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.is("}") {
+		switch {
+		case p.is("option") || p.is("reserved"):
+			err = p.skipStatement()
+		case p.is(";"):
+			err = p.next()
+		case p.tok.kind == tokenEOF:
+			return nil, p.errorf("unexpected end of file in enum %q", name)
+		default:
+			var v EnumValue
+			if v.Name, err = p.expectKind(tokenIdent, "enum value name"); err != nil {
+				return nil, err
+			}
+			if err = p.expect("="); err != nil {
+				return nil, err
+			}
+			if v.Number, err = p.integer(); err != nil {
+				return nil, err
+			}
+			res.Values = append(res.Values, v)
+			if p.is("[") {
+				err = p.skipStatement()
+			} else {
+				err = p.expect(";")
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &res, p.next()
+}
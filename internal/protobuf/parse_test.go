@@ -0,0 +1,100 @@
+package protobuf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *File
+	}{
+		{
+			"message with nested types",
+			`syntax = "proto3";
+			package shop.v1;
+			import "google/protobuf/timestamp.proto";
+			option go_package = "example.com/shop";
+
+			// Order comment
+			message Order {
+				enum Status { UNKNOWN = 0; PAID = 1 [deprecated = true]; }
+				message Item { string sku = 1; }
+				reserved 10 to 12;
+				string id = 1;
+				repeated Item items = 2 [packed = false];
+				map<string, int64> attrs = 3;
+				optional Status status = 4;
+				oneof payment {
+					string card = 5;
+					/* cash */ int64 cash = 6;
+				}
+			}`,
+			&File{
+				Syntax:  "proto3",
+				Package: "shop.v1",
+				Imports: []string{"google/protobuf/timestamp.proto"},
+				Messages: []*Message{{
+					Name: "Order",
+					Fields: []*Field{
+						{Name: "id", Type: "string", Number: 1},
+						{Name: "items", Type: "Item", Number: 2, Repeated: true},
+						{Name: "attrs", Type: "int64", MapKey: "string", Number: 3},
+						{Name: "status", Type: "Status", Number: 4, Optional: true},
+						{Name: "card", Type: "string", Number: 5, Oneof: "payment"},
+						{Name: "cash", Type: "int64", Number: 6, Oneof: "payment"},
+					},
+					Messages: []*Message{{Name: "Item", Fields: []*Field{{Name: "sku", Type: "string", Number: 1}}}},
+					Enums:    []*Enum{{Name: "Status", Values: []EnumValue{{"UNKNOWN", 0}, {"PAID", 1}}}},
+				}},
+			},
+		},
+		{
+			"services and top-level enums",
+			`syntax = 'proto3';
+			enum Kind { KIND_A = 0; KIND_B = -1; }
+			message Empty {}
+			service S { rpc Get(Empty) returns (Empty) { option idempotency_level = NO_SIDE_EFFECTS; } }`,
+			&File{
+				Syntax:   "proto3",
+				Messages: []*Message{{Name: "Empty"}},
+				Enums:    []*Enum{{Name: "Kind", Values: []EnumValue{{"KIND_A", 0}, {"KIND_B", -1}}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expect %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"proto2", `syntax = "proto2";`, `syntax "proto2" is not supported`},
+		{"no syntax", `message A {}`, "syntax is not set"},
+		{"bad field", "syntax = \"proto3\";\nmessage A {\n  string = 1;\n}", "3:10: expected field name"},
+		{"unterminated", `syntax = "proto3"; message A { string a = 1;`, "unexpected end of file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expect error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+// AllOfPart is a schema from allOf, which fields are merged to the struct
+type AllOfPart struct {
+	Type   common.GolangType
+	Inline bool // Inline schema is not rendered separately, since it becomes a part of struct
+}
+
+// AllOfStructs returns the structs from allOf parts, that have allOf parts themselves, so they must be merged first
+func (s *GoStruct) AllOfStructs() []*GoStruct {
+	return lo.FilterMap(s.AllOf, func(item AllOfPart, _ int) (*GoStruct, bool) {
+		strct, ok := allOfStruct(item.Type)
+		return strct, ok && len(strct.AllOf) > 0
+	})
+}
+
+// MergeAllOf merges the fields, required properties, description and default value of allOf parts to the struct.
+// The fields of parts go first, then the struct own fields. Parts that are not structs (e.g. unions) are embedded. Returns error if the
+// same property has different types in different parts. Must be called when all refs are resolved and the
+// AllOfStructs have been merged.
+func (s *GoStruct) MergeAllOf() error {
+	if len(s.AllOf) == 0 {
+		return nil
+	}
+	required := append([]string{}, s.RequiredProperties...)
+	var descriptions []string
+	defaults := lo.Ternary(s.Default == nil, map[string]any{}, nil)
+	ownFields := s.Fields
+	s.Fields = nil
+
+	for i, part := range s.AllOf {
+		strct, ok := allOfStruct(part.Type)
+		if !ok {
+			if err := s.mergeField(GoStructField{Type: part.Type}); err != nil {
+				return fmt.Errorf("allOf item %d: %w", i, err)
+			}
+			continue
+		}
+		if part.Inline {
+			strct.DirectRender = false
+		}
+		for _, f := range strct.Fields {
+			if err := s.mergeField(f); err != nil {
+				return fmt.Errorf("allOf item %d: %w", i, err)
+			}
+		}
+		required = append(required, strct.RequiredProperties...)
+		descriptions = append(descriptions, strct.Description)
+		if d, ok := strct.Default.(map[string]any); ok && defaults != nil {
+			defaults = lo.Assign(d, defaults)
+		}
+	}
+
+	for _, f := range ownFields {
+		if err := s.mergeField(f); err != nil {
+			return err
+		}
+	}
+
+	for i, f := range s.Fields {
+		if f.MarshalName != "" && lo.Contains(required, f.MarshalName) && !f.Required {
+			s.Fields[i].Required = true
+			s.Fields[i].OmitEmpty = false
+			if p, ok := f.Type.(*GoPointer); ok && f.OmitEmpty {
+				s.Fields[i].Type = p.Type // Required property is a value, see --optional-fields
+			}
+		}
+	}
+	s.RequiredProperties = lo.Uniq(required)
+	if s.Description == "" {
+		s.Description = utils.JoinNonemptyStrings("\n", descriptions...)
+	}
+	if len(defaults) > 0 {
+		s.Default = defaults
+	}
+	if len(s.extraPropertiesFields()) > 0 {
+		s.DirectRender = true // Struct gets the marshal methods
+	}
+	s.AllOf = nil
+	return nil
+}
+
+// mergeField adds the field to struct. If struct has the field for the same property already, the field type must
+// be the same, and its description and default are used if the existing field has none.
+func (s *GoStruct) mergeField(f GoStructField) error {
+	idx := lo.IndexOf(lo.Map(s.Fields, func(item GoStructField, _ int) string { return fieldKey(item) }), fieldKey(f))
+	if idx < 0 || f.Name == "" && s.Fields[idx].Type != f.Type { // Different embedded types never conflict
+		s.Fields = append(s.Fields, f)
+		return nil
+	}
+	existing := &s.Fields[idx]
+	if !sameType(existing.Type, f.Type, make(map[[2]common.GolangType]bool)) {
+		return fmt.Errorf("property %q has different types in allOf schemas: %s and %s", fieldKey(f), allOfTypeName(existing.Type), allOfTypeName(f.Type))
+	}
+	existing.Description, _ = lo.Coalesce(existing.Description, f.Description)
+	if existing.Default == nil {
+		existing.Default = f.Default
+	}
+	return nil
+}
+
+// allOfStruct returns the struct the allOf part is, if any
+func allOfStruct(typ common.GolangType) (*GoStruct, bool) {
+	typ = unwrapPromise(typ)
+	if p, ok := typ.(*GoPointer); ok {
+		typ = unwrapPromise(p.Type)
+	}
+	res, ok := typ.(*GoStruct)
+	return res, ok
+}
+
+// allOfTypeName returns the type name for error messages, the anonymous aliases are replaced by aliased types
+func allOfTypeName(typ common.GolangType) string {
+	typ = unwrapPromise(typ)
+	if p, ok := typ.(*GoPointer); ok {
+		typ = unwrapPromise(p.Type)
+	}
+	if a, ok := typ.(*GoTypeAlias); ok && !a.DirectRender {
+		return allOfTypeName(a.AliasedType)
+	}
+	return typ.TypeName()
+}
+
+// fieldKey returns the key the fields from different allOf parts are matched by
+func fieldKey(f GoStructField) string {
+	res, _ := lo.Coalesce(f.MarshalName, f.Name)
+	return res
+}
+
+// sameType returns true if the types are rendered the same way. The pointers are ignored, since the pointer is set
+// for optional properties, and required flag is merged separately. The anonymous types are compared by their contents, seen contains
+// the pairs of types being compared, to stop on recursive types.
+func sameType(a, b common.GolangType, seen map[[2]common.GolangType]bool) bool {
+	a, b = unwrapPromise(a), unwrapPromise(b)
+	if p, ok := a.(*GoPointer); ok {
+		a = unwrapPromise(p.Type)
+	}
+	if p, ok := b.(*GoPointer); ok {
+		b = unwrapPromise(p.Type)
+	}
+	if a == b || seen[[2]common.GolangType{a, b}] {
+		return true
+	}
+	seen[[2]common.GolangType{a, b}] = true
+
+	switch va := a.(type) {
+	case *GoSimple:
+		vb, ok := b.(*GoSimple)
+		return ok && va.Name == vb.Name && va.Import == vb.Import && va.IsIface == vb.IsIface && len(va.TypeParamValues) == 0 && len(vb.TypeParamValues) == 0
+	case *GoTypeAlias:
+		vb, ok := b.(*GoTypeAlias)
+		if !ok || va.DirectRender || vb.DirectRender {
+			return false
+		}
+		return sameType(va.AliasedType, vb.AliasedType, seen)
+	case *GoArray:
+		vb, ok := b.(*GoArray)
+		if !ok || va.DirectRender || vb.DirectRender {
+			return false
+		}
+		return va.Size == vb.Size && sameType(va.ItemsType, vb.ItemsType, seen)
+	case *GoMap:
+		vb, ok := b.(*GoMap)
+		if !ok || va.DirectRender || vb.DirectRender {
+			return false
+		}
+		return sameType(va.KeyType, vb.KeyType, seen) && sameType(va.ValueType, vb.ValueType, seen)
+	case *GoStruct:
+		vb, ok := b.(*GoStruct)
+		if !ok || va.DirectRender || vb.DirectRender || len(va.Fields) != len(vb.Fields) {
+			return false
+		}
+		for i := range va.Fields {
+			fa, fb := va.Fields[i], vb.Fields[i]
+			if fa.Name != fb.Name || fa.MarshalName != fb.MarshalName || !sameType(fa.Type, fb.Type, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
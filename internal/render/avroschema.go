@@ -0,0 +1,46 @@
+package render
+
+import (
+	j "github.com/dave/jennifer/jen"
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+// AvroSchema registers the Avro schema of a type in the generated encoding package. The Avro encoding is driven by
+// schema, so the encoder and decoder look it up by the type of a value being encoded or decoded.
+type AvroSchema struct {
+	Type     common.GolangType
+	Schema   string
+	FullName string // Full name of the top named type, empty if the schema is not a named type
+}
+
+func (a AvroSchema) DirectRendering() bool {
+	return true
+}
+
+func (a AvroSchema) RenderDefinition(ctx *common.RenderContext) []*j.Statement {
+	ctx.LogStartRender("AvroSchema", "", a.Type.TypeName(), "definition", a.DirectRendering())
+	defer ctx.LogFinishRender()
+
+	typ := utils.ToCode(a.Type.RenderUsage(ctx))
+	return []*j.Statement{
+		j.Func().Id("init").Params().Block(
+			j.Qual(ctx.GeneratedModule(encodingPackageName), "RegisterAvroSchema").Call(
+				j.Qual("reflect", "TypeOf").Call(j.Parens(j.Op("*").Add(typ...)).Call(j.Nil())).Dot("Elem").Call(),
+				j.Lit(a.Schema),
+			),
+		),
+	}
+}
+
+func (a AvroSchema) RenderUsage(_ *common.RenderContext) []*j.Statement {
+	panic("not implemented")
+}
+
+func (a AvroSchema) ID() string {
+	return a.Type.TypeName()
+}
+
+func (a AvroSchema) String() string {
+	return "AvroSchema " + a.Type.TypeName()
+}
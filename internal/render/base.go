@@ -10,13 +10,23 @@ type BaseType struct {
 	// rendering of this type is invoked indirectly by another type.
 	// Such as inlined `field struct{...}` and separate `field StructName`, or `field []type` and `field ArrayName`
 	DirectRender bool
-	Import       string // optional generated package name or module to import a type from
+	Import       string       // optional generated package name or module to import a type from
+	Constraints  *Constraints // JSON Schema validation constraints, nil if type is not a schema model
+	Default      any          // Schema default value: string, bool, json.Number, []any or map[string]any
 }
 
 func (b *BaseType) DirectRendering() bool {
 	return b.DirectRender
 }
 
+func (b BaseType) hasValidateMethod() bool {
+	return b.DirectRender && b.Constraints != nil
+}
+
+func (b BaseType) defaultValue() any {
+	return b.Default
+}
+
 func (b *BaseType) TypeName() string {
 	return b.Name
 }
@@ -2,6 +2,9 @@ package render
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
 
 	"github.com/xcnt/go-asyncapi/internal/common"
 	"github.com/xcnt/go-asyncapi/internal/utils"
@@ -25,9 +28,11 @@ type Channel struct {
 
 	ParametersStruct *GoStruct // nil if no parameters
 
-	PubMessagePromise   *Promise[*Message] // nil when message is not set
-	SubMessagePromise   *Promise[*Message] // nil when message is not set
-	FallbackMessageType common.GolangType  // Used in generated code when the message is not set, typically it's `any`
+	PubMessagePromises  []*Promise[*Message] // Empty when message is not set, several items if operation has `oneOf` messages
+	SubMessagePromises  []*Promise[*Message] // Empty when message is not set, several items if operation has `oneOf` messages
+	PubMessageUnion     *GoInterface         // Interface of all published messages, nil if there is only one message
+	SubMessageUnion     *GoInterface         // Interface of all received messages, nil if there is only one message
+	FallbackMessageType common.GolangType    // Used in generated code when the message is not set, typically it's `any`
 
 	BindingsStruct           *GoStruct           // nil if no bindings are set for channel at all
 	BindingsChannelPromise   *Promise[*Bindings] // nil if channel bindings are not set
@@ -77,6 +82,14 @@ func (c Channel) RenderDefinition(ctx *common.RenderContext) []*j.Statement {
 		}
 	}
 
+	// Message unions
+	if c.PubMessageUnion != nil && len(c.PubMessages()) > 1 {
+		res = append(res, c.renderMessageUnion(ctx, *c.PubMessageUnion, c.PubMessages(), protocols, "Marshal", "EnvelopeWriter")...)
+	}
+	if c.SubMessageUnion != nil && len(c.SubMessages()) > 1 {
+		res = append(res, c.renderMessageUnion(ctx, *c.SubMessageUnion, c.SubMessages(), protocols, "Unmarshal", "EnvelopeReader")...)
+	}
+
 	res = append(res, c.renderChannelNameFunc(ctx)...)
 
 	// Proto channels
@@ -107,6 +120,46 @@ func (c Channel) String() string {
 	return "Channel " + c.Name
 }
 
+// PubMessages returns the messages the channel publishes, omitting the ignored ones
+func (c Channel) PubMessages() []*Message {
+	return messagePromisesTargets(c.PubMessagePromises)
+}
+
+// SubMessages returns the messages the channel receives, omitting the ignored ones
+func (c Channel) SubMessages() []*Message {
+	return messagePromisesTargets(c.SubMessagePromises)
+}
+
+func (c Channel) renderMessageUnion(
+	ctx *common.RenderContext,
+	iface GoInterface,
+	messages []*Message,
+	protocols []string,
+	methodPrefix, envelopeType string,
+) []*j.Statement {
+	ctx.Logger.Trace("renderMessageUnion", "name", iface.Name)
+
+	names := lo.Map(messages, func(item *Message, _ int) string { return item.Name })
+	iface.Description = "One of the messages: " + strings.Join(names, ", ")
+	iface.Methods = lo.Map(protocols, func(p string, _ int) GoFuncSignature {
+		return GoFuncSignature{
+			Name: methodPrefix + ctx.ProtoRenderers[p].ProtocolTitle() + "Envelope",
+			Args: []GoFuncParam{{
+				Name: "envelope",
+				Type: &GoSimple{Name: envelopeType, IsIface: true, Import: ctx.RuntimeModule(p)},
+			}},
+			Return: []GoFuncParam{{Type: &GoSimple{Name: "error", IsIface: true}}},
+		}
+	})
+	return iface.RenderDefinition(ctx)
+}
+
+func messagePromisesTargets(prms []*Promise[*Message]) []*Message {
+	return lo.Uniq(lo.FilterMap(prms, func(item *Promise[*Message], _ int) (*Message, bool) {
+		return item.Target(), !item.Target().Dummy
+	}))
+}
+
 func (c Channel) renderChannelNameFunc(ctx *common.RenderContext) []*j.Statement {
 	ctx.Logger.Trace("renderChannelNameFunc")
 
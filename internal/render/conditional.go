@@ -0,0 +1,141 @@
+package render
+
+import (
+	"encoding/json"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/types"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+// SchemaCheck is a schema, that is checked against the decoded data on unmarshal, because it can't be expressed by
+// Go types, such as conditional schemas (if/then/else, not). It's rendered as run.Schema value.
+type SchemaCheck struct {
+	Type        []string
+	Enum        []any // Values decoded as string, bool, json.Number, []any or map[string]any
+	Required    []string
+	Properties  types.OrderedMap[string, *SchemaCheck]
+	Items       *SchemaCheck
+	Constraints Constraints
+
+	AllOf []*SchemaCheck
+	AnyOf []*SchemaCheck
+	OneOf []*SchemaCheck
+	Not   *SchemaCheck
+	If    *SchemaCheck
+	Then  *SchemaCheck
+	Else  *SchemaCheck
+}
+
+// conditionsVarName returns the name of variable with the struct conditional schemas
+func (s GoStruct) conditionsVarName() string {
+	return utils.ToLowerFirstLetter(s.Name) + "Conditions"
+}
+
+// hasValidateMethod returns true if struct has constraints or conditional schemas to check
+func (s GoStruct) hasValidateMethod() bool {
+	return s.BaseType.hasValidateMethod() || s.DirectRender && s.Conditions != nil
+}
+
+// renderConditionsVar renders the variable with run.Schema of the struct conditional schemas
+func renderConditionsVar(ctx *common.RenderContext, s *GoStruct) []*jen.Statement {
+	return []*jen.Statement{
+		jen.Comment(s.conditionsVarName() + " is the conditional part of " + s.Name + " schema, that is checked on unmarshal and by Validate"),
+		jen.Var().Id(s.conditionsVarName()).Op("=").Qual(ctx.RuntimeModule(""), "Schema").Values(s.Conditions.renderFields(ctx)),
+	}
+}
+
+func (c *SchemaCheck) render(ctx *common.RenderContext) jen.Code {
+	if c == nil {
+		return jen.Nil()
+	}
+	return jen.Op("&").Qual(ctx.RuntimeModule(""), "Schema").Values(c.renderFields(ctx))
+}
+
+// renderElement renders the schema as an element of slice or map literal, where the type is omitted
+func (c *SchemaCheck) renderElement(ctx *common.RenderContext) jen.Code {
+	if c == nil {
+		return jen.Nil()
+	}
+	return jen.Values(c.renderFields(ctx))
+}
+
+// renderFields renders the run.Schema fields, the keywords that are not set are omitted
+func (c *SchemaCheck) renderFields(ctx *common.RenderContext) jen.Dict {
+	res := jen.Dict{}
+	strings := func(items []string) jen.Code {
+		return jen.Index().String().Values(lo.Map(items, func(item string, _ int) jen.Code { return jen.Lit(item) })...)
+	}
+	schemas := func(items []*SchemaCheck) jen.Code {
+		return jen.Index().Op("*").Qual(ctx.RuntimeModule(""), "Schema").Values(lo.Map(items, func(item *SchemaCheck, _ int) jen.Code {
+			return item.renderElement(ctx)
+		})...)
+	}
+	intPtr := func(n int) jen.Code {
+		return jen.Qual(ctx.RuntimeModule(""), "ToPtr").Call(jen.Lit(n))
+	}
+	floatPtr := func(n json.Number) jen.Code {
+		return jen.Qual(ctx.RuntimeModule(""), "ToPtr").Types(jen.Float64()).Call(jen.Op(n.String()))
+	}
+
+	if len(c.Type) > 0 {
+		res[jen.Id("Type")] = strings(c.Type)
+	}
+	if len(c.Enum) > 0 {
+		res[jen.Id("Enum")] = jen.Index().Any().Values(lo.Map(c.Enum, func(item any, _ int) jen.Code { return renderAnyValue(item) })...)
+	}
+	if len(c.Required) > 0 {
+		res[jen.Id("Required")] = strings(c.Required)
+	}
+	if c.Properties.Len() > 0 {
+		res[jen.Id("Properties")] = jen.Map(jen.String()).Op("*").Qual(ctx.RuntimeModule(""), "Schema").Values(jen.DictFunc(func(d jen.Dict) {
+			for _, entry := range c.Properties.Entries() {
+				d[jen.Lit(entry.Key)] = entry.Value.renderElement(ctx)
+			}
+		}))
+	}
+	if c.Items != nil {
+		res[jen.Id("Items")] = c.Items.render(ctx)
+	}
+
+	cs := c.Constraints
+	for _, f := range []lo.Tuple2[string, *int]{
+		lo.T2("MinLength", cs.MinLength), lo.T2("MaxLength", cs.MaxLength),
+		lo.T2("MinItems", cs.MinItems), lo.T2("MaxItems", cs.MaxItems),
+		lo.T2("MinProperties", cs.MinProperties), lo.T2("MaxProperties", cs.MaxProperties),
+	} {
+		if f.B != nil {
+			res[jen.Id(f.A)] = intPtr(*f.B)
+		}
+	}
+	for _, f := range []lo.Tuple2[string, *json.Number]{
+		lo.T2("Minimum", cs.Minimum), lo.T2("Maximum", cs.Maximum),
+		lo.T2("ExclusiveMinimum", cs.ExclusiveMinimum), lo.T2("ExclusiveMaximum", cs.ExclusiveMaximum),
+		lo.T2("MultipleOf", cs.MultipleOf),
+	} {
+		if f.B != nil {
+			res[jen.Id(f.A)] = floatPtr(*f.B)
+		}
+	}
+	if cs.Pattern != "" {
+		res[jen.Id("Pattern")] = jen.Lit(cs.Pattern)
+	}
+	if cs.UniqueItems {
+		res[jen.Id("UniqueItems")] = jen.True()
+	}
+
+	for _, f := range []lo.Tuple2[string, []*SchemaCheck]{lo.T2("AllOf", c.AllOf), lo.T2("AnyOf", c.AnyOf), lo.T2("OneOf", c.OneOf)} {
+		if len(f.B) > 0 {
+			res[jen.Id(f.A)] = schemas(f.B)
+		}
+	}
+	for _, f := range []lo.Tuple2[string, *SchemaCheck]{lo.T2("Not", c.Not), lo.T2("If", c.If), lo.T2("Then", c.Then), lo.T2("Else", c.Else)} {
+		if f.B != nil {
+			res[jen.Id(f.A)] = f.B.render(ctx)
+		}
+	}
+	return res
+}
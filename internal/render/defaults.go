@@ -0,0 +1,497 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/samber/lo"
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+type defaultedType interface {
+	defaultValue() any
+}
+
+// renderDefaultValue renders the value of type with schema defaults applied, or nil if type has no defaults. The
+// defaults that don't match the type are skipped with a warning.
+func renderDefaultValue(ctx *common.RenderContext, typ common.GolangType, value any) jen.Code {
+	r := defaultsRenderer{ctx: ctx, visiting: make(map[common.GolangType]bool)}
+	res, err := r.render(typ, value)
+	for _, e := range append(r.errs, err) {
+		if e != nil {
+			warnInvalidDefault(ctx, typ, e)
+		}
+	}
+	if err != nil {
+		return nil
+	}
+	return res
+}
+
+// renderConstructor renders the New<Name> function, that returns the struct with schema defaults set. Renders
+// nothing if struct has no defaults.
+func renderConstructor(ctx *common.RenderContext, s *GoStruct) []*jen.Statement {
+	r := defaultsRenderer{ctx: ctx, visiting: make(map[common.GolangType]bool)}
+	value, err := r.renderStruct(s, s.Default)
+	for _, e := range append(r.errs, err) {
+		if e != nil {
+			warnInvalidDefault(ctx, s, e)
+		}
+	}
+	if value == nil || err != nil {
+		return nil
+	}
+	return []*jen.Statement{
+		jen.Comment(s.NewFuncName() + " returns " + s.Name + " with default values from schema"),
+		jen.Func().Id(s.NewFuncName()).Params().Id(s.Name).Block(jen.Return(value)),
+	}
+}
+
+func warnInvalidDefault(ctx *common.RenderContext, typ common.GolangType, err error) {
+	msg := "Default value does not match the type, ignore it"
+	ctx.Logger.Warn(msg, "type", typ.TypeName(), "err", err)
+	diagnostics.Add(diagnostics.SeverityWarning, diagnostics.CodeInvalidValue, nil, "", diagnostics.FormatMessage(msg, "type", typ.TypeName(), "err", err))
+}
+
+// defaultsRenderer renders the Go values of schema default values
+type defaultsRenderer struct {
+	ctx      *common.RenderContext
+	visiting map[common.GolangType]bool // Structs being rendered, to stop on recursive types
+	errs     []error                    // Invalid defaults of struct fields, such fields are skipped
+}
+
+// render renders the value of type. If value is nil, the default of the type is used, if any. Returns nil if
+// there is no value to render.
+func (r *defaultsRenderer) render(typ common.GolangType, value any) (jen.Code, error) {
+	switch v := typ.(type) {
+	case *GolangTypePromise:
+		if !v.Assigned() {
+			return nil, nil
+		}
+		return r.render(v.Target(), value)
+	case *GoPointer:
+		if !v.isPointer() {
+			return r.render(v.Type, value)
+		}
+		// Optional value is set only if the default is given explicitly, the defaults of struct fields don't count
+		if value == nil {
+			value = typeDefaultValue(v.Type)
+		}
+		if value == nil {
+			return nil, nil
+		}
+		res, err := r.render(v.Type, value)
+		if res == nil || err != nil {
+			return nil, err
+		}
+		return jen.Qual(r.ctx.RuntimeModule(""), "ToPtr").Types(utils.ToCode(v.Type.RenderUsage(r.ctx))...).Call(res), nil
+	case *GoOptional:
+		if !v.isOptional() {
+			return r.render(v.Type, value)
+		}
+		if value == nil {
+			value = typeDefaultValue(v.Type)
+		}
+		if value == nil {
+			return nil, nil
+		}
+		res, err := r.render(v.Type, value)
+		if res == nil || err != nil {
+			return nil, err
+		}
+		return jen.Qual(r.ctx.RuntimeModule(""), "NewOptional").Types(utils.ToCode(v.Type.RenderUsage(r.ctx))...).Call(res), nil
+	}
+
+	if s, ok := typ.(*GoStruct); ok && value == nil && s.DirectRender && s.Constraints != nil {
+		// Schema struct with defaults has a constructor. Invalid defaults are reported when the constructor is rendered
+		errCount := len(r.errs)
+		res, err := r.renderStruct(s, s.Default)
+		r.errs = r.errs[:errCount]
+		if res == nil || err != nil {
+			return nil, nil
+		}
+		return jen.Add(r.qual(s.Import, s.NewFuncName())).Call(), nil
+	}
+
+	if value == nil {
+		value = typeDefaultValue(typ)
+	}
+	switch v := typ.(type) {
+	case *UnionStruct:
+		if value != nil {
+			return nil, errors.New("default value of union is not supported")
+		}
+	case *GoStruct:
+		return r.renderStruct(v, value)
+	case *GoArray:
+		if value == nil {
+			return nil, nil
+		}
+		items, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %T", value)
+		}
+		if v.Size > 0 && len(items) > v.Size {
+			return nil, fmt.Errorf("expected at most %d items, got %d", v.Size, len(items))
+		}
+		var res []jen.Code
+		for i, item := range items {
+			c, err := r.renderItem(v.ItemsType, item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			res = append(res, c)
+		}
+		return jen.Add(utils.ToCode(v.RenderUsage(r.ctx))...).Values(res...), nil
+	case *GoMap:
+		if value == nil {
+			return nil, nil
+		}
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected object, got %T", value)
+		}
+		dict := jen.Dict{}
+		for k, item := range obj {
+			c, err := r.renderItem(v.ValueType, item)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k, err)
+			}
+			dict[jen.Lit(k)] = c
+		}
+		return jen.Add(utils.ToCode(v.RenderUsage(r.ctx))...).Values(dict), nil
+	case *GoTypeAlias:
+		if value == nil {
+			return nil, nil
+		}
+		if v.Transparent {
+			return nil, fmt.Errorf("default value of %s type is not supported", v.AliasedType.TypeName())
+		}
+		return r.render(v.AliasedType, value)
+	case *GoEnum:
+		if value == nil {
+			return nil, nil
+		}
+		item, ok := lo.Find(v.Values, func(item GoEnumValue) bool { return fmt.Sprint(item.Value) == fmt.Sprint(value) })
+		if !ok {
+			return nil, fmt.Errorf("value %v is not in enum", value)
+		}
+		return r.qual(v.Import, item.Name), nil
+	case *GoSimple:
+		if value == nil {
+			return nil, nil
+		}
+		return r.renderSimple(v, value)
+	}
+	return nil, nil
+}
+
+// renderItem renders the array item or map value, which is always present in the literal
+func (r *defaultsRenderer) renderItem(typ common.GolangType, value any) (jen.Code, error) {
+	if value == nil {
+		if !isTypeNilable(typ) {
+			return nil, errors.New("null value is not allowed")
+		}
+		return jen.Nil(), nil
+	}
+	res, err := r.render(typ, value)
+	if res == nil && err == nil {
+		err = fmt.Errorf("default value %v is not supported", value)
+	}
+	return res, err
+}
+
+// renderStruct renders the struct literal with fields set from value object and field defaults. Tuple struct
+// fields are set from value array items at their positions. Returns nil if no field has a value.
+func (r *defaultsRenderer) renderStruct(s *GoStruct, value any) (jen.Code, error) {
+	fields, err := r.renderFields(s, value)
+	if err != nil {
+		return nil, err
+	}
+	dict := jen.Dict{}
+	for i, c := range fields {
+		if c != nil {
+			dict[jen.Id(s.Fields[i].Name)] = c
+		}
+	}
+	if len(dict) == 0 {
+		return nil, nil
+	}
+	return jen.Add(utils.ToCode(s.RenderUsage(r.ctx))...).Values(dict), nil
+}
+
+// renderFields renders the values of struct fields set from value object and field defaults, nil for fields without
+// a value
+func (r *defaultsRenderer) renderFields(s *GoStruct, value any) ([]jen.Code, error) {
+	fieldValues, err := structFieldValues(s, value)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]jen.Code, len(s.Fields))
+	if r.visiting[s] {
+		return res, nil
+	}
+	r.visiting[s] = true
+	defer delete(r.visiting, s)
+
+	for i, f := range s.Fields {
+		if f.Name == "" {
+			continue // Embedded type
+		}
+		c, err := r.render(f.Type, fieldValues[i])
+		if err != nil {
+			if s.Tuple != nil {
+				r.errs = append(r.errs, fmt.Errorf("item %d: %w", i, err))
+			} else {
+				r.errs = append(r.errs, fmt.Errorf("property %q: %w", f.MarshalName, err))
+			}
+			continue
+		}
+		res[i] = c
+	}
+	return res, nil
+}
+
+// renderSetDefaults renders the setDefaults method, that sets the default values to the struct fields whose
+// properties are absent in the data. It's called on unmarshal, after the data is decoded.
+func renderSetDefaults(ctx *common.RenderContext, s *GoStruct) []*jen.Statement {
+	r := defaultsRenderer{ctx: ctx, visiting: make(map[common.GolangType]bool)}
+	fields, _ := r.renderFields(s, nil) // Invalid defaults are reported when the constructor is rendered
+	rn := s.ReceiverName()
+	var body []jen.Code
+	for i, c := range fields {
+		// Map fields for patternProperties and additionalProperties have no property name
+		if c != nil && s.Fields[i].MarshalName != "" {
+			body = append(body, jen.If(jen.Op("!").Id("present").Call(jen.Lit(s.Fields[i].MarshalName))).Block(
+				jen.Id(rn).Dot(s.Fields[i].Name).Op("=").Add(c),
+			))
+		}
+	}
+	return []*jen.Statement{
+		jen.Comment("setDefaults sets the schema default values to the fields whose properties are absent in the data"),
+		jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("setDefaults").
+			Params(jen.Id("present").Func().Params(jen.Id("name").String()).Bool()).
+			Block(body...),
+	}
+}
+
+// hasFieldDefaults returns true if any struct field gets a value from schema defaults, so the defaults must be set
+// to the fields absent in the data on unmarshal. Tuple structs are not considered.
+func (s GoStruct) hasFieldDefaults() bool {
+	if s.Tuple != nil || s.Constraints == nil {
+		return false
+	}
+	visiting := map[*GoStruct]bool{&s: true}
+	return lo.SomeBy(s.Fields, func(item GoStructField) bool {
+		return item.Name != "" && item.MarshalName != "" && hasDefaultValue(item.Type, item.Default, visiting)
+	})
+}
+
+// hasDefaultValue returns true if the value of type has anything set by schema defaults, see defaultsRenderer.render.
+// If value is nil, the default of the type is used, if any.
+func hasDefaultValue(typ common.GolangType, value any, visiting map[*GoStruct]bool) bool {
+	typ = unwrapPromise(typ)
+	switch v := typ.(type) {
+	case *GoPointer:
+		if !v.isPointer() {
+			return hasDefaultValue(v.Type, value, visiting)
+		}
+		return value != nil || typeDefaultValue(v.Type) != nil
+	case *GoOptional:
+		if !v.isOptional() {
+			return hasDefaultValue(v.Type, value, visiting)
+		}
+		return value != nil || typeDefaultValue(v.Type) != nil
+	case *UnionStruct:
+		return false
+	case *GoStruct:
+		if value == nil {
+			value = v.Default
+		}
+		fieldValues, err := structFieldValues(v, value)
+		if err != nil || visiting[v] {
+			return false
+		}
+		visiting[v] = true
+		defer delete(visiting, v)
+		for i, f := range v.Fields {
+			if f.Name != "" && hasDefaultValue(f.Type, fieldValues[i], visiting) {
+				return true
+			}
+		}
+		return false
+	}
+	return value != nil || typeDefaultValue(typ) != nil
+}
+
+// NameDefaultedStructs makes the anonymous structs, that have the fields with schema defaults, to be rendered
+// separately. Such structs have the unmarshal methods, that set the defaults to the fields absent in the data, and
+// the anonymous struct can't have methods. Returns the structs that have been made named.
+func NameDefaultedStructs(roots []common.GolangType) []common.GolangType {
+	var res []common.GolangType
+	done := make(map[common.GolangType]bool)
+
+	var visit func(typ common.GolangType)
+	visit = func(typ common.GolangType) {
+		typ = unwrapPromise(typ)
+		if done[typ] {
+			return
+		}
+		done[typ] = true
+		if s, ok := typ.(*GoStruct); ok && !s.DirectRender && s.hasFieldDefaults() {
+			s.DirectRender = true
+			res = append(res, s)
+		}
+		for _, e := range typeEdges(typ) {
+			visit(e.target)
+		}
+	}
+	for _, r := range roots {
+		visit(r)
+	}
+	return res
+}
+
+// structFieldValues returns the values of struct fields from object or tuple array value, the field defaults are
+// used for fields absent in value
+func structFieldValues(s *GoStruct, value any) ([]any, error) {
+	res := make([]any, len(s.Fields))
+	if s.Tuple != nil {
+		items, ok := value.([]any)
+		if value != nil && !ok {
+			return nil, fmt.Errorf("expected array, got %T", value)
+		}
+		fields := s.itemFields()
+		for i, f := range fields {
+			res[i] = f.Default
+			if i < len(items) {
+				res[i] = items[i]
+			}
+		}
+		if s.Tuple.Additional && len(items) > len(fields) {
+			res[len(fields)] = items[len(fields):]
+		}
+		return res, nil
+	}
+
+	obj, ok := value.(map[string]any)
+	if value != nil && !ok {
+		return nil, fmt.Errorf("expected object, got %T", value)
+	}
+	known := make(map[string]bool)
+	for i, f := range s.Fields {
+		fieldValue, ok := obj[f.MarshalName]
+		if !ok || f.MarshalName == "" {
+			fieldValue = f.Default
+		}
+		res[i] = fieldValue
+		known[f.MarshalName] = f.MarshalName != ""
+	}
+
+	// The rest of properties go to patternProperties and additionalProperties map fields, like on unmarshal
+	for _, name := range lo.Keys(obj) {
+		if known[name] {
+			continue
+		}
+		idx := -1
+		for i, f := range s.Fields {
+			if f.KeyPattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(f.KeyPattern)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", name, err)
+			}
+			if re.MatchString(name) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			idx = lo.IndexOf(lo.Map(s.Fields, func(item GoStructField, _ int) bool { return item.Additional }), true)
+		}
+		if idx < 0 {
+			if s.NoAdditionalProperties {
+				return nil, fmt.Errorf("unknown property %q", name)
+			}
+			continue
+		}
+		props, _ := res[idx].(map[string]any)
+		if props == nil {
+			props = make(map[string]any)
+			res[idx] = props
+		}
+		props[name] = obj[name]
+	}
+	return res, nil
+}
+
+func (r *defaultsRenderer) renderSimple(s *GoSimple, value any) (jen.Code, error) {
+	if s.IsIface {
+		return renderAnyValue(value), nil
+	}
+	if s.Import != "" {
+		return nil, fmt.Errorf("default value of %s type is not supported", s.Name)
+	}
+	var ok bool
+	switch s.Name {
+	case "string":
+		_, ok = value.(string)
+	case "bool":
+		_, ok = value.(bool)
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		if n, isNumber := value.(json.Number); isNumber {
+			_, err := n.Int64()
+			ok = err == nil
+		}
+	case "float32", "float64":
+		_, ok = value.(json.Number)
+	}
+	if !ok {
+		return nil, fmt.Errorf("value %v is not suitable for %s type", value, s.Name)
+	}
+	if n, isNumber := value.(json.Number); isNumber {
+		return jen.Op(n.String()), nil // Untyped constant
+	}
+	return jen.Lit(value), nil
+}
+
+func (r *defaultsRenderer) qual(pkg, name string) jen.Code {
+	if pkg != "" && pkg != r.ctx.CurrentPackage {
+		return jen.Qual(r.ctx.GeneratedModule(pkg), name)
+	}
+	return jen.Id(name)
+}
+
+// renderAnyValue renders the value to be put to `any`, as JSON decoder would produce it
+func renderAnyValue(value any) jen.Code {
+	switch v := value.(type) {
+	case nil:
+		return jen.Nil()
+	case json.Number:
+		f, _ := v.Float64()
+		return jen.Lit(f)
+	case []any:
+		return jen.Index().Any().Values(lo.Map(v, func(item any, _ int) jen.Code { return renderAnyValue(item) })...)
+	case map[string]any:
+		return jen.Map(jen.String()).Any().Values(jen.DictFunc(func(d jen.Dict) {
+			for k, item := range v {
+				d[jen.Lit(k)] = renderAnyValue(item)
+			}
+		}))
+	}
+	return jen.Lit(value)
+}
+
+func typeDefaultValue(typ common.GolangType) any {
+	typ = unwrapPromise(typ)
+	if v, ok := typ.(defaultedType); ok {
+		return v.defaultValue()
+	}
+	return nil
+}
@@ -1,34 +1,137 @@
 package render
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/samber/lo"
 
 	j "github.com/dave/jennifer/jen"
 	"github.com/xcnt/go-asyncapi/internal/common"
 	"github.com/xcnt/go-asyncapi/internal/utils"
+	"github.com/xcnt/go-asyncapi/run"
+)
+
+const (
+	encodingPackageName = "encoding"
+	avroFormat          = "avro"
+	octetStreamFormat   = "application/octet-stream"
 )
 
-const encodingPackageName = "encoding"
+// encodingEncoders are the expressions that create the encoder of format writing to `w`
+var encodingEncoders = map[string]func(ctx *common.RenderContext) j.Code{
+	"application/json": func(_ *common.RenderContext) j.Code {
+		return j.Qual("encoding/json", "NewEncoder").Call(j.Id("w"))
+	},
+	"application/yaml": func(_ *common.RenderContext) j.Code {
+		return j.Qual("gopkg.in/yaml.v3", "NewEncoder").Call(j.Id("w"))
+	},
+	"application/x-msgpack": func(_ *common.RenderContext) j.Code {
+		return j.Qual("github.com/vmihailenco/msgpack/v5", "NewEncoder").Call(j.Id("w"))
+	},
+	"application/cbor": func(_ *common.RenderContext) j.Code {
+		return j.Qual("github.com/fxamacker/cbor/v2", "NewEncoder").Call(j.Id("w"))
+	},
+	"application/xml": func(_ *common.RenderContext) j.Code {
+		return j.Qual("encoding/xml", "NewEncoder").Call(j.Id("w"))
+	},
+	"text/plain": func(ctx *common.RenderContext) j.Code {
+		return j.Qual(ctx.RuntimeModule(""), "NewTextEncoder").Call(j.Id("w"))
+	},
+	octetStreamFormat: func(ctx *common.RenderContext) j.Code {
+		return j.Qual(ctx.RuntimeModule(""), "NewRawEncoder").Call(j.Id("w"))
+	},
+	avroFormat:     func(_ *common.RenderContext) j.Code { return j.Id("avroEncoder").Values(j.Id("w")) },
+	protobufFormat: func(_ *common.RenderContext) j.Code { return j.Id("protobufEncoder").Values(j.Id("w")) },
+}
+
+// encodingDecoders are the expressions that create the decoder of format reading from `r`
+var encodingDecoders = map[string]func(ctx *common.RenderContext) j.Code{
+	"application/json": func(_ *common.RenderContext) j.Code {
+		return j.Qual("encoding/json", "NewDecoder").Call(j.Id("r"))
+	},
+	"application/yaml": func(_ *common.RenderContext) j.Code {
+		return j.Qual("gopkg.in/yaml.v3", "NewDecoder").Call(j.Id("r"))
+	},
+	"application/x-msgpack": func(_ *common.RenderContext) j.Code {
+		return j.Qual("github.com/vmihailenco/msgpack/v5", "NewDecoder").Call(j.Id("r"))
+	},
+	"application/cbor": func(_ *common.RenderContext) j.Code {
+		return j.Qual("github.com/fxamacker/cbor/v2", "NewDecoder").Call(j.Id("r"))
+	},
+	"application/xml": func(_ *common.RenderContext) j.Code {
+		return j.Qual("encoding/xml", "NewDecoder").Call(j.Id("r"))
+	},
+	"text/plain": func(ctx *common.RenderContext) j.Code {
+		return j.Qual(ctx.RuntimeModule(""), "NewTextDecoder").Call(j.Id("r"))
+	},
+	octetStreamFormat: func(ctx *common.RenderContext) j.Code {
+		return j.Qual(ctx.RuntimeModule(""), "NewRawDecoder").Call(j.Id("r"))
+	},
+	avroFormat:     func(_ *common.RenderContext) j.Code { return j.Id("avroDecoder").Values(j.Id("r")) },
+	protobufFormat: func(_ *common.RenderContext) j.Code { return j.Id("protobufDecoder").Values(j.Id("r")) },
+}
+
+// optionalCodecs are the run.Optional codec variables and the packages providing Marshal and Unmarshal functions, by
+// format the runtime module has no dependency for
+var optionalCodecs = map[string]lo.Tuple2[string, string]{
+	"application/x-msgpack": lo.T2("MsgpackCodec", "github.com/vmihailenco/msgpack/v5"),
+	"application/cbor":      lo.T2("CBORCodec", "github.com/fxamacker/cbor/v2"),
+}
+
+// encodingCompressors are the function bodies that create the compressor writing to `w`, by content encoding
+var encodingCompressors = map[string]string{
+	"gzip":    `return %Q(compress/gzip,NewWriter)(w), nil`,
+	"deflate": `return %Q(compress/zlib,NewWriter)(w), nil`,
+	"zstd":    `return %Q(github.com/klauspost/compress/zstd,NewWriter)(w)`,
+	"snappy":  `return %Q(github.com/klauspost/compress/snappy,NewBufferedWriter)(w), nil`,
+	"lz4":     `return %Q(github.com/pierrec/lz4/v4,NewWriter)(w), nil`,
+}
 
-var encodingEncoders = map[string]j.Code{
-	"application/json":      j.Op(`func(w io.Writer) Encoder`).Block(j.Return(j.Qual("encoding/json", "NewEncoder").Call(j.Id("w")))),
-	"application/yaml":      j.Op(`func(w io.Writer) Encoder`).Block(j.Return(j.Qual("gopkg.in/yaml.v3", "NewEncoder").Call(j.Id("w")))),
-	"application/x-msgpack": j.Op(`func(w io.Writer) Encoder`).Block(j.Return(j.Qual("github.com/vmihailenco/msgpack/v5", "NewEncoder").Call(j.Id("w")))),
-	"text/plain":            j.Op(`func(w io.Writer) Encoder`).Block(j.Return(j.Qual("github.com/xcnt/client-go/pkg/text", "NewEncoder").Call(j.Id("w")))),
-	// TODO: add other encoders: protobuf, avro, etc.
+// encodingDecompressors are the function bodies that create the decompressor reading from `r`, by content encoding
+var encodingDecompressors = map[string]string{
+	"gzip":    `return %Q(compress/gzip,NewReader)(r)`,
+	"deflate": `return %Q(compress/zlib,NewReader)(r)`,
+	"zstd": `
+		d, err := %Q(github.com/klauspost/compress/zstd,NewReader)(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil`,
+	"snappy": `return %Q(io,NopCloser)(%Q(github.com/klauspost/compress/snappy,NewReader)(r)), nil`,
+	"lz4":    `return %Q(io,NopCloser)(%Q(github.com/pierrec/lz4/v4,NewReader)(r)), nil`,
 }
 
-var encodingDecoders = map[string]j.Code{
-	"application/json":      j.Op(`func(r io.Reader) Decoder`).Block(j.Return(j.Qual("encoding/json", "NewDecoder").Call(j.Id("r")))),
-	"application/yaml":      j.Op(`func(r io.Reader) Decoder`).Block(j.Return(j.Qual("gopkg.in/yaml.v3", "NewDecoder").Call(j.Id("r")))),
-	"application/x-msgpack": j.Op(`func(r io.Reader) Decoder`).Block(j.Return(j.Qual("github.com/vmihailenco/msgpack/v5", "NewDecoder").Call(j.Id("r")))),
-	"text/plain":            j.Op(`func(r io.Reader) Decoder`).Block(j.Return(j.Qual("github.com/xcnt/client-go/pkg/text", "NewDecoder").Call(j.Id("r")))),
-	// TODO: add other decoders: protobuf, avro, etc.
+// contentTypeFormats are the formats of content types and suffix patterns, see run.ContentTypeKeys
+var contentTypeFormats = map[string]string{
+	"application/vnd.apache.avro+binary": avroFormat,
+	"application/vnd.apache.avro":        avroFormat,
+	"application/avro":                   avroFormat,
+	"avro/binary":                        avroFormat,
+	"*+avro":                             avroFormat,
+	"application/x-protobuf":             protobufFormat,
+	"application/protobuf":               protobufFormat,
+	"application/vnd.google.protobuf":    protobufFormat,
+	"*+protobuf":                         protobufFormat,
+	"application/json":                   "application/json",
+	"*+json":                             "application/json",
+	"application/yaml":                   "application/yaml",
+	"*+yaml":                             "application/yaml",
+	"application/x-msgpack":              "application/x-msgpack",
+	"*+msgpack":                          "application/x-msgpack",
+	"application/cbor":                   "application/cbor",
+	"*+cbor":                             "application/cbor",
+	"application/xml":                    "application/xml",
+	"text/xml":                           "application/xml",
+	"*+xml":                              "application/xml",
+	"text/plain":                         "text/plain",
+	"application/octet-stream":           octetStreamFormat,
 }
 
 type EncodingEncode struct {
 	AllMessages        *ListPromise[*Message]
 	DefaultContentType string
+	Codecs             map[string]common.ContentTypeCodec // Encoders and decoders set by user
 }
 
 func (e EncodingEncode) DirectRendering() bool {
@@ -42,33 +145,59 @@ func (e EncodingEncode) RenderDefinition(ctx *common.RenderContext) []*j.Stateme
 	contentTypes := lo.Uniq(lo.FilterMap(e.AllMessages.Targets(), func(item *Message, _ int) (string, bool) {
 		return item.ContentType, item.ContentType != ""
 	}))
-	return []*j.Statement{
+	res := []*j.Statement{
 		j.Op(`
 			type Encoder interface {
 				Encode(v any) error
 			}`),
 
+		j.Comment("Encoders are the encoder constructors by content type or suffix pattern, e.g. \"*+json\""),
 		j.Add(utils.QualSprintf(`var Encoders = map[string]func(w %Q(io,Writer)) Encoder`)).Values(j.DictFunc(func(d j.Dict) {
 			for _, ct := range contentTypes {
-				format := getFormatByContentType(ct)
-				if format != "" {
-					if v, ok := encodingEncoders[format]; ok {
-						d[j.Lit(ct)] = v
-					}
-				} else {
-					d[j.Lit(ct)] = j.Add(utils.QualSprintf(`func(_ %Q(io,Writer)) Encoder { panic("No encoder is set for content type %s") }`, ct))
+				if v := encoderExpr(ctx, e.Codecs, ct); v != nil {
+					d[j.Lit(ct)] = j.Op(`func(w io.Writer) Encoder`).Block(j.Return(v))
 				}
 			}
 		})),
 
 		j.Add(utils.QualSprintf(`
+			// NewEncoder returns the encoder for content type. Content type parameters are ignored if there is no encoder
+			// for them, the suffix pattern is used if there is no encoder for the media type, e.g. "*+json" for
+			// "application/cloudevents+json".
 			func NewEncoder(contentType string, w %Q(io,Writer)) Encoder {
-				if v, ok := Encoders[contentType]; ok {
+				if v, ok := %Q(%s,LookupContentType)(Encoders, contentType); ok {
 					return v(w)
 				}
-				panic("Unknown content type " + contentType)
+				panic("No encoder is set for content type " + contentType + ", add it by Register function")
+			}`, ctx.RuntimeModule(""))),
+
+		j.Add(utils.QualSprintf(`
+			// Register sets the encoder and decoder constructors for content type or suffix pattern, e.g. "*+cbor",
+			// overriding the existing ones. Nil constructor is not set. Should be called on program start.
+			func Register(contentType string, encoder func(w %Q(io,Writer)) Encoder, decoder func(r %Q(io,Reader)) Decoder) {
+				if encoder != nil {
+					Encoders[contentType] = encoder
+				}
+				if decoder != nil {
+					Decoders[contentType] = decoder
+				}
 			}`)),
 	}
+	res = append(res, renderOptionalCodecs(ctx, e.Codecs, contentTypes)...)
+	contentEncodings := messageContentEncodings(e.AllMessages.Targets())
+	for _, ce := range contentEncodings {
+		if _, ok := encodingCompressors[ce]; !ok {
+			ctx.Logger.Warn("No compressor is set for content encoding, add it by encoding.RegisterCompression function", "contentEncoding", ce)
+		}
+	}
+	res = append(res, renderCompressors(compressionEncodings(contentEncodings))...)
+	if hasAvroMessages(e.AllMessages.Targets()) {
+		res = append(res, renderAvroEncoder()...)
+	}
+	if hasProtobufMessages(e.AllMessages.Targets()) {
+		res = append(res, renderProtobufEncoder())
+	}
+	return res
 }
 
 func (e EncodingEncode) RenderUsage(_ *common.RenderContext) []*j.Statement {
@@ -86,6 +215,7 @@ func (e EncodingEncode) String() string {
 type EncodingDecode struct {
 	AllMessages        *ListPromise[*Message]
 	DefaultContentType string
+	Codecs             map[string]common.ContentTypeCodec // Encoders and decoders set by user
 }
 
 func (e EncodingDecode) DirectRendering() bool {
@@ -99,33 +229,38 @@ func (e EncodingDecode) RenderDefinition(ctx *common.RenderContext) []*j.Stateme
 	contentTypes := lo.Uniq(lo.FilterMap(e.AllMessages.Targets(), func(item *Message, _ int) (string, bool) {
 		return item.ContentType, item.ContentType != ""
 	}))
-	return []*j.Statement{
+	res := []*j.Statement{
 		j.Op(`
 			type Decoder interface {
 				Decode(v any) error
 			}`),
 
+		j.Comment("Decoders are the decoder constructors by content type or suffix pattern, e.g. \"*+json\""),
 		j.Add(utils.QualSprintf(`var Decoders = map[string]func(r %Q(io,Reader)) Decoder`)).Values(j.DictFunc(func(d j.Dict) {
 			for _, ct := range contentTypes {
-				format := getFormatByContentType(ct)
-				if format != "" {
-					if v, ok := encodingDecoders[format]; ok {
-						d[j.Lit(ct)] = v
-					}
-				} else {
-					d[j.Lit(ct)] = j.Add(utils.QualSprintf(`func(_ %Q(io,Reader)) Decoder { panic("No decoder is set for content type %s") }`, ct))
+				if v := decoderExpr(ctx, e.Codecs, ct); v != nil {
+					d[j.Lit(ct)] = j.Op(`func(r io.Reader) Decoder`).Block(j.Return(v))
 				}
 			}
 		})),
 
 		j.Add(utils.QualSprintf(`
+			// NewDecoder returns the decoder for content type, it's looked up the same way as in NewEncoder
 			func NewDecoder(contentType string, r %Q(io,Reader)) Decoder {
-				if v, ok := Decoders[contentType]; ok {
+				if v, ok := %Q(%s,LookupContentType)(Decoders, contentType); ok {
 					return v(r)
 				}
-				panic("Unknown content type " + contentType)
-			}`)),
+				panic("No decoder is set for content type " + contentType + ", add it by Register function")
+			}`, ctx.RuntimeModule(""))),
 	}
+	res = append(res, renderDecompressors(compressionEncodings(messageContentEncodings(e.AllMessages.Targets())))...)
+	if hasAvroMessages(e.AllMessages.Targets()) {
+		res = append(res, renderAvroDecoder()...)
+	}
+	if hasProtobufMessages(e.AllMessages.Targets()) {
+		res = append(res, renderProtobufDecoder())
+	}
+	return res
 }
 
 func (e EncodingDecode) RenderUsage(_ *common.RenderContext) []*j.Statement {
@@ -140,17 +275,308 @@ func (e EncodingDecode) String() string {
 	return "EncodingDecode"
 }
 
+// getFormatByContentType returns the format of content type the tool has encoder and decoder for, or empty string
+// if content type is unknown. Content type parameters and structured syntax suffix are considered, e.g.
+// "application/cloudevents+json; charset=utf-8" is JSON.
 func getFormatByContentType(contentType string) string {
-	// TODO: add other formats: protobuf, avro, etc.
-	switch {
-	case contentType == "application/json":
-		return "application/json"
-	case contentType == "application/yaml":
-		return "application/yaml"
-	case contentType == "application/x-msgpack":
-		return "application/x-msgpack"
-	case contentType == "text/plain":
-		return "text/plain"
+	for _, key := range run.ContentTypeKeys(contentType) {
+		if format, ok := contentTypeFormats[key]; ok {
+			return format
+		}
 	}
 	return ""
 }
+
+// IsRawContentType returns true if the content type is a byte stream, that is passed through as is, so message
+// payload is []byte
+func IsRawContentType(contentType string) bool {
+	return getFormatByContentType(contentType) == octetStreamFormat
+}
+
+// contentTypeCodec returns the encoder and decoder set by user for the content type
+func contentTypeCodec(codecs map[string]common.ContentTypeCodec, contentType string) common.ContentTypeCodec {
+	for _, key := range run.ContentTypeKeys(contentType) {
+		if v, ok := codecs[key]; ok {
+			return v
+		}
+	}
+	return common.ContentTypeCodec{}
+}
+
+// HasContentTypeCodec returns true if there is an encoder or decoder for the content type, either set by user or
+// the default one
+func HasContentTypeCodec(codecs map[string]common.ContentTypeCodec, contentType string) bool {
+	return contentTypeCodec(codecs, contentType) != common.ContentTypeCodec{} || getFormatByContentType(contentType) != ""
+}
+
+// renderOptionalCodecs renders the init function, that sets the run.Optional codecs for the formats of default
+// encoders, such as msgpack and CBOR
+func renderOptionalCodecs(ctx *common.RenderContext, codecs map[string]common.ContentTypeCodec, contentTypes []string) []*j.Statement {
+	formats := lo.Uniq(lo.FilterMap(contentTypes, func(item string, _ int) (string, bool) {
+		format := getFormatByContentType(item)
+		_, ok := optionalCodecs[format]
+		return format, ok && contentTypeCodec(codecs, item) == common.ContentTypeCodec{}
+	}))
+	if len(formats) == 0 {
+		return nil
+	}
+	sort.Strings(formats)
+	return []*j.Statement{
+		j.Func().Id("init").Params().BlockFunc(func(bg *j.Group) {
+			for _, format := range formats {
+				codec := optionalCodecs[format]
+				bg.Qual(ctx.RuntimeModule(""), codec.A).Op("=").Qual(ctx.RuntimeModule(""), "Codec").Values(j.Dict{
+					j.Id("Marshal"):   j.Qual(codec.B, "Marshal"),
+					j.Id("Unmarshal"): j.Qual(codec.B, "Unmarshal"),
+				})
+			}
+		}),
+	}
+}
+
+// encoderExpr returns the expression that creates the encoder for content type writing to `w`, or nil if there is no
+// encoder. User encoder goes first.
+func encoderExpr(ctx *common.RenderContext, codecs map[string]common.ContentTypeCodec, contentType string) j.Code {
+	if fn := contentTypeCodec(codecs, contentType).Encoder; fn != "" {
+		return qualFunc(fn).Call(j.Id("w"))
+	}
+	if v, ok := encodingEncoders[getFormatByContentType(contentType)]; ok {
+		return v(ctx)
+	}
+	return nil
+}
+
+// decoderExpr returns the expression that creates the decoder for content type reading from `r`, or nil if there is
+// no decoder. User decoder goes first.
+func decoderExpr(ctx *common.RenderContext, codecs map[string]common.ContentTypeCodec, contentType string) j.Code {
+	if fn := contentTypeCodec(codecs, contentType).Decoder; fn != "" {
+		return qualFunc(fn).Call(j.Id("r"))
+	}
+	if v, ok := encodingDecoders[getFormatByContentType(contentType)]; ok {
+		return v(ctx)
+	}
+	return nil
+}
+
+// qualFunc renders the function by its full name, e.g. "github.com/fxamacker/cbor/v2.NewEncoder"
+func qualFunc(fn string) *j.Statement {
+	slash := strings.LastIndex(fn, "/")
+	dot := slash + 1 + strings.Index(fn[slash+1:], ".")
+	return j.Qual(fn[:dot], fn[dot+1:])
+}
+
+// formatStructTag is the struct tag the format encoder gets the property name from
+type formatStructTag struct {
+	name      string
+	omitEmpty bool // Encoder supports `omitempty` option, that skips the empty values
+}
+
+// formatStructTags are the struct tags of formats, the formats not listed here don't use struct tags
+var formatStructTags = map[string]formatStructTag{
+	"application/json":      {name: "json", omitEmpty: true},
+	"application/yaml":      {name: "yaml", omitEmpty: true},
+	"application/x-msgpack": {name: "msgpack", omitEmpty: true},
+	"application/cbor":      {name: "cbor", omitEmpty: true},
+	"application/xml":       {name: "xml", omitEmpty: true},
+	avroFormat:              {name: "avro"},
+}
+
+// hasAvroMessages returns true if any message is encoded in Avro or has a payload described by the Avro schema
+func hasAvroMessages(messages []*Message) bool {
+	return lo.SomeBy(messages, func(item *Message) bool {
+		return getFormatByContentType(item.ContentType) == avroFormat ||
+			strings.HasPrefix(item.SchemaFormat, "application/vnd.apache.avro")
+	})
+}
+
+// hasProtobufMessages returns true if any message is encoded in protobuf
+func hasProtobufMessages(messages []*Message) bool {
+	return lo.SomeBy(messages, func(item *Message) bool {
+		return getFormatByContentType(item.ContentType) == protobufFormat
+	})
+}
+
+// messageContentEncodings returns the content encodings the messages are compressed with in all protocols
+func messageContentEncodings(messages []*Message) []string {
+	var res []string
+	for _, m := range messages {
+		for _, srv := range m.AllServersPromises {
+			if ce := m.ProtoContentEncoding(srv.Target().Protocol); !srv.Target().Dummy && ce != "" {
+				res = append(res, ce)
+			}
+		}
+	}
+	res = lo.Uniq(res)
+	sort.Strings(res)
+	return res
+}
+
+// compressionEncodings returns the content encodings to render the compressors for. Messages may be received with any
+// Content-Encoding header, so the ones from the standard library are always available.
+func compressionEncodings(messageEncodings []string) []string {
+	res := lo.Uniq(append([]string{"deflate", "gzip"}, messageEncodings...))
+	sort.Strings(res)
+	return res
+}
+
+func renderCompressors(contentEncodings []string) []*j.Statement {
+	return []*j.Statement{
+		j.Comment("Compressors are the compressor constructors by content encoding in lower case"),
+		j.Add(utils.QualSprintf(`var Compressors = map[string]func(w %Q(io,Writer)) (%Q(io,WriteCloser), error)`)).Values(j.DictFunc(func(d j.Dict) {
+			for _, ce := range contentEncodings {
+				if body, ok := encodingCompressors[ce]; ok {
+					d[j.Lit(ce)] = j.Op(`func(w io.Writer) (io.WriteCloser, error)`).Block(utils.QualSprintf(body))
+				}
+			}
+		})),
+		j.Add(utils.QualSprintf(`
+			// NewCompressor returns the writer that compresses the data according to content encoding and writes it to w.
+			// The data is written as is if content encoding is empty or "identity". Close flushes the compressed data.
+			func NewCompressor(contentEncoding string, w %Q(io,Writer)) (%Q(io,WriteCloser), error) {
+				contentEncoding = %Q(strings,ToLower)(%Q(strings,TrimSpace)(contentEncoding))
+				if contentEncoding == "" || contentEncoding == "identity" {
+					return nopWriteCloser{w}, nil
+				}
+				if v, ok := Compressors[contentEncoding]; ok {
+					return v(w)
+				}
+				return nil, %Q(fmt,Errorf)("no compressor is set for content encoding %%q, add it by RegisterCompression function", contentEncoding)
+			}
+
+			// RegisterCompression sets the compressor and decompressor constructors for content encoding in lower case,
+			// overriding the existing ones. Nil constructor is not set. Should be called on program start.
+			func RegisterCompression(contentEncoding string, compressor func(w %Q(io,Writer)) (%Q(io,WriteCloser), error), decompressor func(r %Q(io,Reader)) (%Q(io,ReadCloser), error)) {
+				if compressor != nil {
+					Compressors[contentEncoding] = compressor
+				}
+				if decompressor != nil {
+					Decompressors[contentEncoding] = decompressor
+				}
+			}
+
+			type nopWriteCloser struct {
+				%Q(io,Writer)
+			}
+
+			func (nopWriteCloser) Close() error {
+				return nil
+			}`)),
+	}
+}
+
+func renderDecompressors(contentEncodings []string) []*j.Statement {
+	return []*j.Statement{
+		j.Comment("Decompressors are the decompressor constructors by content encoding in lower case"),
+		j.Add(utils.QualSprintf(`var Decompressors = map[string]func(r %Q(io,Reader)) (%Q(io,ReadCloser), error)`)).Values(j.DictFunc(func(d j.Dict) {
+			for _, ce := range contentEncodings {
+				if body, ok := encodingDecompressors[ce]; ok {
+					d[j.Lit(ce)] = j.Op(`func(r io.Reader) (io.ReadCloser, error)`).Block(utils.QualSprintf(body))
+				}
+			}
+		})),
+		j.Add(utils.QualSprintf(`
+			// NewDecompressor returns the reader that decompresses the data read from r according to content encoding.
+			// The data is read as is if content encoding is empty or "identity".
+			func NewDecompressor(contentEncoding string, r %Q(io,Reader)) (%Q(io,ReadCloser), error) {
+				contentEncoding = %Q(strings,ToLower)(%Q(strings,TrimSpace)(contentEncoding))
+				if contentEncoding == "" || contentEncoding == "identity" {
+					return %Q(io,NopCloser)(r), nil
+				}
+				if v, ok := Decompressors[contentEncoding]; ok {
+					return v(r)
+				}
+				return nil, %Q(fmt,Errorf)("no decompressor is set for content encoding %%q, add it by RegisterCompression function", contentEncoding)
+			}`)),
+	}
+}
+
+func renderAvroEncoder() []*j.Statement {
+	return []*j.Statement{
+		j.Add(utils.QualSprintf(`
+			var avroSchemas %Q(sync,Map)
+
+			// RegisterAvroSchema sets the Avro schema to encode and decode the values of a given type
+			func RegisterAvroSchema(typ %Q(reflect,Type), schema string) {
+				avroSchemas.Store(typ, %Q(github.com/hamba/avro/v2,MustParse)(schema))
+			}
+
+			func getAvroSchema(typ %Q(reflect,Type)) (%Q(github.com/hamba/avro/v2,Schema), error) {
+				if v, ok := avroSchemas.Load(typ); ok {
+					return v.(%Q(github.com/hamba/avro/v2,Schema)), nil
+				}
+				return nil, %Q(fmt,Errorf)("no avro schema registered for type %%v", typ)
+			}`)),
+		j.Add(utils.QualSprintf(`
+			type avroEncoder struct {
+				w %Q(io,Writer)
+			}
+
+			func (e avroEncoder) Encode(v any) error {
+				schema, err := getAvroSchema(%Q(reflect,TypeOf)(v))
+				if err != nil {
+					return err
+				}
+				return %Q(github.com/hamba/avro/v2,NewEncoderForSchema)(schema, e.w).Encode(v)
+			}`)),
+	}
+}
+
+func renderAvroDecoder() []*j.Statement {
+	return []*j.Statement{
+		j.Add(utils.QualSprintf(`
+			type avroDecoder struct {
+				r %Q(io,Reader)
+			}
+
+			func (d avroDecoder) Decode(v any) error {
+				typ := %Q(reflect,TypeOf)(v)
+				if typ == nil || typ.Kind() != %Q(reflect,Pointer) {
+					return %Q(fmt,Errorf)("avro decode target must be a pointer, got %%T", v)
+				}
+				schema, err := getAvroSchema(typ.Elem())
+				if err != nil {
+					return err
+				}
+				return %Q(github.com/hamba/avro/v2,NewDecoderForSchema)(schema, d.r).Decode(v)
+			}`)),
+	}
+}
+
+func renderProtobufEncoder() *j.Statement {
+	return j.Add(utils.QualSprintf(`
+		type protobufEncoder struct {
+			w %Q(io,Writer)
+		}
+
+		func (e protobufEncoder) Encode(v any) error {
+			m, ok := v.(interface{ MarshalProtobuf() ([]byte, error) })
+			if !ok {
+				return %Q(fmt,Errorf)("type %%T is not a protobuf message", v)
+			}
+			b, err := m.MarshalProtobuf()
+			if err != nil {
+				return err
+			}
+			_, err = e.w.Write(b)
+			return err
+		}`))
+}
+
+func renderProtobufDecoder() *j.Statement {
+	return j.Add(utils.QualSprintf(`
+		type protobufDecoder struct {
+			r %Q(io,Reader)
+		}
+
+		func (d protobufDecoder) Decode(v any) error {
+			m, ok := v.(interface{ UnmarshalProtobuf(data []byte) error })
+			if !ok {
+				return %Q(fmt,Errorf)("type %%T is not a protobuf message pointer", v)
+			}
+			b, err := %Q(io,ReadAll)(d.r)
+			if err != nil {
+				return err
+			}
+			return m.UnmarshalProtobuf(b)
+		}`))
+}
@@ -29,6 +29,9 @@ func (a GoArray) RenderDefinition(ctx *common.RenderContext) []*jen.Statement {
 	}
 	items := utils.ToCode(a.ItemsType.RenderUsage(ctx))
 	res = append(res, stmt.Add(items...))
+	if a.hasValidateMethod() {
+		res = append(res, renderTypeValidateMethod(ctx, &a, a.Name)...)
+	}
 
 	return res
 }
@@ -0,0 +1,135 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/samber/lo"
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+// GoEnum is a named type with a fixed set of values, every value is rendered as a constant
+type GoEnum struct {
+	BaseType
+	UnderlyingType *GoSimple // Type of values: string, int or float64
+	Values         []GoEnumValue
+	Strict         bool // Reject the values not in Values on marshal and unmarshal
+}
+
+type GoEnumValue struct {
+	Name  string // Constant name
+	Value any    // Constant value: string, int64 or float64
+}
+
+func (e GoEnum) RenderDefinition(ctx *common.RenderContext) []*jen.Statement {
+	ctx.LogStartRender("GoEnum", e.Import, e.Name, "definition", e.DirectRendering())
+	defer ctx.LogFinishRender()
+
+	var res []*jen.Statement
+	if e.Description != "" {
+		res = append(res, jen.Comment(e.Name+" -- "+utils.ToLowerFirstLetter(e.Description)))
+	}
+	res = append(res, jen.Type().Id(e.Name).Add(utils.ToCode(e.UnderlyingType.RenderUsage(ctx))...))
+	res = append(res, jen.Const().DefsFunc(func(g *jen.Group) {
+		for _, v := range e.Values {
+			g.Id(v.Name).Id(e.Name).Op("=").Add(renderEnumValue(v.Value))
+		}
+	}))
+
+	rn := e.receiverName()
+	names := lo.Map(e.Values, func(item GoEnumValue, _ int) jen.Code { return jen.Id(item.Name) })
+	res = append(res,
+		jen.Comment("IsValid returns true if the value is one of the "+e.Name+" constants"),
+		jen.Func().Params(jen.Id(rn).Id(e.Name)).Id("IsValid").Params().Bool().Block(
+			jen.Switch(jen.Id(rn)).Block(jen.Case(names...).Block(jen.Return(jen.True()))),
+			jen.Return(jen.False()),
+		),
+		jen.Func().Params(jen.Id(rn).Id(e.Name)).Id("String").Params().String().Block(
+			jen.Return(e.renderFormatValue(jen.Id(rn))),
+		),
+	)
+	if e.Strict {
+		res = append(res, e.renderMarshalMethods()...)
+	}
+	if e.hasValidateMethod() {
+		res = append(res, renderTypeValidateMethod(ctx, &e, e.Name)...)
+	}
+	return res
+}
+
+func (e GoEnum) RenderUsage(ctx *common.RenderContext) []*jen.Statement {
+	ctx.LogStartRender("GoEnum", e.Import, e.Name, "usage", e.DirectRendering())
+	defer ctx.LogFinishRender()
+
+	if e.Import != "" && e.Import != ctx.CurrentPackage {
+		return []*jen.Statement{jen.Qual(ctx.GeneratedModule(e.Import), e.Name)}
+	}
+	return []*jen.Statement{jen.Id(e.Name)}
+}
+
+func (e GoEnum) receiverName() string {
+	return strings.ToLower(e.Name[:1])
+}
+
+// renderFormatValue renders an expression that formats the enum value as string
+func (e GoEnum) renderFormatValue(val jen.Code) *jen.Statement {
+	switch e.UnderlyingType.Name {
+	case "string":
+		return jen.String().Call(val)
+	case "float64":
+		return jen.Qual("strconv", "FormatFloat").Call(jen.Float64().Call(val), jen.LitRune('g'), jen.Lit(-1), jen.Lit(64))
+	}
+	return jen.Qual("strconv", "FormatInt").Call(jen.Int64().Call(val), jen.Lit(10))
+}
+
+// renderMarshalMethods renders JSON and YAML marshal/unmarshal methods, that reject the unknown values
+func (e GoEnum) renderMarshalMethods() []*jen.Statement {
+	rn := e.receiverName()
+	underlying := jen.Id(e.UnderlyingType.Name)
+	checkValid := func(val jen.Code, withResult bool) *jen.Statement {
+		err := jen.Qual("fmt", "Errorf").Call(jen.Lit("unknown "+e.Name+" value: %s"), e.renderFormatValue(val))
+		return jen.If(jen.Op("!").Add(val).Dot("IsValid").Call()).Block(
+			jen.ReturnFunc(func(g *jen.Group) {
+				if withResult {
+					g.Nil()
+				}
+				g.Add(err)
+			}),
+		)
+	}
+	unmarshal := func(decodeStmt *jen.Statement) []jen.Code {
+		return []jen.Code{
+			jen.Var().Id("v").Add(underlying),
+			jen.If(jen.Err().Op(":=").Add(decodeStmt), jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Err())),
+			checkValid(jen.Id(e.Name).Call(jen.Id("v")), false),
+			jen.Op("*").Id(rn).Op("=").Id(e.Name).Call(jen.Id("v")),
+			jen.Return(jen.Nil()),
+		}
+	}
+
+	return []*jen.Statement{
+		jen.Func().Params(jen.Id(rn).Id(e.Name)).Id("MarshalJSON").Params().Params(jen.Index().Byte(), jen.Error()).Block(
+			checkValid(jen.Id(rn), true),
+			jen.Return(jen.Qual("encoding/json", "Marshal").Call(jen.Add(underlying).Call(jen.Id(rn)))),
+		),
+		jen.Func().Params(jen.Id(rn).Op("*").Id(e.Name)).Id("UnmarshalJSON").Params(jen.Id("data").Index().Byte()).Error().Block(
+			unmarshal(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Op("&").Id("v")))...,
+		),
+		jen.Func().Params(jen.Id(rn).Id(e.Name)).Id("MarshalYAML").Params().Params(jen.Any(), jen.Error()).Block(
+			checkValid(jen.Id(rn), true),
+			jen.Return(jen.Add(underlying).Call(jen.Id(rn)), jen.Nil()),
+		),
+		jen.Func().Params(jen.Id(rn).Op("*").Id(e.Name)).Id("UnmarshalYAML").Params(jen.Id("node").Op("*").Qual("gopkg.in/yaml.v3", "Node")).Error().Block(
+			unmarshal(jen.Id("node").Dot("Decode").Call(jen.Op("&").Id("v")))...,
+		),
+	}
+}
+
+func renderEnumValue(value any) *jen.Statement {
+	if v, ok := value.(int64); ok {
+		return jen.Op(strconv.FormatInt(v, 10)) // Untyped constant, jen.Lit renders int64(...)
+	}
+	return jen.Lit(value)
+}
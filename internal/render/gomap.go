@@ -25,6 +25,9 @@ func (m GoMap) RenderDefinition(ctx *common.RenderContext) []*jen.Statement {
 	keyType := utils.ToCode(m.KeyType.RenderUsage(ctx))
 	valueType := utils.ToCode(m.ValueType.RenderUsage(ctx))
 	res = append(res, stmt.Map((&jen.Statement{}).Add(keyType...)).Add(valueType...))
+	if m.hasValidateMethod() {
+		res = append(res, renderTypeValidateMethod(ctx, &m, m.Name)...)
+	}
 
 	return res
 }
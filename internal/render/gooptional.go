@@ -0,0 +1,54 @@
+package render
+
+import (
+	"github.com/dave/jennifer/jen"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+// GoOptional is a nullable type rendered as run.Optional[T] value, which is null if not set. Used instead of GoPointer
+// if --nullable-fields=optional is set.
+type GoOptional struct {
+	Type         common.GolangType
+	DirectRender bool
+}
+
+func (p GoOptional) DirectRendering() bool {
+	return p.DirectRender
+}
+
+func (p GoOptional) RenderDefinition(ctx *common.RenderContext) []*jen.Statement {
+	ctx.LogStartRender("GoOptional", "", "", "definition", p.DirectRendering())
+	defer ctx.LogFinishRender()
+
+	return p.Type.RenderDefinition(ctx)
+}
+
+func (p GoOptional) RenderUsage(ctx *common.RenderContext) []*jen.Statement {
+	ctx.LogStartRender("GoOptional", "", "", "usage", p.DirectRendering())
+	defer ctx.LogFinishRender()
+
+	if p.isOptional() {
+		return []*jen.Statement{jen.Qual(ctx.RuntimeModule(""), "Optional").Types(utils.ToCode(p.Type.RenderUsage(ctx))...)}
+	}
+	return p.Type.RenderUsage(ctx)
+}
+
+// isOptional returns true if the type is actually wrapped, i.e. the underlying type is not an interface or pointer,
+// that can be nil itself
+func (p GoOptional) isOptional() bool {
+	return GoPointer{Type: p.Type}.isPointer()
+}
+
+func (p GoOptional) TypeName() string {
+	return p.Type.TypeName()
+}
+
+func (p GoOptional) ID() string {
+	return p.Type.ID()
+}
+
+func (p GoOptional) String() string {
+	return "GoOptional -> " + p.Type.String()
+}
@@ -9,6 +9,10 @@ import (
 type GoPointer struct {
 	Type         common.GolangType
 	DirectRender bool
+	Nullable     bool // Pointer denotes the nullable type, so nil is a valid value even for required property
+	// OmitEmpty is set for optional property in --optional-fields=omitempty mode. The pointer is rendered only if
+	// the `omitempty` tag option doesn't omit the zero value of type, e.g. for structs.
+	OmitEmpty bool
 }
 
 func (p GoPointer) DirectRendering() bool {
@@ -26,19 +30,26 @@ func (p GoPointer) RenderUsage(ctx *common.RenderContext) []*jen.Statement {
 	ctx.LogStartRender("GoPointer", "", "", "usage", p.DirectRendering())
 	defer ctx.LogFinishRender()
 
-	isPtr := true
+	if p.isPointer() {
+		return []*jen.Statement{jen.Op("*").Add(utils.ToCode(p.Type.RenderUsage(ctx))...)}
+	}
+	return p.Type.RenderUsage(ctx)
+}
+
+// isPointer returns true if the pointer is actually rendered, i.e. the underlying type is not an interface or pointer
+func (p GoPointer) isPointer() bool {
+	if p.OmitEmpty && isTypeOmittable(p.Type) {
+		return false
+	}
 	switch v := p.Type.(type) {
 	case *GoInterface: // Prevent pointer to interface
-		isPtr = false
+		return false
 	case golangPointerType:
-		isPtr = !v.IsPointer() // Prevent appearing pointer to pointer
+		return !v.IsPointer() // Prevent appearing pointer to pointer
 	case *GoSimple:
-		isPtr = !v.IsIface
-	}
-	if isPtr {
-		return []*jen.Statement{jen.Op("*").Add(utils.ToCode(p.Type.RenderUsage(ctx))...)}
+		return !v.IsIface
 	}
-	return p.Type.RenderUsage(ctx)
+	return true
 }
 
 func (p GoPointer) TypeName() string {
@@ -58,5 +69,29 @@ func (p GoPointer) WrappedGolangType() (common.GolangType, bool) {
 }
 
 func (p GoPointer) IsPointer() bool {
-	return true
+	return !p.OmitEmpty || p.isPointer()
+}
+
+// isTypeOmittable returns true if the zero value of type is omitted by `omitempty` tag option of encoding/json, i.e.
+// it's a basic type, slice, map, interface or pointer
+func isTypeOmittable(typ common.GolangType) bool {
+	switch v := typ.(type) {
+	case *GolangTypePromise:
+		return v.Assigned() && isTypeOmittable(v.Target())
+	case *GoPointer:
+		return v.isPointer() || isTypeOmittable(v.Type)
+	case *GoOptional:
+		return !v.isOptional() && isTypeOmittable(v.Type)
+	case *GoTypeAlias:
+		return isTypeOmittable(v.AliasedType)
+	case *GoEnum:
+		return isTypeOmittable(v.UnderlyingType)
+	case *GoArray:
+		return v.Size == 0
+	case *GoMap, *GoInterface:
+		return true
+	case *GoSimple:
+		return v.Import == "" || v.IsIface
+	}
+	return false
 }
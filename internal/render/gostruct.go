@@ -17,6 +17,19 @@ import (
 type GoStruct struct {
 	BaseType
 	Fields []GoStructField
+
+	DiscriminatorField  string // Property that tells apart the schemas, if schema has `discriminator`
+	DiscriminatorValue  string // Value of discriminator property denoting this schema, if set by discriminator mapping
+	DiscriminatorSchema string // Schema name, the discriminator value if neither mapping nor property const sets it
+
+	RequiredProperties []string    // Properties required by schema, including ones defined in AllOf parts
+	AllOf              []AllOfPart // Schemas to be merged to struct after linking, see MergeAllOf
+	// NoAdditionalProperties is true if schema has `additionalProperties: false`, so unmarshaling the properties
+	// that are not struct fields is an error
+	NoAdditionalProperties bool
+	Tuple                  *TupleItems // Set if struct is marshaled as an array, fields are its items
+	// Conditions are the conditional schemas (if/then/else, not), that are checked against the data on unmarshal
+	Conditions *SchemaCheck
 }
 
 func (s GoStruct) RenderDefinition(ctx *common.RenderContext) []*jen.Statement {
@@ -31,6 +44,22 @@ func (s GoStruct) RenderDefinition(ctx *common.RenderContext) []*jen.Statement {
 		return item.renderDefinition(ctx)
 	})
 	res = append(res, jen.Type().Id(s.Name).Struct(utils.ToCode(code)...))
+	if s.Constraints != nil {
+		res = append(res, renderConstructor(ctx, &s)...)
+	}
+	if s.Tuple != nil && s.DirectRender {
+		res = append(res, renderTupleMethods(ctx, &s)...)
+	}
+	if s.hasUnmarshalMethods() {
+		res = append(res, renderUnmarshalMethods(ctx, &s)...)
+	}
+	if s.hasPropertiesMethods() {
+		warnUnsupportedPropertiesFormats(ctx, &s)
+		res = append(res, renderPropertiesMethods(ctx, &s)...)
+	}
+	if s.hasValidateMethod() {
+		res = append(res, renderTypeValidateMethod(ctx, &s, s.Name)...)
+	}
 	return res
 }
 
@@ -84,6 +113,13 @@ type GoStructField struct {
 	ExtraTags      types.OrderedMap[string, string] // Just append these tags as constant, overwrite other tags on overlap
 	ExtraTagNames  []string                         // Append these tags and fill them the same value as others
 	ExtraTagValues []string                         // Add these comma-separated values to all tags (excluding ExtraTags)
+	Required       bool                             // Property is required by schema, nil value is a violation
+	OmitEmpty      bool                             // Property is optional, the empty value is not marshaled
+	Default        any                              // Property default value, overrides the default of its type
+	// KeyPattern is set for a map field, that contains the properties with names matching this regex (patternProperties)
+	KeyPattern string
+	// Additional is true for a map field, that contains the properties not set to other fields (additionalProperties)
+	Additional bool
 }
 
 func (f GoStructField) renderDefinition(ctx *common.RenderContext) []*jen.Statement {
@@ -100,18 +136,26 @@ func (f GoStructField) renderDefinition(ctx *common.RenderContext) []*jen.Statem
 	items := utils.ToCode(f.Type.RenderUsage(ctx))
 	stmt = stmt.Add(items...)
 
+	tags := lo.FromEntries(f.ExtraTags.Entries())
 	if f.TagsSource != nil {
 		tagValues := append([]string{f.MarshalName}, f.ExtraTagValues...)
-		tagNames := lo.Uniq(lo.FilterMap(f.TagsSource.Targets(), func(item *Message, _ int) (string, bool) {
-			format := getFormatByContentType(item.ContentType)
-			return format, format != ""
+		formatTags := lo.Uniq(lo.FilterMap(f.TagsSource.Targets(), func(item *Message, _ int) (formatStructTag, bool) {
+			tag, ok := formatStructTags[getFormatByContentType(item.ContentType)]
+			return tag, ok
 		}))
-		tagNames = append(tagNames, f.ExtraTagNames...)
-
-		tags := lo.SliceToMap(tagNames, func(item string) (string, string) {
+		formatTagValues := lo.SliceToMap(formatTags, func(item formatStructTag) (string, string) {
+			if f.OmitEmpty && item.omitEmpty {
+				return item.name, strings.Join(tagValues, ",") + ",omitempty"
+			}
+			return item.name, strings.Join(tagValues, ",")
+		})
+		extraTagValues := lo.SliceToMap(f.ExtraTagNames, func(item string) (string, string) {
 			return item, strings.Join(tagValues, ",")
 		})
-		tags = lo.Assign(tags, lo.FromEntries(f.ExtraTags.Entries()))
+
+		tags = lo.Assign(extraTagValues, formatTagValues, tags)
+	}
+	if len(tags) > 0 {
 		stmt = stmt.Tag(tags)
 	}
 
@@ -9,6 +9,7 @@ import (
 type GoTypeAlias struct {
 	BaseType
 	AliasedType common.GolangType
+	Transparent bool // Render Go type alias "type A = B" instead of definition, so that A keeps the methods of B
 }
 
 func (p GoTypeAlias) RenderDefinition(ctx *common.RenderContext) []*jen.Statement {
@@ -20,8 +21,16 @@ func (p GoTypeAlias) RenderDefinition(ctx *common.RenderContext) []*jen.Statemen
 		res = append(res, jen.Comment(p.Name+" -- "+utils.ToLowerFirstLetter(p.Description)))
 	}
 
-	aliasedStmt := utils.ToCode(p.AliasedType.RenderDefinition(ctx))
-	res = append(res, jen.Type().Id(p.Name).Add(aliasedStmt...))
+	if p.Transparent {
+		aliasedStmt := utils.ToCode(p.AliasedType.RenderUsage(ctx))
+		res = append(res, jen.Type().Id(p.Name).Op("=").Add(aliasedStmt...))
+	} else {
+		aliasedStmt := utils.ToCode(p.AliasedType.RenderDefinition(ctx))
+		res = append(res, jen.Type().Id(p.Name).Add(aliasedStmt...))
+		if p.hasValidateMethod() {
+			res = append(res, renderTypeValidateMethod(ctx, &p, p.Name)...)
+		}
+	}
 	return res
 }
 
@@ -42,6 +51,11 @@ func (p GoTypeAlias) RenderUsage(ctx *common.RenderContext) []*jen.Statement {
 	return []*jen.Statement{jen.Add(aliasedStmt...)}
 }
 
+// hasValidateMethod returns false for transparent alias, since methods can't be defined on a type from other package
+func (p GoTypeAlias) hasValidateMethod() bool {
+	return !p.Transparent && p.BaseType.hasValidateMethod()
+}
+
 func (p GoTypeAlias) WrappedGolangType() (common.GolangType, bool) {
 	return p.AliasedType, p.AliasedType != nil
 }
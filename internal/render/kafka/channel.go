@@ -2,7 +2,6 @@ package kafka
 
 import (
 	"github.com/xcnt/go-asyncapi/internal/common"
-	"github.com/xcnt/go-asyncapi/internal/render"
 	"github.com/xcnt/go-asyncapi/internal/render/proto"
 	"github.com/xcnt/go-asyncapi/internal/utils"
 	j "github.com/dave/jennifer/jen"
@@ -118,10 +117,7 @@ func (pc ProtoChannel) renderProtoPublisherMethods(ctx *common.RenderContext) []
 	rn := pc.Struct.ReceiverName()
 	receiver := j.Id(rn).Id(pc.Struct.Name)
 
-	var msgTyp common.GolangType = render.GoPointer{Type: pc.Parent.FallbackMessageType, DirectRender: true}
-	if pc.Parent.PubMessagePromise != nil {
-		msgTyp = render.GoPointer{Type: pc.Parent.PubMessagePromise.Target().OutStruct, DirectRender: true}
-	}
+	msgTyp := pc.PubMessageType()
 
 	return []*j.Statement{
 		// Method SealEnvelope(envelope kafka.EnvelopeWriter, message *Message1Out) error
@@ -133,25 +129,8 @@ func (pc ProtoChannel) renderProtoPublisherMethods(ctx *common.RenderContext) []
 			Error().
 			BlockFunc(func(bg *j.Group) {
 				bg.Op("envelope.ResetPayload()")
-				if pc.Parent.PubMessagePromise == nil { // No Message set for Channel in spec
-					bg.Empty().Add(utils.QualSprintf(`
-						enc := %Q(encoding/json,NewEncoder)(envelope)
-						if err := enc.Encode(message); err != nil {
-							return err
-						}`))
-				} else { // Message is set for Channel in spec
-					bg.Op(`
-						if err := message.MarshalKafkaEnvelope(envelope); err != nil {
-							return err
-						}`)
-				}
+				pc.RenderMarshalMessage(ctx, bg)
 				bg.Op("envelope.SetTopic").Call(j.Id(rn).Dot("topic"))
-				// Message SetBindings
-				if pc.Parent.PubMessagePromise != nil && pc.Parent.PubMessagePromise.Target().HasProtoBindings(pc.ProtoName) {
-					bg.Op("envelope.SetBindings").Call(
-						j.Add(utils.ToCode(pc.Parent.PubMessagePromise.Target().BindingsStruct.RenderUsage(ctx))...).Values().Dot(pc.ProtoTitle).Call(),
-					)
-				}
 				bg.Return(j.Nil())
 			}),
 	}
@@ -2,25 +2,33 @@ package render
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/xcnt/go-asyncapi/internal/common"
 	"github.com/xcnt/go-asyncapi/internal/utils"
 	j "github.com/dave/jennifer/jen"
+	"github.com/samber/lo"
 )
 
 type Message struct {
 	Name                 string
+	MessageID            string // Message id to tell apart the messages in one channel, e.g. in headers
 	Dummy                bool
 	OutStruct            *GoStruct
 	InStruct             *GoStruct
 	PayloadType          common.GolangType // `any` or a particular type
 	HeadersFallbackType  *GoMap
 	HeadersTypePromise   *Promise[*GoStruct]
-	AllServersPromises   []*Promise[*Server]      // For extracting all using protocols
-	BindingsStruct       *GoStruct                // nil if message bindings are not defined for message
-	BindingsPromise      *Promise[*Bindings]      // nil if message bindings are not defined for message as well
-	ContentType          string                   // Message's content type or default from schema or fallback
-	CorrelationIDPromise *Promise[*CorrelationID] // nil if correlationID is not defined for message
+	AllServersPromises   []*Promise[*Server]           // For extracting all using protocols
+	BindingsStruct       *GoStruct                     // nil if message bindings are not defined for message
+	BindingsPromise      *Promise[*Bindings]           // nil if message bindings are not defined for message as well
+	PayloadSchemaPromise *ListPromise[common.Renderer] // Avro and Protobuf schemas, nil if message bindings are not defined
+	ContentType          string                        // Message's content type or default from schema or fallback
+	SchemaFormat         string                        // Payload schema format, empty for default (jsonschema)
+	ContentEncoding      string                        // Payload compression set by x-content-encoding extension
+	CloudEvents          common.CloudEventsMode        // CloudEvents content mode, empty if message is not mapped to CloudEvents
+	CloudEventsSource    string                        // Default CloudEvents source attribute
+	CorrelationIDPromise *Promise[*CorrelationID]      // nil if correlationID is not defined for message
 }
 
 func (m Message) DirectRendering() bool {
@@ -74,14 +82,155 @@ func (m Message) HasProtoBindings(protoName string) bool {
 	return ok1 || ok2
 }
 
+// PayloadDiscriminator returns the discriminator property name and value of message payload if payload schema
+// has a discriminator
+func (m Message) PayloadDiscriminator() (field, value string, ok bool) {
+	strct, ok := underlyingStruct(m.PayloadType)
+	if !ok || strct.DiscriminatorField == "" {
+		return "", "", false
+	}
+	values := discriminatorValues(strct, strct.DiscriminatorField, lo.Compact([]string{strct.DiscriminatorValue}), strct.DiscriminatorSchema)
+	if len(values) == 0 {
+		return "", "", false
+	}
+	return strct.DiscriminatorField, values[0], true
+}
+
+// ProtoContentEncoding returns the content encoding (compression) of payload of message sent by protocol, or empty string
+// if payload is not compressed. The contentEncoding of protocol message bindings (AMQP) goes first, then
+// x-content-encoding extension.
+func (m Message) ProtoContentEncoding(protoName string) string {
+	res, _ := lo.Coalesce(m.bindingsLiteral(protoName, "ContentEncoding"), m.ContentEncoding)
+	res = strings.ToLower(strings.TrimSpace(res))
+	return lo.Ternary(res == "identity", "", res)
+}
+
+// bindingsLiteral returns the string value of field of protocol message bindings, or empty string if it's not set
+func (m Message) bindingsLiteral(protoName, field string) string {
+	return bindingsLiteral(m.BindingsPromise, protoName, field)
+}
+
+// bindingsLiteral returns the string value of field of protocol bindings, or empty string if it's not set
+func bindingsLiteral(prm *Promise[*Bindings], protoName, field string) string {
+	if prm == nil {
+		return ""
+	}
+	if vals, ok := prm.Target().Values.Get(protoName); ok {
+		if v, ok := vals.StructVals.Get(field); ok {
+			if s, ok := v.(*GoValue).Literal.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// Schema registry vendors, set by `schemaRegistryVendor` in Kafka server bindings
+const (
+	SchemaRegistryVendorConfluent = "confluent"
+	SchemaRegistryVendorApicurio  = "apicurio"
+)
+
+// RegistrySchema is the message payload schema registered in schema registry. Its id is written to the payload or
+// headers in the wire format of a registry vendor.
+type RegistrySchema struct {
+	Type              string // AVRO, JSON or PROTOBUF
+	Schema            string // Empty for JSON, the latest schema version registered for subject is used
+	RecordName        string
+	MessageIndexes    []int // Protobuf message indexes in .proto file
+	IDLocation        string
+	IDPayloadEncoding string
+}
+
+// RegistrySchema returns the payload schema if the message bindings of protocol (Kafka) set the schema id location.
+// Avro and Protobuf schemas are taken from the payload definition, other payloads are treated as JSON Schema.
+func (m Message) RegistrySchema(protoName string) (RegistrySchema, bool) {
+	res := RegistrySchema{
+		IDLocation:        m.bindingsLiteral(protoName, "SchemaIDLocation"),
+		IDPayloadEncoding: m.bindingsLiteral(protoName, "SchemaIDPayloadEncoding"),
+	}
+	if res.IDLocation == "" || m.PayloadSchemaPromise == nil {
+		return res, false
+	}
+	// Keep in sync with kafka.SchemaIDFormat in runtime, the implementation uses it on sending
+	switch vendor := m.registryVendor(protoName); {
+	case vendor == SchemaRegistryVendorApicurio && res.IDPayloadEncoding == "":
+		res.IDPayloadEncoding = "apicurio-new"
+	case vendor == SchemaRegistryVendorConfluent && res.IDLocation == "header":
+		panic(fmt.Errorf("schemaIdLocation %q is not supported by %s schema registry", res.IDLocation, vendor))
+	case vendor == "" && res.IDLocation == "header":
+		// Header name is vendor-specific
+		panic(fmt.Errorf("schemaIdLocation %q requires schemaRegistryVendor to be set in server bindings", res.IDLocation))
+	}
+
+	typ := m.PayloadType
+	for {
+		v, ok := typ.(golangTypeWrapperType)
+		if !ok {
+			break
+		}
+		if typ, ok = v.WrappedGolangType(); !ok {
+			break
+		}
+	}
+	for _, item := range m.PayloadSchemaPromise.Targets() {
+		switch v := item.(type) {
+		case *AvroSchema:
+			if v.Type == typ {
+				res.Type, res.Schema, res.RecordName = "AVRO", v.Schema, v.FullName
+				return res, true
+			}
+		case *ProtobufMessage:
+			if v.Struct == typ {
+				res.Type, res.Schema, res.RecordName, res.MessageIndexes = "PROTOBUF", v.FileSchema, v.FullName, v.MessageIndexes
+				return res, true
+			}
+		}
+	}
+	res.Type, res.RecordName = "JSON", typ.TypeName()
+	return res, true
+}
+
+// registryVendor returns the schema registry vendor set in bindings of protocol servers the message is sent to, or
+// empty string if it's not set. The servers must not set different vendors, since the message has one wire format.
+func (m Message) registryVendor(protoName string) string {
+	vendors := lo.Uniq(lo.FilterMap(m.AllServersPromises, func(item *Promise[*Server], _ int) (string, bool) {
+		srv := item.Target()
+		v := bindingsLiteral(srv.BindingsPromise, protoName, "SchemaRegistryVendor")
+		return v, srv.Protocol == protoName && !srv.Dummy && v != ""
+	}))
+	if len(vendors) > 1 {
+		panic(fmt.Errorf("message servers set different schema registry vendors: %s", strings.Join(vendors, ", ")))
+	}
+	if len(vendors) == 0 {
+		return ""
+	}
+	return vendors[0]
+}
+
+// CloudEventsHeaderPrefix returns the prefix of header names the CloudEvents attributes are written to in binary
+// content mode, according to the protocol binding of CloudEvents spec
+func CloudEventsHeaderPrefix(protoName string) string {
+	switch protoName {
+	case "kafka":
+		return "ce_"
+	case "amqp":
+		return "cloudEvents_"
+	case "mqtt":
+		return "" // MQTT 5 user properties are named as attributes
+	}
+	return "ce-"
+}
+
 func (m Message) renderPublishMessageStruct(ctx *common.RenderContext) []*j.Statement {
 	ctx.Logger.Trace("renderPublishMessageStruct")
 
 	var res []*j.Statement
 	res = append(res, j.Func().Id(m.OutStruct.NewFuncName()).Params().Op("*").Add(utils.ToCode(m.OutStruct.RenderUsage(ctx))...).Block(
-		j.Return(j.Op("&").Add(utils.ToCode(m.OutStruct.RenderUsage(ctx))...).Values()),
+		j.Return(j.Op("&").Add(utils.ToCode(m.OutStruct.RenderUsage(ctx))...).Values(m.renderFieldDefaults(ctx, m.OutStruct))),
 	))
 	res = append(res, m.OutStruct.RenderDefinition(ctx)...)
+	res = append(res, m.renderValidateMethod(ctx, m.OutStruct)...)
 
 	for _, p := range getServerProtocols(ctx, m.AllServersPromises) {
 		res = append(res, m.renderMarshalEnvelopeMethod(ctx, p, ctx.ProtoRenderers[p].ProtocolTitle())...)
@@ -130,11 +279,33 @@ func (m Message) renderPublishCommonMethods(ctx *common.RenderContext) []*j.Stat
 	return res
 }
 
+// renderFieldDefaults renders the default values of payload and headers from schema, if any
+func (m Message) renderFieldDefaults(ctx *common.RenderContext, strct *GoStruct) j.Dict {
+	res := j.Dict{}
+	for _, f := range strct.Fields {
+		if v := renderDefaultValue(ctx, f.Type, nil); v != nil {
+			res[j.Id(f.Name)] = v
+		}
+	}
+	return res
+}
+
+// renderValidateMethod renders the Validate method of message struct, that validates the payload and headers
+func (m Message) renderValidateMethod(ctx *common.RenderContext, strct *GoStruct) []*j.Statement {
+	ctx.Logger.Trace("renderValidateMethod")
+
+	rn := strct.ReceiverName()
+	r := validationRenderer{ctx: ctx, visiting: map[common.GolangType]bool{strct: true}}
+	checks := r.renderContents(strct, j.Id(rn), validationPath{"$"})
+	return renderValidateMethod(ctx, j.Id(rn).Op("*").Id(strct.Name), checks)
+}
+
 func (m Message) renderMarshalEnvelopeMethod(ctx *common.RenderContext, protoName, protoTitle string) []*j.Statement {
 	ctx.Logger.Trace("renderMarshalEnvelopeMethod")
 
 	rn := m.OutStruct.ReceiverName()
 	receiver := j.Id(rn).Op("*").Id(m.OutStruct.Name)
+	contentEncoding := m.ProtoContentEncoding(protoName)
 
 	return []*j.Statement{
 		// Method MarshalProtoEnvelope(envelope proto.EnvelopeWriter) error
@@ -142,28 +313,81 @@ func (m Message) renderMarshalEnvelopeMethod(ctx *common.RenderContext, protoNam
 			Params(j.Id("envelope").Qual(ctx.RuntimeModule(protoName), "EnvelopeWriter")).
 			Error().
 			BlockFunc(func(bg *j.Group) {
-				bg.Op("enc := ").Qual(ctx.GeneratedModule(encodingPackageName), "NewEncoder").Call(
-					j.Lit(m.ContentType),
-					j.Id("envelope"),
-				)
+				if m.CloudEvents != common.CloudEventsNone {
+					// The filled attributes are kept in the message, so the caller knows the id and time of sent event
+					bg.Id(rn).Dot("CloudEvent").Dot("SetDefaults").Call(j.Lit(m.MessageID), j.Lit(m.CloudEventsSource), j.Lit(m.ContentType))
+					bg.If(j.Err().Op(":=").Id(rn).Dot("CloudEvent").Dot("Validate").Call(), j.Err().Op("!=").Nil()).Block(j.Return(j.Err()))
+				}
+				w := j.Id("envelope")
+				if contentEncoding != "" {
+					bg.Add(utils.QualSprintf(`
+						zw, err := %Q(%s,NewCompressor)(%q, envelope)
+						if err != nil {
+							return err
+						}
+						defer zw.Close() // Releases the compressor on error, the result is checked below`, ctx.GeneratedModule(encodingPackageName), contentEncoding))
+					w = j.Id("zw")
+				}
+				if m.CloudEvents == common.CloudEventsStructured {
+					// Payload becomes the event data inside the JSON event
+					bg.Var().Id("data").Qual("bytes", "Buffer")
+					bg.Op("enc := ").Qual(ctx.GeneratedModule(encodingPackageName), "NewEncoder").Call(j.Lit(m.ContentType), j.Op("&").Id("data"))
+				} else {
+					bg.Op("enc := ").Qual(ctx.GeneratedModule(encodingPackageName), "NewEncoder").Call(j.Lit(m.ContentType), w)
+				}
+				if ctx.RenderOpts.ValidateMessages {
+					bg.If(j.Err().Op(":=").Id(rn).Dot("Validate").Call(), j.Err().Op("!=").Nil()).Block(j.Return(j.Err()))
+				}
 				bg.Op(fmt.Sprintf(`
 					if err := enc.Encode(%[1]s.Payload); err != nil {
 						return err
 					}`, rn))
-				bg.Op("envelope.SetContentType").Call(j.Lit(m.ContentType))
-				if m.HeadersTypePromise != nil {
-					bg.Id("envelope").Dot("SetHeaders").Call(
-						j.Qual(ctx.RuntimeModule(""), "Headers").Values(j.DictFunc(func(d j.Dict) {
-							for _, f := range m.HeadersTypePromise.Target().Fields {
-								d[j.Lit(f.Name)] = j.Id(rn).Dot("Headers").Dot(f.Name)
-							}
-						})),
-					)
-				} else {
-					bg.Id("envelope.SetHeaders").Call(
-						j.Qual(ctx.RuntimeModule(""), "Headers").Call(j.Id(rn).Dot("Headers")),
-					)
+				contentType := j.Lit(m.ContentType)
+				if m.CloudEvents == common.CloudEventsStructured {
+					bg.If(
+						j.Err().Op(":=").Qual(ctx.RuntimeModule(""), "MarshalStructuredCloudEvent").Call(w, j.Id(rn).Dot("CloudEvent"), j.Id("data").Dot("Bytes").Call()),
+						j.Err().Op("!=").Nil(),
+					).Block(j.Return(j.Err()))
+					contentType = j.Qual(ctx.RuntimeModule(""), "CloudEventsContentType")
+				}
+				if contentEncoding != "" {
+					bg.Op(`
+						if err := zw.Close(); err != nil {
+							return err
+						}`)
+				}
+				bg.Op("envelope.SetContentType").Call(contentType)
+
+				// Headers are copied to a new map if extra headers are added, to not modify the message ones
+				extraHeaders := contentEncoding != "" || m.CloudEvents == common.CloudEventsBinary
+				var headers j.Code
+				switch {
+				case m.HeadersTypePromise != nil:
+					headers = j.Qual(ctx.RuntimeModule(""), "Headers").Values(j.DictFunc(func(d j.Dict) {
+						for _, f := range m.HeadersTypePromise.Target().Fields {
+							d[j.Lit(f.Name)] = j.Id(rn).Dot("Headers").Dot(f.Name)
+						}
+					}))
+				case extraHeaders:
+					bg.Id("headers").Op(":=").Make(j.Qual(ctx.RuntimeModule(""), "Headers"), j.Len(j.Id(rn).Dot("Headers")).Op("+").Lit(1))
+					bg.For(j.Id("k, v").Op(":=").Range().Id(rn).Dot("Headers")).Block(j.Id("headers").Index(j.Id("k")).Op("=").Id("v"))
+				default:
+					headers = j.Qual(ctx.RuntimeModule(""), "Headers").Call(j.Id(rn).Dot("Headers"))
 				}
+				if extraHeaders {
+					if headers != nil {
+						bg.Id("headers").Op(":=").Add(headers)
+					}
+					if contentEncoding != "" {
+						bg.Id("headers").Index(j.Qual(ctx.RuntimeModule(""), "ContentEncodingHeader")).Op("=").Lit(contentEncoding)
+					}
+					if m.CloudEvents == common.CloudEventsBinary {
+						bg.For(j.Id("k, v").Op(":=").Range().Id(rn).Dot("CloudEvent").Dot("Headers").Call(j.Lit(CloudEventsHeaderPrefix(protoName)))).
+							Block(j.Id("headers").Index(j.Id("k")).Op("=").Id("v"))
+					}
+					headers = j.Id("headers")
+				}
+				bg.Id("envelope").Dot("SetHeaders").Call(headers)
 				bg.Return(j.Nil())
 			}),
 	}
@@ -174,9 +398,10 @@ func (m Message) renderSubscribeMessageStruct(ctx *common.RenderContext) []*j.St
 
 	var res []*j.Statement
 	res = append(res, j.Func().Id(m.InStruct.NewFuncName()).Params().Op("*").Add(utils.ToCode(m.InStruct.RenderUsage(ctx))...).Block(
-		j.Return(j.Op("&").Add(utils.ToCode(m.InStruct.RenderUsage(ctx))...).Values()),
+		j.Return(j.Op("&").Add(utils.ToCode(m.InStruct.RenderUsage(ctx))...).Values(m.renderFieldDefaults(ctx, m.InStruct))),
 	))
 	res = append(res, m.InStruct.RenderDefinition(ctx)...)
+	res = append(res, m.renderValidateMethod(ctx, m.InStruct)...)
 
 	for _, p := range getServerProtocols(ctx, m.AllServersPromises) {
 		res = append(res, m.renderUnmarshalEnvelopeMethod(ctx, p, ctx.ProtoRenderers[p].ProtocolTitle())...)
@@ -226,6 +451,7 @@ func (m Message) renderUnmarshalEnvelopeMethod(ctx *common.RenderContext, protoN
 
 	rn := m.InStruct.ReceiverName()
 	receiver := j.Id(rn).Op("*").Id(m.InStruct.Name)
+	contentEncoding := m.ProtoContentEncoding(protoName)
 
 	return []*j.Statement{
 		// Method UnmarshalProtoEnvelope(envelope proto.EnvelopeReader) error
@@ -233,16 +459,68 @@ func (m Message) renderUnmarshalEnvelopeMethod(ctx *common.RenderContext, protoN
 			Params(j.Id("envelope").Qual(ctx.RuntimeModule(protoName), "EnvelopeReader")).
 			Error().
 			BlockFunc(func(bg *j.Group) {
-				bg.Op("dec := ").Qual(ctx.GeneratedModule(encodingPackageName), "NewDecoder").Call(
-					j.Lit(m.ContentType),
-					j.Id("envelope"),
-				)
+				r := j.Id("envelope")
+				if schema, ok := m.RegistrySchema(protoName); ok && schema.IDLocation == "payload" {
+					// Schema id written by the sender precedes the payload, it's passed to the envelope to be checked
+					bg.List(j.Id("schemaID"), j.Id("_"), j.Err()).Op(":=").Qual(ctx.RuntimeModule(protoName), "ReadSchemaID").Call(
+						j.Id("envelope"), j.Lit(schema.IDLocation), j.Lit(schema.IDPayloadEncoding), j.Lit(schema.Type),
+					)
+					bg.If(j.Err().Op("!=").Nil()).Block(j.Return(j.Err()))
+					bg.If(
+						j.List(j.Id("sr"), j.Id("ok")).Op(":=").Id("envelope").Assert(j.Qual(ctx.RuntimeModule(protoName), "SchemaEnvelopeReader")),
+						j.Id("ok"),
+					).Block(j.Id("sr").Dot("SetSchemaID").Call(j.Id("schemaID")))
+				}
+				// Content-Encoding header set by sender goes first, the payload may be compressed or not regardless
+				// of the message content encoding
+				if contentEncoding != "" {
+					bg.Add(utils.QualSprintf(`
+						contentEncoding, ok := envelope.Headers().GetString(%Q(%s,ContentEncodingHeader))
+						if !ok {
+							contentEncoding = %q
+						}`, ctx.RuntimeModule(""), contentEncoding))
+				} else {
+					bg.Add(utils.QualSprintf(`
+						contentEncoding, _ := envelope.Headers().GetString(%Q(%s,ContentEncodingHeader))`, ctx.RuntimeModule("")))
+				}
+				bg.Add(utils.QualSprintf(`
+					zr, err := %Q(%s,NewDecompressor)(contentEncoding, envelope)
+					if err != nil {
+						return err
+					}
+					defer zr.Close()`, ctx.GeneratedModule(encodingPackageName)))
+				r = j.Id("zr")
+				switch m.CloudEvents {
+				case common.CloudEventsStructured:
+					bg.List(j.Id("ce"), j.Id("data"), j.Err()).Op(":=").Qual(ctx.RuntimeModule(""), "UnmarshalStructuredCloudEvent").Call(r)
+					bg.If(j.Err().Op("!=").Nil()).Block(j.Return(j.Err()))
+					bg.Id(rn).Dot("CloudEvent").Op("=").Id("ce")
+					r = j.Qual("bytes", "NewReader").Call(j.Id("data"))
+				case common.CloudEventsBinary:
+					args := []j.Code{j.Id("envelope").Dot("Headers").Call(), j.Lit(CloudEventsHeaderPrefix(protoName))}
+					if CloudEventsHeaderPrefix(protoName) == "" && m.HeadersTypePromise != nil {
+						// Message headers are not distinguishable from attributes without a prefix
+						for _, f := range m.HeadersTypePromise.Target().Fields {
+							args = append(args, j.Lit(f.Name))
+						}
+					}
+					bg.List(j.Id("ce"), j.Err()).Op(":=").Qual(ctx.RuntimeModule(""), "CloudEventFromHeaders").Call(args...)
+					bg.If(j.Err().Op("!=").Nil()).Block(j.Return(j.Err()))
+					bg.If(j.Id("ce").Dot("DataContentType").Op("==").Lit("")).Block(
+						j.Id("ce").Dot("DataContentType").Op("=").Lit(m.ContentType),
+					)
+					bg.Id(rn).Dot("CloudEvent").Op("=").Id("ce")
+				}
+				bg.Op("dec := ").Qual(ctx.GeneratedModule(encodingPackageName), "NewDecoder").Call(j.Lit(m.ContentType), r)
 				bg.Op(fmt.Sprintf(`
 					if err := dec.Decode(&%[1]s.Payload); err != nil {
 						return err
 					}`, rn))
 				if m.HeadersTypePromise != nil {
 					if len(m.HeadersTypePromise.Target().Fields) > 0 { // Object defined as empty should not provide code
+						if v := renderDefaultValue(ctx, m.InStruct.MustGetField("Headers").Type, nil); v != nil {
+							bg.Id(rn).Dot("Headers").Op("=").Add(v)
+						}
 						bg.Op("headers := envelope.Headers()")
 						for _, f := range m.HeadersTypePromise.Target().Fields {
 							fType := j.Add(utils.ToCode(f.Type.RenderUsage(ctx))...)
@@ -255,7 +533,11 @@ func (m Message) renderUnmarshalEnvelopeMethod(ctx *common.RenderContext, protoN
 						j.Op("envelope.Headers()"),
 					)
 				}
-				bg.Return(j.Nil())
+				if ctx.RenderOpts.ValidateMessages {
+					bg.Return(j.Id(rn).Dot("Validate").Call())
+				} else {
+					bg.Return(j.Nil())
+				}
 			}),
 	}
 }
@@ -90,6 +90,7 @@ func NewListCbPromise[T any](findCb func(item common.Renderer, path []string) bo
 
 type ListPromise[T any] struct {
 	AssignErrorNote string // Optional error message additional note to be shown when assignment fails
+	InAllDocuments  bool   // Collect the objects from all documents, not only from the one the promise is defined in
 	findCb          func(item common.Renderer, path []string) bool
 
 	targets  []T
@@ -113,6 +114,10 @@ func (r *ListPromise[T]) FindCallback() func(item common.Renderer, path []string
 	return r.findCb
 }
 
+func (r *ListPromise[T]) AllDocuments() bool {
+	return r.InAllDocuments
+}
+
 func (r *ListPromise[T]) Targets() []T {
 	return r.targets
 }
@@ -0,0 +1,255 @@
+package render
+
+import (
+	"github.com/dave/jennifer/jen"
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/diagnostics"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+// hasPropertiesMethods returns true if struct has the properties that are not struct fields (patternProperties,
+// additionalProperties), so it needs the custom marshal methods
+func (s GoStruct) hasPropertiesMethods() bool {
+	return s.DirectRender && (s.NoAdditionalProperties || len(s.extraPropertiesFields()) > 0)
+}
+
+// extraPropertiesFields returns the map fields, that contain the properties by name pattern or additional properties
+func (s GoStruct) extraPropertiesFields() []GoStructField {
+	return lo.Filter(s.Fields, func(item GoStructField, _ int) bool {
+		_, isMap := unwrapPromise(item.Type).(*GoMap)
+		return isMap && (item.KeyPattern != "" || item.Additional)
+	})
+}
+
+// warnUnsupportedPropertiesFormats reports the formats of messages the struct is used in, that don't get the
+// properties from map fields, since only JSON and YAML have the custom marshal methods. E.g. encoding/xml doesn't
+// support maps, so the map fields are not marshaled to XML at all.
+func warnUnsupportedPropertiesFormats(ctx *common.RenderContext, s *GoStruct) {
+	var formats []string
+	for _, f := range s.Fields { // Map fields have no struct tags by format, so the messages are taken from others
+		if f.TagsSource == nil {
+			continue
+		}
+		for _, m := range f.TagsSource.Targets() {
+			format := getFormatByContentType(m.ContentType)
+			if _, ok := formatStructTags[format]; ok && !lo.Contains([]string{"application/json", "application/yaml", avroFormat}, format) {
+				formats = append(formats, format)
+			}
+		}
+	}
+	for _, format := range lo.Uniq(formats) {
+		msg := "Additional and pattern properties are marshaled only to JSON and YAML, ignore them"
+		ctx.Logger.Warn(msg, "type", s.Name, "format", format)
+		diagnostics.Add(diagnostics.SeverityWarning, diagnostics.CodeUnsupportedFeature, nil, "", diagnostics.FormatMessage(msg, "type", s.Name, "format", format))
+	}
+}
+
+// hasUnmarshalMethods returns true if struct needs the custom unmarshal methods, because it has the properties that
+// are not struct fields, the conditional schemas to check or the defaults to set to absent properties
+func (s GoStruct) hasUnmarshalMethods() bool {
+	return s.hasPropertiesMethods() || s.DirectRender && (s.Conditions != nil || s.hasFieldDefaults())
+}
+
+// renderUnmarshalMethods renders the JSON and YAML unmarshal methods, that decode the struct fields, then put the
+// properties that are not struct fields to patternProperties and additionalProperties map fields, and check the data
+// against the conditional schemas
+func renderUnmarshalMethods(ctx *common.RenderContext, s *GoStruct) []*jen.Statement {
+	rn := s.ReceiverName()
+	plainType := jen.Type().Id("plain").Id(s.Name) // Same fields without methods, to avoid recursion
+	returnIfErr := func(call jen.Code) jen.Code {
+		return jen.If(jen.Err().Op(":=").Add(call), jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Err()))
+	}
+	hasProperties := s.hasPropertiesMethods()
+
+	var res []*jen.Statement
+	jsonBody := []jen.Code{
+		plainType,
+		returnIfErr(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Parens(jen.Op("*").Id("plain")).Call(jen.Id(rn)))),
+	}
+	yamlBody := []jen.Code{
+		plainType,
+		returnIfErr(jen.Id("node").Dot("Decode").Call(jen.Parens(jen.Op("*").Id("plain")).Call(jen.Id(rn)))),
+	}
+	if hasProperties {
+		res = append(res,
+			jen.Comment("setExtraProperty decodes the property that is not a struct field to the map field it belongs to"),
+			jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("setExtraProperty").
+				Params(jen.Id("name").String(), jen.Id("decode").Func().Params(jen.Id("target").Any()).Error()).
+				Error().
+				Block(renderSetExtraProperty(ctx, s, rn, s.extraPropertiesFields())...),
+		)
+		jsonBody = append(jsonBody,
+			jen.Var().Id("props").Map(jen.String()).Qual("encoding/json", "RawMessage"),
+			returnIfErr(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Op("&").Id("props"))),
+			jen.For(jen.List(jen.Id("name"), jen.Id("value")).Op(":=").Range().Id("props")).Block(
+				returnIfErr(jen.Id(rn).Dot("setExtraProperty").Call(
+					jen.Id("name"),
+					jen.Func().Params(jen.Id("target").Any()).Error().Block(
+						jen.Return(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("value"), jen.Id("target"))),
+					),
+				)),
+			),
+		)
+		yamlBody = append(yamlBody,
+			jen.For(jen.Id("idx").Op(":=").Lit(0), jen.Id("idx").Op("+").Lit(1).Op("<").Len(jen.Id("node").Dot("Content")), jen.Id("idx").Op("+=").Lit(2)).Block(
+				returnIfErr(jen.Id(rn).Dot("setExtraProperty").Call(
+					jen.Id("node").Dot("Content").Index(jen.Id("idx")).Dot("Value"),
+					jen.Id("node").Dot("Content").Index(jen.Id("idx").Op("+").Lit(1)).Dot("Decode"),
+				)),
+			),
+		)
+	}
+	if s.DirectRender && s.hasFieldDefaults() {
+		res = append(res, renderSetDefaults(ctx, s)...)
+		if !hasProperties {
+			jsonBody = append(jsonBody,
+				jen.Var().Id("props").Map(jen.String()).Qual("encoding/json", "RawMessage"),
+				returnIfErr(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Op("&").Id("props"))),
+			)
+		}
+		jsonBody = append(jsonBody,
+			jen.Id(rn).Dot("setDefaults").Call(jen.Func().Params(jen.Id("name").String()).Bool().Block(
+				jen.List(jen.Id("_"), jen.Id("ok")).Op(":=").Id("props").Index(jen.Id("name")),
+				jen.Return(jen.Id("ok")),
+			)),
+		)
+		yamlBody = append(yamlBody,
+			jen.Id(rn).Dot("setDefaults").Call(jen.Func().Params(jen.Id("name").String()).Bool().Block(
+				jen.For(jen.Id("idx").Op(":=").Lit(0), jen.Id("idx").Op("<").Len(jen.Id("node").Dot("Content")), jen.Id("idx").Op("+=").Lit(2)).Block(
+					jen.If(jen.Id("node").Dot("Content").Index(jen.Id("idx")).Dot("Value").Op("==").Id("name")).Block(jen.Return(jen.True())),
+				),
+				jen.Return(jen.False()),
+			)),
+		)
+	}
+	if s.Conditions != nil {
+		res = append(res, renderConditionsVar(ctx, s)...)
+		checkConditions := returnIfErr(jen.Id(s.conditionsVarName()).Dot("Check").Call(jen.Id("value")))
+		jsonBody = append(jsonBody,
+			jen.Var().Id("value").Any(),
+			returnIfErr(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Op("&").Id("value"))),
+			checkConditions,
+		)
+		yamlBody = append(yamlBody,
+			jen.Var().Id("value").Any(),
+			returnIfErr(jen.Id("node").Dot("Decode").Call(jen.Op("&").Id("value"))),
+			checkConditions,
+		)
+	}
+
+	return append(res,
+		jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("UnmarshalJSON").Params(jen.Id("data").Index().Byte()).Error().Block(
+			append(jsonBody, jen.Return(jen.Nil()))...,
+		),
+		jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("UnmarshalYAML").Params(jen.Id("node").Op("*").Qual("gopkg.in/yaml.v3", "Node")).Error().Block(
+			append(yamlBody, jen.Return(jen.Nil()))...,
+		),
+	)
+}
+
+// renderPropertiesMethods renders the JSON and YAML marshal methods, that put the patternProperties and
+// additionalProperties map fields to the data along with the struct fields
+func renderPropertiesMethods(ctx *common.RenderContext, s *GoStruct) []*jen.Statement {
+	rn := s.ReceiverName()
+	fields := s.extraPropertiesFields()
+	plainType := jen.Type().Id("plain").Id(s.Name) // Same fields without methods, to avoid recursion
+	returnNilIfErr := func(call jen.Code) jen.Code {
+		return jen.If(jen.Err().Op(":=").Add(call), jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err()))
+	}
+
+	if len(fields) == 0 {
+		return nil // Nothing to marshal besides the struct fields
+	}
+
+	return []*jen.Statement{
+		jen.Comment("extraProperties returns the properties that are not struct fields. Returns error if the property name"),
+		jen.Comment("doesn't match the pattern of patternProperties it's set to"),
+		jen.Func().Params(jen.Id(rn).Id(s.Name)).Id("extraProperties").Params().Params(jen.Map(jen.String()).Any(), jen.Error()).Block(
+			jen.Id("res").Op(":=").Make(jen.Map(jen.String()).Any()),
+			jen.CustomFunc(jen.Options{Separator: "\n"}, func(g *jen.Group) {
+				for _, f := range fields {
+					var check jen.Code = jen.Null()
+					if f.KeyPattern != "" {
+						check = jen.If(jen.Op("!").Qual(ctx.RuntimeModule(""), "MatchPattern").Call(jen.Lit(f.KeyPattern), jen.Id("name"))).Block(
+							jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit("property %q: name does not match pattern %q"), jen.Id("name"), jen.Lit(f.KeyPattern))),
+						)
+					}
+					g.For(jen.List(jen.Id("name"), jen.Id("value")).Op(":=").Range().Id(rn).Dot(f.Name)).Block(
+						check,
+						jen.Id("res").Index(jen.Id("name")).Op("=").Id("value"),
+					)
+				}
+			}),
+			jen.Return(jen.Id("res"), jen.Nil()),
+		),
+
+		jen.Func().Params(jen.Id(rn).Id(s.Name)).Id("MarshalJSON").Params().Params(jen.Index().Byte(), jen.Error()).Block(
+			plainType,
+			jen.List(jen.Id("data"), jen.Err()).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id("plain").Call(jen.Id(rn))),
+			jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err())),
+			jen.List(jen.Id("extra"), jen.Err()).Op(":=").Id(rn).Dot("extraProperties").Call(),
+			jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err())),
+			jen.Return(jen.Qual(ctx.RuntimeModule(""), "AppendJSONProperties").Call(jen.Id("data"), jen.Id("extra"))),
+		),
+
+		jen.Func().Params(jen.Id(rn).Id(s.Name)).Id("MarshalYAML").Params().Params(jen.Any(), jen.Error()).Block(
+			plainType,
+			jen.Var().Id("node").Qual("gopkg.in/yaml.v3", "Node"),
+			returnNilIfErr(jen.Id("node").Dot("Encode").Call(jen.Id("plain").Call(jen.Id(rn)))),
+			jen.List(jen.Id("extra"), jen.Err()).Op(":=").Id(rn).Dot("extraProperties").Call(),
+			jen.If(jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Nil(), jen.Err())),
+			jen.For(jen.List(jen.Id("_"), jen.Id("name")).Op(":=").Range().Qual(ctx.RuntimeModule(""), "SortedKeys").Call(jen.Id("extra"))).Block(
+				jen.Var().List(jen.Id("keyNode"), jen.Id("valueNode")).Qual("gopkg.in/yaml.v3", "Node"),
+				jen.Id("keyNode").Dot("SetString").Call(jen.Id("name")),
+				returnNilIfErr(jen.Id("valueNode").Dot("Encode").Call(jen.Id("extra").Index(jen.Id("name")))),
+				jen.Id("node").Dot("Content").Op("=").Append(jen.Id("node").Dot("Content"), jen.Op("&").Id("keyNode"), jen.Op("&").Id("valueNode")),
+			),
+			jen.Return(jen.Op("&").Id("node"), jen.Nil()),
+		),
+	}
+}
+
+// renderSetExtraProperty renders the body of setExtraProperty method. Property names are checked against the struct
+// fields, then against patternProperties regexes in schema order, the rest are additional properties.
+func renderSetExtraProperty(ctx *common.RenderContext, s *GoStruct, rn string, fields []GoStructField) []jen.Code {
+	var res []jen.Code
+	known := lo.Uniq(lo.FilterMap(s.Fields, func(item GoStructField, _ int) (string, bool) {
+		return item.MarshalName, item.MarshalName != ""
+	}))
+	if len(known) > 0 {
+		res = append(res, jen.Switch(jen.Id("name")).Block(
+			jen.Case(lo.Map(known, func(item string, _ int) jen.Code { return jen.Lit(item) })...).Block(
+				jen.Return(jen.Nil()).Comment("Struct field"),
+			),
+		))
+	}
+
+	setField := func(f GoStructField) []jen.Code {
+		m := unwrapPromise(f.Type).(*GoMap)
+		return []jen.Code{
+			jen.Var().Id("value").Add(utils.ToCode(m.ValueType.RenderUsage(ctx))...),
+			jen.If(jen.Err().Op(":=").Id("decode").Call(jen.Op("&").Id("value")), jen.Err().Op("!=").Nil()).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("property %q: %w"), jen.Id("name"), jen.Err())),
+			),
+			jen.If(jen.Id(rn).Dot(f.Name).Op("==").Nil()).Block(
+				jen.Id(rn).Dot(f.Name).Op("=").Make(jen.Add(utils.ToCode(m.RenderUsage(ctx))...)),
+			),
+			jen.Id(rn).Dot(f.Name).Index(jen.Id("name")).Op("=").Id("value"),
+			jen.Return(jen.Nil()),
+		}
+	}
+	for _, f := range fields {
+		if f.KeyPattern != "" {
+			res = append(res, jen.If(jen.Qual(ctx.RuntimeModule(""), "MatchPattern").Call(jen.Lit(f.KeyPattern), jen.Id("name"))).Block(setField(f)...))
+		}
+	}
+	if f, ok := lo.Find(fields, func(item GoStructField) bool { return item.Additional }); ok {
+		return append(res, setField(f)...)
+	}
+	if s.NoAdditionalProperties {
+		return append(res, jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("unknown property %q"), jen.Id("name"))))
+	}
+	return append(res, jen.Return(jen.Nil()))
+}
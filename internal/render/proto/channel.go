@@ -10,6 +10,8 @@ import (
 	"github.com/samber/lo"
 )
 
+const encodingPackageName = "encoding"
+
 type BaseProtoChannel struct {
 	Parent          *render.Channel
 	GolangNameProto string // Channel GolangName name concatenated with protocol name, e.g. Channel1Kafka
@@ -22,11 +24,44 @@ type BaseProtoChannel struct {
 func (pc BaseProtoChannel) RenderCommonSubscriberMethods(ctx *common.RenderContext) []*j.Statement {
 	ctx.Logger.Trace("RenderCommonSubscriberMethods", "proto", pc.ProtoName)
 
+	rn := pc.Struct.ReceiverName()
+	receiver := j.Id(rn).Id(pc.Struct.Name)
+
+	var res []*j.Statement
+	if messages := pc.Parent.SubMessages(); len(messages) > 1 {
+		res = append(res, pc.renderExtractEnvelopeUnion(ctx, messages)...)
+	} else {
+		res = append(res, pc.renderExtractEnvelope(ctx, messages)...)
+	}
+
+	return append(res,
+		// Method Subscriber() proto.Subscriber
+		j.Func().Params(receiver.Clone()).Id("Subscriber").
+			Params().
+			Qual(ctx.RuntimeModule(pc.ProtoName), "Subscriber").
+			Block(
+				j.Return(j.Id(rn).Dot("subscriber")),
+			),
+
+		// Method Subscribe(ctx context.Context, cb func(envelope proto.EnvelopeReader)) error
+		j.Func().Params(receiver.Clone()).Id("Subscribe").
+			Params(
+				j.Id("ctx").Qual("context", "Context"),
+				j.Id("cb").Func().Params(j.Id("envelope").Qual(ctx.RuntimeModule(pc.ProtoName), "EnvelopeReader")), // FIXME: *any on fallback variant
+			).
+			Error().
+			Block(
+				j.Return(j.Id(rn).Dot("subscriber.Receive(ctx, cb)")),
+			),
+	)
+}
+
+func (pc BaseProtoChannel) renderExtractEnvelope(ctx *common.RenderContext, messages []*render.Message) []*j.Statement {
 	rn := pc.Struct.ReceiverName()
 	receiver := j.Id(rn).Id(pc.Struct.Name)
 	var msgTyp common.GolangType = render.GoPointer{Type: pc.Parent.FallbackMessageType, DirectRender: true}
-	if pc.Parent.SubMessagePromise != nil {
-		msgTyp = render.GoPointer{Type: pc.Parent.SubMessagePromise.Target().InStruct, DirectRender: true}
+	if len(messages) > 0 {
+		msgTyp = render.GoPointer{Type: messages[0].InStruct, DirectRender: true}
 	}
 
 	return []*j.Statement{
@@ -38,7 +73,7 @@ func (pc BaseProtoChannel) RenderCommonSubscriberMethods(ctx *common.RenderConte
 			).
 			Error().
 			BlockFunc(func(bg *j.Group) {
-				if pc.Parent.SubMessagePromise == nil {
+				if len(messages) == 0 {
 					bg.Empty().Add(utils.QualSprintf(`
 						enc := %Q(encoding/json,NewDecoder)(envelope)
 						if err := enc.Decode(message); err != nil {
@@ -48,25 +83,239 @@ func (pc BaseProtoChannel) RenderCommonSubscriberMethods(ctx *common.RenderConte
 					bg.Op(fmt.Sprintf(`return message.Unmarshal%sEnvelope(envelope)`, pc.ProtoTitle))
 				}
 			}),
+	}
+}
 
-		// Method Subscriber() proto.Subscriber
-		j.Func().Params(receiver.Clone()).Id("Subscriber").
-			Params().
-			Qual(ctx.RuntimeModule(pc.ProtoName), "Subscriber").
-			Block(
-				j.Return(j.Id(rn).Dot("subscriber")),
-			),
+// renderExtractEnvelopeUnion renders the ExtractEnvelope method for channel that receives several messages. The
+// message type is determined by the message id header, then by the payload discriminator, then by the content type.
+func (pc BaseProtoChannel) renderExtractEnvelopeUnion(ctx *common.RenderContext, messages []*render.Message) []*j.Statement {
+	ctx.Logger.Trace("renderExtractEnvelopeUnion", "proto", pc.ProtoName)
 
-		// Method Subscribe(ctx context.Context, cb func(envelope proto.EnvelopeReader)) error
-		j.Func().Params(receiver.Clone()).Id("Subscribe").
-			Params(
-				j.Id("ctx").Qual("context", "Context"),
-				j.Id("cb").Func().Params(j.Id("envelope").Qual(ctx.RuntimeModule(pc.ProtoName), "EnvelopeReader")), // FIXME: *any on fallback variant
-			).
-			Error().
-			Block(
-				j.Return(j.Id(rn).Dot("subscriber.Receive(ctx, cb)")),
+	rn := pc.Struct.ReceiverName()
+	receiver := j.Id(rn).Id(pc.Struct.Name)
+	unionType := utils.ToCode(pc.Parent.SubMessageUnion.RenderUsage(ctx))
+	bufferedName := utils.ToLowerFirstLetter(pc.GolangNameProto) + "BufferedEnvelope"
+	newMessage := func(m *render.Message) *j.Statement {
+		return j.Id("message").Op("=").Op("&").Add(utils.ToCode(m.InStruct.RenderUsage(ctx))...).Values()
+	}
+
+	discriminated := lo.Filter(messages, func(item *render.Message, _ int) bool {
+		_, _, ok := item.PayloadDiscriminator()
+		return ok
+	})
+	// Structured CloudEvents are detected by the event type instead, the payload is wrapped in the event
+	structured := messages[0].CloudEvents == common.CloudEventsStructured
+	if structured {
+		discriminated = nil
+	}
+	// Messages which content type is unique among all messages
+	contentTyped := lo.Filter(messages, func(item *render.Message, _ int) bool {
+		return lo.CountBy(messages, func(m *render.Message) bool { return m.ContentType == item.ContentType }) == 1
+	})
+
+	decodeName := utils.ToLowerFirstLetter(pc.GolangNameProto) + "DiscriminatorPayload"
+	// Schema id prefix is supported only by Kafka
+	withSchemaID := lo.SomeBy(discriminated, func(item *render.Message) bool {
+		return pc.discriminatorPayloadArgs(item).schemaIDLocation != ""
+	})
+
+	var res []*j.Statement
+	if len(discriminated) > 0 {
+		res = append(res, pc.renderDiscriminatorPayload(ctx, decodeName, withSchemaID)...)
+	}
+	if len(discriminated) > 0 || structured {
+		res = append(res,
+			j.Comment(bufferedName+" reads the payload already read from the original envelope"),
+			j.Type().Id(bufferedName).Struct(
+				j.Qual(ctx.RuntimeModule(pc.ProtoName), "EnvelopeReader"),
+				j.Id("payload").Qual("io", "Reader"),
 			),
+			j.Func().Params(j.Id("e").Id(bufferedName)).Id("Read").
+				Params(j.Id("p").Index().Byte()).
+				Params(j.Int(), j.Error()).
+				Block(j.Return(j.Id("e").Dot("payload").Dot("Read").Call(j.Id("p")))),
+		)
+		// The schema id read by message goes to the original envelope
+		if lo.SomeBy(messages, func(item *render.Message) bool {
+			schema, ok := item.RegistrySchema(pc.ProtoName)
+			return ok && schema.IDLocation == "payload"
+		}) {
+			res = append(res, j.Func().Params(j.Id("e").Id(bufferedName)).Id("SetSchemaID").
+				Params(j.Id("id").Int64()).
+				Block(
+					j.If(
+						j.List(j.Id("sr"), j.Id("ok")).Op(":=").Id("e").Dot("EnvelopeReader").Assert(j.Qual(ctx.RuntimeModule(pc.ProtoName), "SchemaEnvelopeReader")),
+						j.Id("ok"),
+					).Block(j.Id("sr").Dot("SetSchemaID").Call(j.Id("id"))),
+				))
+		}
+	}
+
+	return append(res,
+		// Method ExtractEnvelope(envelope proto.EnvelopeReader) (Channel1SubMessage, error)
+		j.Func().Params(receiver.Clone()).Id("ExtractEnvelope").
+			Params(j.Id("envelope").Qual(ctx.RuntimeModule(pc.ProtoName), "EnvelopeReader")).
+			Params(j.Add(unionType...), j.Error()).
+			BlockFunc(func(bg *j.Group) {
+				bg.Var().Id("message").Add(unionType...)
+				bg.Op("headers := envelope.Headers()")
+				bg.Op("messageID, _ := headers.GetString").Call(j.Qual(ctx.RuntimeModule(""), "MessageIDHeader"))
+				bg.Switch(j.Id("messageID")).BlockFunc(func(g *j.Group) {
+					for _, m := range lo.UniqBy(messages, func(item *render.Message) string { return item.MessageID }) {
+						g.Case(j.Lit(m.MessageID)).Block(newMessage(m))
+					}
+				})
+				// The event type is message id by default
+				eventTypeSwitch := func(eventType j.Code) *j.Statement {
+					return j.Switch(eventType).BlockFunc(func(g *j.Group) {
+						for _, m := range lo.UniqBy(messages, func(item *render.Message) string { return item.MessageID }) {
+							g.Case(j.Lit(m.MessageID)).Block(newMessage(m))
+						}
+					})
+				}
+				switch messages[0].CloudEvents {
+				case common.CloudEventsBinary:
+					bg.If(j.Id("message").Op("==").Nil()).Block(
+						j.Id("eventType, _").Op(":=").Id("headers").Dot("GetString").Call(
+							j.Lit(render.CloudEventsHeaderPrefix(pc.ProtoName)+"type"),
+						),
+						eventTypeSwitch(j.Id("eventType")),
+					)
+				case common.CloudEventsStructured:
+					bg.If(j.Id("message").Op("==").Nil()).BlockFunc(func(g *j.Group) {
+						g.Add(utils.QualSprintf(`
+							buf, err := %Q(io,ReadAll)(envelope)
+							if err != nil {
+								return nil, err
+							}`))
+						g.Id("envelope").Op("=").Id(bufferedName).Values(j.Dict{
+							j.Id("EnvelopeReader"): j.Id("envelope"),
+							j.Id("payload"):        j.Qual("bytes", "NewReader").Call(j.Id("buf")),
+						})
+						g.If(
+							j.List(j.Id("ce"), j.Id("_"), j.Err()).Op(":=").Qual(ctx.RuntimeModule(""), "UnmarshalStructuredCloudEvent").Call(
+								j.Qual("bytes", "NewReader").Call(j.Id("buf")),
+							),
+							j.Err().Op("==").Nil(),
+						).Block(eventTypeSwitch(j.Id("ce").Dot("Type")))
+					})
+				}
+				if len(discriminated) > 0 {
+					bg.If(j.Id("message").Op("==").Nil()).BlockFunc(func(g *j.Group) {
+						g.Add(utils.QualSprintf(`
+							buf, err := %Q(io,ReadAll)(envelope)
+							if err != nil {
+								return nil, err
+							}`))
+						g.Id("envelope").Op("=").Id(bufferedName).Values(j.Dict{
+							j.Id("EnvelopeReader"): j.Id("envelope"),
+							j.Id("payload"):        j.Qual("bytes", "NewReader").Call(j.Id("buf")),
+						})
+						// Decode the payload the same way as the message does, then check the discriminator value
+						groups := lo.GroupBy(discriminated, func(item *render.Message) discriminatorPayloadArgs {
+							return pc.discriminatorPayloadArgs(item)
+						})
+						keys := lo.Uniq(lo.Map(discriminated, func(item *render.Message, _ int) discriminatorPayloadArgs {
+							return pc.discriminatorPayloadArgs(item)
+						}))
+						for i, k := range keys {
+							args := []j.Code{j.Id("buf"), j.Id("headers")}
+							if withSchemaID {
+								args = append(args, j.Lit(k.schemaIDLocation), j.Lit(k.schemaIDEncoding), j.Lit(k.schemaType))
+							}
+							args = append(args, j.Lit(k.contentEncoding), j.Lit(k.contentType))
+							cond := j.Err().Op("==").Nil()
+							if i > 0 {
+								cond = j.Id("message").Op("==").Nil().Op("&&").Add(cond)
+							}
+							g.If(j.List(j.Id("payload"), j.Err()).Op(":=").Id(decodeName).Call(args...), cond).
+								Block(j.Switch().BlockFunc(func(sg *j.Group) {
+									for _, m := range groups[k] {
+										field, value, _ := m.PayloadDiscriminator()
+										sg.Case(j.Id("payload").Index(j.Lit(field)).Op("==").Lit(value)).Block(newMessage(m))
+									}
+								}))
+						}
+					})
+				}
+				if len(contentTyped) > 0 {
+					bg.If(j.Id("message").Op("==").Nil()).Block(
+						j.Op(`contentType, _ := headers.GetString("Content-Type")`),
+						j.Switch(j.Id("contentType")).BlockFunc(func(g *j.Group) {
+							for _, m := range contentTyped {
+								g.Case(j.Lit(m.ContentType)).Block(newMessage(m))
+							}
+						}),
+					)
+				}
+				bg.If(j.Id("message").Op("==").Nil()).Block(
+					j.Return(j.Nil(), j.Qual("fmt", "Errorf").Call(
+						j.Lit("%w: cannot determine the message type, message id %q"),
+						j.Qual(ctx.RuntimeModule(""), "ErrUnknownMessage"),
+						j.Id("messageID"),
+					)),
+				)
+				bg.If(
+					j.Err().Op(":=").Id("message").Dot("Unmarshal"+pc.ProtoTitle+"Envelope").Call(j.Id("envelope")),
+					j.Err().Op("!=").Nil(),
+				).Block(j.Return(j.Nil(), j.Err()))
+				bg.Return(j.Id("message"), j.Nil())
+			}),
+	)
+}
+
+// discriminatorPayloadArgs are the arguments of the function that decodes the payload to check the discriminator
+type discriminatorPayloadArgs struct {
+	schemaIDLocation, schemaIDEncoding, schemaType string
+	contentEncoding, contentType                   string
+}
+
+func (pc BaseProtoChannel) discriminatorPayloadArgs(m *render.Message) discriminatorPayloadArgs {
+	res := discriminatorPayloadArgs{contentEncoding: m.ProtoContentEncoding(pc.ProtoName), contentType: m.ContentType}
+	if schema, ok := m.RegistrySchema(pc.ProtoName); ok && schema.IDLocation == "payload" {
+		res.schemaIDLocation, res.schemaIDEncoding, res.schemaType = schema.IDLocation, schema.IDPayloadEncoding, schema.Type
+	}
+	return res
+}
+
+// renderDiscriminatorPayload renders the function that decodes the payload to a map, so that the discriminator value
+// can be checked. The schema id is skipped and the payload is decompressed, like on unmarshaling the message.
+func (pc BaseProtoChannel) renderDiscriminatorPayload(ctx *common.RenderContext, name string, withSchemaID bool) []*j.Statement {
+	return []*j.Statement{
+		j.Comment(name + " decodes the payload to find the discriminator value"),
+		j.Func().Id(name).
+			ParamsFunc(func(g *j.Group) {
+				g.Id("buf").Index().Byte()
+				g.Id("headers").Qual(ctx.RuntimeModule(""), "Headers")
+				if withSchemaID {
+					g.List(j.Id("schemaIDLocation"), j.Id("schemaIDEncoding"), j.Id("schemaType")).String()
+				}
+				g.List(j.Id("contentEncoding"), j.Id("contentType")).String()
+			}).
+			Params(j.Map(j.String()).Any(), j.Error()).
+			BlockFunc(func(bg *j.Group) {
+				bg.Id("r").Op(":=").Qual("bytes", "NewReader").Call(j.Id("buf"))
+				if withSchemaID {
+					bg.If(
+						j.List(j.Id("_"), j.Id("_"), j.Err()).Op(":=").Qual(ctx.RuntimeModule(pc.ProtoName), "ReadSchemaID").Call(
+							j.Id("r"), j.Id("schemaIDLocation"), j.Id("schemaIDEncoding"), j.Id("schemaType"),
+						),
+						j.Err().Op("!=").Nil(),
+					).Block(j.Return(j.Nil(), j.Err()))
+				}
+				bg.Add(utils.QualSprintf(`if v, ok := headers.GetString(%Q(%s,ContentEncodingHeader)); ok {
+						contentEncoding = v
+					}
+					zr, err := %Q(%s,NewDecompressor)(contentEncoding, r)
+					if err != nil {
+						return nil, err
+					}
+					defer zr.Close()
+					payload := make(map[string]any)
+					err = %Q(%s,NewDecoder)(contentType, zr).Decode(&payload)
+					return payload, err`,
+					ctx.RuntimeModule(""), ctx.GeneratedModule(encodingPackageName), ctx.GeneratedModule(encodingPackageName)))
+			}),
 	}
 }
 
@@ -76,7 +325,12 @@ func (pc BaseProtoChannel) RenderCommonPublisherMethods(ctx *common.RenderContex
 	rn := pc.Struct.ReceiverName()
 	receiver := j.Id(rn).Id(pc.Struct.Name)
 
-	return []*j.Statement{
+	var res []*j.Statement
+	if len(pc.Parent.PubMessages()) > 1 {
+		res = append(res, pc.renderMessageIDEnvelope(ctx)...)
+	}
+
+	return append(res,
 		// Method Publisher() proto.Publisher
 		j.Func().Params(receiver.Clone()).Id("Publisher").
 			Params().
@@ -95,6 +349,113 @@ func (pc BaseProtoChannel) RenderCommonPublisherMethods(ctx *common.RenderContex
 			Block(
 				j.Return(j.Id(rn).Dot("publisher.Send(ctx, envelopes...)")),
 			),
+	)
+}
+
+// PubMessageType returns the type of message SealEnvelope method accepts
+func (pc BaseProtoChannel) PubMessageType() common.GolangType {
+	messages := pc.Parent.PubMessages()
+	switch len(messages) {
+	case 0:
+		return render.GoPointer{Type: pc.Parent.FallbackMessageType, DirectRender: true}
+	case 1:
+		return render.GoPointer{Type: messages[0].OutStruct, DirectRender: true}
+	}
+	return pc.Parent.PubMessageUnion
+}
+
+// RenderMarshalMessage renders the SealEnvelope method code that writes a message to the envelope
+func (pc BaseProtoChannel) RenderMarshalMessage(ctx *common.RenderContext, bg *j.Group) {
+	messages := pc.Parent.PubMessages()
+	switch len(messages) {
+	case 0: // No Message set for Channel in spec
+		bg.Empty().Add(utils.QualSprintf(`
+			enc := %Q(encoding/json,NewEncoder)(envelope)
+			if err := enc.Encode(message); err != nil {
+				return err
+			}`))
+	case 1: // Message is set for Channel in spec
+		pc.renderMarshalMessage(ctx, bg, messages[0], j.Id("message"), j.Id("envelope"))
+	default: // Several messages are set for Channel in spec, pick one by type and mark it with message id
+		bg.Switch(j.Id("m").Op(":=").Id("message").Assert(j.Type())).BlockFunc(func(g *j.Group) {
+			for _, m := range messages {
+				envelope := j.Id(pc.messageIDEnvelopeName()).Values(j.Dict{
+					j.Id("EnvelopeWriter"): j.Id("envelope"),
+					j.Id("messageID"):      j.Lit(m.MessageID),
+				})
+				g.Case(j.Op("*").Add(utils.ToCode(m.OutStruct.RenderUsage(ctx))...)).BlockFunc(func(cg *j.Group) {
+					pc.renderMarshalMessage(ctx, cg, m, j.Id("m"), envelope)
+				})
+			}
+			g.Default().Block(j.Return(j.Qual("fmt", "Errorf").Call(
+				j.Lit("%w: %T"), j.Qual(ctx.RuntimeModule(""), "ErrUnknownMessage"), j.Id("message"),
+			)))
+		})
+	}
+}
+
+func (pc BaseProtoChannel) renderMarshalMessage(ctx *common.RenderContext, bg *j.Group, msg *render.Message, message, envelope *j.Statement) {
+	bg.If(
+		j.Err().Op(":=").Add(message).Dot("Marshal"+pc.ProtoTitle+"Envelope").Call(envelope),
+		j.Err().Op("!=").Nil(),
+	).Block(j.Return(j.Err()))
+	// Message SetBindings
+	if msg.HasProtoBindings(pc.ProtoName) {
+		bg.Op("envelope.SetBindings").Call(
+			j.Add(utils.ToCode(msg.BindingsStruct.RenderUsage(ctx))...).Values().Dot(pc.ProtoTitle).Call(),
+		)
+	}
+	// Payload schema to register in schema registry, the implementation writes its id to the envelope
+	if schema, ok := msg.RegistrySchema(pc.ProtoName); ok {
+		bg.If(
+			j.Id("sw, ok").Op(":=").Id("envelope").Assert(j.Qual(ctx.RuntimeModule(pc.ProtoName), "SchemaEnvelopeWriter")),
+			j.Id("ok"),
+		).Block(
+			j.Id("sw").Dot("SetSchema").Call(j.Qual(ctx.RuntimeModule(pc.ProtoName), "Schema").Values(j.DictFunc(func(d j.Dict) {
+				d[j.Id("Type")] = j.Lit(schema.Type)
+				if schema.Schema != "" {
+					d[j.Id("Schema")] = j.Lit(schema.Schema)
+				}
+				if schema.RecordName != "" {
+					d[j.Id("RecordName")] = j.Lit(schema.RecordName)
+				}
+				if len(schema.MessageIndexes) > 0 {
+					d[j.Id("MessageIndexes")] = j.Index().Int().ValuesFunc(func(g *j.Group) {
+						for _, idx := range schema.MessageIndexes {
+							g.Lit(idx)
+						}
+					})
+				}
+			}))),
+		)
+	}
+}
+
+func (pc BaseProtoChannel) messageIDEnvelopeName() string {
+	return utils.ToLowerFirstLetter(pc.GolangNameProto) + "MessageIDEnvelope"
+}
+
+// renderMessageIDEnvelope renders the envelope wrapper that adds the message id header to message headers
+func (pc BaseProtoChannel) renderMessageIDEnvelope(ctx *common.RenderContext) []*j.Statement {
+	name := pc.messageIDEnvelopeName()
+	return []*j.Statement{
+		j.Comment(name + " adds the message id header to the headers being set"),
+		j.Type().Id(name).Struct(
+			j.Qual(ctx.RuntimeModule(pc.ProtoName), "EnvelopeWriter"),
+			j.Id("messageID").String(),
+		),
+		j.Func().Params(j.Id("e").Id(name)).Id("SetHeaders").
+			Params(j.Id("headers").Qual(ctx.RuntimeModule(""), "Headers")).
+			Block(
+				j.Id("h").Op(":=").Qual(ctx.RuntimeModule(""), "Headers").Values(j.Dict{
+					j.Qual(ctx.RuntimeModule(""), "MessageIDHeader"): j.Id("e").Dot("messageID"),
+				}),
+				j.Op(`
+					for k, v := range headers {
+						h[k] = v
+					}
+					e.EnvelopeWriter.SetHeaders(h)`),
+			),
 	}
 }
 
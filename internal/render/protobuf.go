@@ -0,0 +1,405 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	j "github.com/dave/jennifer/jen"
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/protobuf"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+const protobufFormat = "protobuf"
+
+// Kinds of protobuf fields, that are not scalar types
+const (
+	ProtobufKindEnum    = "enum"
+	ProtobufKindMessage = "message"
+)
+
+// ProtobufMessage renders the methods to marshal and unmarshal a struct in protobuf wire format.
+// See https://protobuf.dev/programming-guides/encoding/
+type ProtobufMessage struct {
+	Struct *GoStruct
+	Fields []ProtobufField
+
+	FullName       string // Message name with package, e.g. "shop.Order.Item"
+	MessageIndexes []int  // Message indexes in file, e.g. [1, 0] is the first nested message of the second one
+	FileSchema     string // Contents of the .proto file the message is defined in
+}
+
+// ProtobufField describes how a struct field is encoded in protobuf message
+type ProtobufField struct {
+	FieldName string // Go struct field name
+	Number    int
+	Kind      string            // Scalar type name, "enum" or "message"
+	Type      common.GolangType // Element type for enum and message kinds
+	Repeated  bool
+	Presence  bool   // Singular field is a pointer (or nil-able bytes), the zero value is also encoded if set
+	Oneof     string // Oneof name the field belongs to
+
+	MapKey   *ProtobufField // Set for map fields
+	MapValue *ProtobufField
+}
+
+func (p ProtobufMessage) DirectRendering() bool {
+	return true
+}
+
+func (p ProtobufMessage) RenderDefinition(ctx *common.RenderContext) []*j.Statement {
+	ctx.LogStartRender("ProtobufMessage", "", p.Struct.Name, "definition", p.DirectRendering())
+	defer ctx.LogFinishRender()
+
+	g := protobufCodeGen{pkg: ctx.RuntimeModule("protobuf"), rn: p.Struct.ReceiverName()}
+	fields := append([]ProtobufField{}, p.Fields...)
+	sort.SliceStable(fields, func(i, k int) bool { return fields[i].Number < fields[k].Number })
+	name := p.Struct.Name
+
+	var appendBody, unmarshalCases strings.Builder
+	for _, f := range fields {
+		appendBody.WriteString(g.encodeField(f))
+		unmarshalCases.WriteString(fmt.Sprintf("case %d:\n", f.Number))
+		unmarshalCases.WriteString(g.decodeField(f, fields))
+	}
+
+	return []*j.Statement{
+		j.Add(utils.QualSprintf(`
+			// MarshalProtobuf returns %[1]s encoded in protobuf wire format
+			func (%[2]s %[1]s) MarshalProtobuf() ([]byte, error) {
+				return %[2]s.AppendProtobuf(nil), nil
+			}`, name, g.rn)),
+		j.Add(utils.QualSprintf("%s", fmt.Sprintf(`
+			// AppendProtobuf appends %[1]s encoded in protobuf wire format to buf
+			func (%[3]s %[1]s) AppendProtobuf(buf []byte) []byte {
+				%[2]s
+				return buf
+			}`, name, appendBody.String(), g.rn))),
+		j.Add(utils.QualSprintf("%s", fmt.Sprintf(`
+			// UnmarshalProtobuf decodes %[1]s from protobuf wire format
+			func (%[5]s *%[1]s) UnmarshalProtobuf(data []byte) error {
+				*%[5]s = %[1]s{}
+				rd := %[2]s(data)
+				for {
+					num, typ, err := rd.Next()
+					if err == %[3]s {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+					switch num {
+					%[4]s
+					default:
+						if err = rd.Skip(typ); err != nil {
+							return err
+						}
+					}
+				}
+			}`, name, g.q("NewReader"), "%Q(io,EOF)", unmarshalCases.String(), g.rn))),
+	}
+}
+
+func (p ProtobufMessage) RenderUsage(_ *common.RenderContext) []*j.Statement {
+	panic("not implemented")
+}
+
+func (p ProtobufMessage) ID() string {
+	return p.Struct.Name
+}
+
+func (p ProtobufMessage) String() string {
+	return "ProtobufMessage " + p.Struct.Name
+}
+
+// ProtobufEnum renders the constants for protobuf enum values
+type ProtobufEnum struct {
+	Type   *GoTypeAlias
+	Values []ProtobufEnumValue
+}
+
+type ProtobufEnumValue struct {
+	Name      string // Go constant name
+	ProtoName string // Value name in .proto file, e.g. "STATUS_PAID"
+	Number    int
+}
+
+func (p ProtobufEnum) DirectRendering() bool {
+	return true
+}
+
+func (p ProtobufEnum) RenderDefinition(ctx *common.RenderContext) []*j.Statement {
+	ctx.LogStartRender("ProtobufEnum", "", p.Type.Name, "definition", p.DirectRendering())
+	defer ctx.LogFinishRender()
+
+	rn := strings.ToLower(p.Type.Name[:1])
+	// Aliases (allow_alias option) have the same number, the first value is used
+	values := lo.UniqBy(p.Values, func(item ProtobufEnumValue) int { return item.Number })
+	return []*j.Statement{
+		j.Const().DefsFunc(func(g *j.Group) {
+			for _, v := range p.Values {
+				g.Id(v.Name).Id(p.Type.Name).Op("=").Lit(v.Number)
+			}
+		}),
+		j.Comment("IsValid returns true if the value is one of the " + p.Type.Name + " constants"),
+		j.Func().Params(j.Id(rn).Id(p.Type.Name)).Id("IsValid").Params().Bool().Block(
+			j.Switch(j.Id(rn)).Block(j.Case(lo.Map(values, func(item ProtobufEnumValue, _ int) j.Code {
+				return j.Id(item.Name)
+			})...).Block(j.Return(j.True()))),
+			j.Return(j.False()),
+		),
+		j.Comment("String returns the value name as in .proto file, or the number if the value is unknown"),
+		j.Func().Params(j.Id(rn).Id(p.Type.Name)).Id("String").Params().String().Block(
+			j.Switch(j.Id(rn)).BlockFunc(func(g *j.Group) {
+				for _, v := range values {
+					g.Case(j.Id(v.Name)).Block(j.Return(j.Lit(v.ProtoName)))
+				}
+			}),
+			j.Return(j.Qual("strconv", "FormatInt").Call(j.Int64().Call(j.Id(rn)), j.Lit(10))),
+		),
+	}
+}
+
+func (p ProtobufEnum) RenderUsage(_ *common.RenderContext) []*j.Statement {
+	panic("not implemented")
+}
+
+func (p ProtobufEnum) ID() string {
+	return p.Type.Name
+}
+
+func (p ProtobufEnum) String() string {
+	return "ProtobufEnum " + p.Type.Name
+}
+
+// protobufCodeGen produces the Go code text of encoding and decoding the protobuf fields. Qualified identifiers are
+// written as %Q(...) expressions, see utils.QualSprintf. The local variables are named with two letters or more, not
+// to shadow the receiver.
+type protobufCodeGen struct {
+	pkg string // Runtime protobuf package
+	rn  string // Receiver name
+}
+
+func (g protobufCodeGen) q(name string) string {
+	return "%Q(" + g.pkg + "," + name + ")"
+}
+
+func (g protobufCodeGen) goType(f *ProtobufField) string {
+	if t, ok := protobuf.ScalarGoType(f.Kind); ok {
+		return t
+	}
+	return f.Type.TypeName()
+}
+
+func (g protobufCodeGen) wireType(kind string) string {
+	switch kind {
+	case "double", "fixed64", "sfixed64":
+		return g.q("Fixed64Type")
+	case "float", "fixed32", "sfixed32":
+		return g.q("Fixed32Type")
+	case "string", "bytes", ProtobufKindMessage:
+		return g.q("BytesType")
+	}
+	return g.q("VarintType")
+}
+
+// isPackable returns true if repeated values of a kind are encoded as packed
+func (g protobufCodeGen) isPackable(kind string) bool {
+	return kind != "string" && kind != "bytes" && kind != ProtobufKindMessage
+}
+
+// appendValue returns the statement appending the value of expr (without tag) to buf
+func (g protobufCodeGen) appendValue(buf, kind, expr string) string {
+	var res string
+	switch kind {
+	case "int32", "int64", "uint32", "uint64", ProtobufKindEnum:
+		res = fmt.Sprintf("%s(%s, uint64(%s))", g.q("AppendVarint"), buf, expr)
+	case "bool":
+		res = fmt.Sprintf("%s(%s, %s(%s))", g.q("AppendVarint"), buf, g.q("EncodeBool"), expr)
+	case "sint32", "sint64":
+		res = fmt.Sprintf("%s(%s, %s(int64(%s)))", g.q("AppendVarint"), buf, g.q("EncodeZigZag"), expr)
+	case "fixed32", "sfixed32":
+		res = fmt.Sprintf("%s(%s, uint32(%s))", g.q("AppendFixed32"), buf, expr)
+	case "float":
+		res = fmt.Sprintf("%s(%s, %%Q(math,Float32bits)(%s))", g.q("AppendFixed32"), buf, expr)
+	case "fixed64", "sfixed64":
+		res = fmt.Sprintf("%s(%s, uint64(%s))", g.q("AppendFixed64"), buf, expr)
+	case "double":
+		res = fmt.Sprintf("%s(%s, %%Q(math,Float64bits)(%s))", g.q("AppendFixed64"), buf, expr)
+	case "string":
+		res = fmt.Sprintf("%s(%s, %s)", g.q("AppendString"), buf, expr)
+	case "bytes":
+		res = fmt.Sprintf("%s(%s, %s)", g.q("AppendBytes"), buf, expr)
+	case ProtobufKindMessage:
+		res = fmt.Sprintf("%s(%s, %s.AppendProtobuf(nil))", g.q("AppendBytes"), buf, expr)
+	}
+	return fmt.Sprintf("%s = %s\n", buf, res)
+}
+
+// appendTagged returns the statements appending the tag and value of expr to buf
+func (g protobufCodeGen) appendTagged(buf string, num int, kind, expr string) string {
+	return fmt.Sprintf("%[1]s = %[2]s(%[1]s, %[3]d, %[4]s)\n", buf, g.q("AppendTag"), num, g.wireType(kind)) +
+		g.appendValue(buf, kind, expr)
+}
+
+func (g protobufCodeGen) nonZeroCond(kind, expr string) string {
+	switch kind {
+	case "bool":
+		return expr
+	case "string":
+		return expr + ` != ""`
+	case "bytes":
+		return "len(" + expr + ") > 0"
+	}
+	return expr + " != 0"
+}
+
+func (g protobufCodeGen) encodeField(f ProtobufField) string {
+	fld := g.rn + "." + f.FieldName
+	switch {
+	case f.MapKey != nil:
+		return fmt.Sprintf(`for key, val := range %s {
+				var entry []byte
+				%s%s%s}
+			`, fld, g.appendTagged("entry", 1, f.MapKey.Kind, "key"), g.appendTagged("entry", 2, f.MapValue.Kind, "val"),
+			g.appendTagged("buf", f.Number, "bytes", "entry"))
+	case f.Repeated && g.isPackable(f.Kind):
+		return fmt.Sprintf(`if len(%[1]s) > 0 {
+				var packed []byte
+				for _, item := range %[1]s {
+					%[2]s}
+				buf = %[3]s(buf, %[4]d, %[5]s)
+				buf = %[6]s(buf, packed)
+			}
+			`, fld, g.appendValue("packed", f.Kind, "item"), g.q("AppendTag"), f.Number, g.q("BytesType"), g.q("AppendBytes"))
+	case f.Repeated:
+		return fmt.Sprintf("for _, item := range %s {\n%s}\n", fld, g.appendTagged("buf", f.Number, f.Kind, "item"))
+	case f.Presence && f.Kind != "bytes" && f.Kind != ProtobufKindMessage:
+		return fmt.Sprintf("if %s != nil {\n%s}\n", fld, g.appendTagged("buf", f.Number, f.Kind, "*"+fld))
+	case f.Presence:
+		return fmt.Sprintf("if %s != nil {\n%s}\n", fld, g.appendTagged("buf", f.Number, f.Kind, fld))
+	}
+	return fmt.Sprintf("if %s {\n%s}\n", g.nonZeroCond(f.Kind, fld), g.appendTagged("buf", f.Number, f.Kind, fld))
+}
+
+// readValue returns the statements reading a value by reader rd and declaring the variable value with it
+func (g protobufCodeGen) readValue(rd string, f *ProtobufField) string {
+	var read, conv string
+	switch f.Kind {
+	case "int32", "int64", "uint32", "uint64", ProtobufKindEnum:
+		read, conv = "Varint", fmt.Sprintf("%s(raw)", g.goType(f))
+	case "bool":
+		read, conv = "Varint", "raw != 0"
+	case "sint32", "sint64":
+		read, conv = "Varint", fmt.Sprintf("%s(%s(raw))", g.goType(f), g.q("DecodeZigZag"))
+	case "fixed32", "sfixed32":
+		read, conv = "Fixed32", fmt.Sprintf("%s(raw)", g.goType(f))
+	case "float":
+		read, conv = "Fixed32", "%Q(math,Float32frombits)(raw)"
+	case "fixed64", "sfixed64":
+		read, conv = "Fixed64", fmt.Sprintf("%s(raw)", g.goType(f))
+	case "double":
+		read, conv = "Fixed64", "%Q(math,Float64frombits)(raw)"
+	case "string":
+		read, conv = "Bytes", "string(raw)"
+	case "bytes":
+		read, conv = "Bytes", "append([]byte{}, raw...)"
+	case ProtobufKindMessage:
+		return fmt.Sprintf(`raw, err := %[1]s.Bytes()
+			if err != nil {
+				return err
+			}
+			var value %[2]s
+			if err = value.UnmarshalProtobuf(raw); err != nil {
+				return err
+			}
+			`, rd, g.goType(f))
+	}
+	return fmt.Sprintf(`raw, err := %s.%s()
+		if err != nil {
+			return err
+		}
+		value := %s
+		`, rd, read, conv)
+}
+
+func (g protobufCodeGen) expectType(num, typ, kind string) string {
+	return fmt.Sprintf(`if err := %s(%s, %s, %s); err != nil {
+			return err
+		}
+		`, g.q("ExpectType"), num, typ, g.wireType(kind))
+}
+
+func (g protobufCodeGen) decodeField(f ProtobufField, allFields []ProtobufField) string {
+	fld := g.rn + "." + f.FieldName
+	switch {
+	case f.MapKey != nil:
+		return fmt.Sprintf(`%[1]sraw, err := rd.Bytes()
+			if err != nil {
+				return err
+			}
+			var key %[2]s
+			var val %[3]s
+			er := %[4]s(raw)
+			for {
+				entryNum, entryTyp, err := er.Next()
+				if err == %%Q(io,EOF) {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				switch entryNum {
+				case 1:
+					%[5]s%[6]skey = value
+				case 2:
+					%[7]s%[8]sval = value
+				default:
+					if err = er.Skip(entryTyp); err != nil {
+						return err
+					}
+				}
+			}
+			if %[9]s == nil {
+				%[9]s = make(map[%[2]s]%[3]s)
+			}
+			%[9]s[key] = val
+			`, g.expectType("num", "typ", ProtobufKindMessage), g.goType(f.MapKey), g.goType(f.MapValue), g.q("NewReader"),
+			g.expectType("entryNum", "entryTyp", f.MapKey.Kind), g.readValue("er", f.MapKey),
+			g.expectType("entryNum", "entryTyp", f.MapValue.Kind), g.readValue("er", f.MapValue), fld)
+	case f.Repeated && g.isPackable(f.Kind):
+		return fmt.Sprintf(`if typ == %[1]s {
+				data, err := rd.Bytes()
+				if err != nil {
+					return err
+				}
+				pr := %[2]s(data)
+				for !pr.Done() {
+					%[3]s%[4]s = append(%[4]s, value)
+				}
+			} else {
+				%[5]s%[6]s%[4]s = append(%[4]s, value)
+			}
+			`, g.q("BytesType"), g.q("NewReader"), g.readValue("pr", &f), fld,
+			g.expectType("num", "typ", f.Kind), g.readValue("rd", &f))
+	case f.Repeated:
+		return fmt.Sprintf("%s%s%s = append(%s, value)\n", g.expectType("num", "typ", f.Kind), g.readValue("rd", &f), fld, fld)
+	}
+
+	assign := fmt.Sprintf("%s = value\n", fld)
+	if f.Presence && f.Kind != "bytes" {
+		assign = fmt.Sprintf("%s = &value\n", fld)
+	}
+	if f.Oneof != "" {
+		// Only one field of oneof can be set, the last one wins
+		for _, other := range allFields {
+			if other.Oneof == f.Oneof && other.FieldName != f.FieldName {
+				assign += fmt.Sprintf("%s.%s = nil\n", g.rn, other.FieldName)
+			}
+		}
+	}
+	return g.expectType("num", "typ", f.Kind) + g.readValue("rd", &f) + assign
+}
@@ -0,0 +1,202 @@
+package render
+
+import (
+	"reflect"
+
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+)
+
+// typeEdge is a reference from one type to another one, e.g. from struct to its field type
+type typeEdge struct {
+	target  common.GolangType
+	byValue bool           // Target is contained by value, so that the types cannot contain each other this way
+	field   *GoStructField // Struct field the reference is made by, if any
+}
+
+// typeEdges returns the references the type makes to other types. Promises are transparent, i.e. the reference
+// is made to the type the promise points to.
+func typeEdges(typ common.GolangType) []typeEdge {
+	switch v := typ.(type) {
+	case *GoStruct:
+		return lo.Map(v.Fields, func(_ GoStructField, i int) typeEdge {
+			target := unwrapPromise(v.Fields[i].Type)
+			if o, ok := target.(*GoOptional); ok {
+				target = unwrapPromise(o.Type) // Optional keeps the value inside, so the field is the place for a pointer
+			}
+			return typeEdge{target: target, byValue: true, field: &v.Fields[i]}
+		})
+	case *UnionStruct:
+		return typeEdges(&v.GoStruct)
+	case *GoArray:
+		return []typeEdge{{target: unwrapPromise(v.ItemsType), byValue: v.Size > 0}}
+	case *GoMap:
+		return []typeEdge{{target: unwrapPromise(v.KeyType)}, {target: unwrapPromise(v.ValueType)}}
+	case *GoTypeAlias:
+		return []typeEdge{{target: unwrapPromise(v.AliasedType), byValue: true}}
+	case *GoPointer:
+		return []typeEdge{{target: unwrapPromise(v.Type)}}
+	case *GoOptional:
+		return []typeEdge{{target: unwrapPromise(v.Type), byValue: true}}
+	}
+	return nil
+}
+
+func unwrapPromise(typ common.GolangType) common.GolangType {
+	for {
+		prm, ok := typ.(*GolangTypePromise)
+		if !ok || !prm.Assigned() {
+			return typ
+		}
+		typ = prm.Target()
+	}
+}
+
+// isReferenceType returns true if type is a pointer, so it has an identity and can be a part of types cycle. Only
+// such types can refer to other types, see typeEdges.
+func isReferenceType(typ common.GolangType) bool {
+	return typ != nil && reflect.ValueOf(typ).Kind() == reflect.Pointer
+}
+
+// isNamedType returns true if type usage in code is just its name, i.e. the type is defined separately
+func isNamedType(typ common.GolangType) bool {
+	switch v := typ.(type) {
+	case *GoStruct:
+		return v.DirectRender
+	case *UnionStruct:
+		return v.DirectRender
+	case *GoArray:
+		return v.DirectRender
+	case *GoMap:
+		return v.DirectRender
+	case *GoTypeAlias:
+		return v.DirectRender
+	case *GoPointer, *GoOptional:
+		return false
+	}
+	return true // Other types don't refer to other types
+}
+
+// NameRecursiveTypes makes the anonymous recursive types to be rendered separately, since the anonymous type can't
+// refer to itself in Go. For every cycle of anonymous types, one of them (preferably the struct) is made named.
+// Returns the types that have been made named.
+func NameRecursiveTypes(roots []common.GolangType) []common.GolangType {
+	var res []common.GolangType
+	for {
+		cycle := findAnonymousCycle(roots)
+		if cycle == nil {
+			return res
+		}
+		// Prefer structs, since the struct is what is usually meant as the recursive type
+		isStruct := func(item common.GolangType, _ int) bool {
+			_, ok := item.(*GoStruct)
+			return ok
+		}
+		candidates := append(lo.Filter(cycle, isStruct), lo.Reject(cycle, isStruct)...)
+		typ, ok := lo.Find(candidates, setDirectRender)
+		if !ok {
+			panic("Recursive types cycle contains no type that can be named, this must not happen")
+		}
+		res = append(res, typ)
+	}
+}
+
+// findAnonymousCycle returns the types cycle, that consist of anonymous types only, nil if no such cycle
+func findAnonymousCycle(roots []common.GolangType) []common.GolangType {
+	done := make(map[common.GolangType]bool)
+	var stack []common.GolangType
+
+	var visit func(typ common.GolangType) []common.GolangType
+	visit = func(typ common.GolangType) []common.GolangType {
+		if !isReferenceType(typ) {
+			return nil
+		}
+		if idx := lo.IndexOf(stack, typ); idx >= 0 {
+			return append([]common.GolangType{}, stack[idx:]...)
+		}
+		if done[typ] {
+			return nil
+		}
+		stack = append(stack, typ)
+		defer func() { stack = stack[:len(stack)-1] }()
+		if !isNamedType(typ) {
+			for _, e := range typeEdges(typ) {
+				if cycle := visit(e.target); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		done[typ] = true
+		return nil
+	}
+
+	for _, r := range roots {
+		// Definition of named type renders the usages of types it refers to
+		for _, e := range typeEdges(unwrapPromise(r)) {
+			if cycle := visit(e.target); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func setDirectRender(typ common.GolangType) bool {
+	switch v := typ.(type) {
+	case *GoStruct:
+		v.DirectRender = true
+	case *UnionStruct:
+		v.DirectRender = true
+	case *GoArray:
+		v.DirectRender = true
+	case *GoMap:
+		v.DirectRender = true
+	case *GoTypeAlias:
+		v.DirectRender = true
+	default:
+		return false
+	}
+	return true
+}
+
+// PointerizeRecursiveFields breaks the cycles of types that contain each other by value, such as a struct with a
+// field of the same struct type, which is invalid in Go. The struct field that closes such cycle becomes a pointer.
+// Returns the fields that have been changed as "Struct.Field".
+func PointerizeRecursiveFields(roots []common.GolangType) []string {
+	var res []string
+	onStack := make(map[common.GolangType]bool)
+	done := make(map[common.GolangType]bool)
+
+	var visit func(typ common.GolangType)
+	visit = func(typ common.GolangType) {
+		onStack[typ] = true
+		for _, e := range typeEdges(typ) {
+			if !e.byValue || !isReferenceType(e.target) {
+				continue
+			}
+			switch {
+			case onStack[e.target]:
+				if e.field == nil {
+					// The only by-value edge without field is the array or type alias, which have no place for a pointer.
+					// Such types are also rejected by Go compiler, so leave it as is to be reported
+					continue
+				}
+				e.field.Type = &GoPointer{Type: e.field.Type}
+				fieldName, _ := lo.Coalesce(e.field.Name, e.target.TypeName()) // Embedded field has no name
+				res = append(res, typ.TypeName()+"."+fieldName)
+			case !done[e.target]:
+				visit(e.target)
+			}
+		}
+		onStack[typ] = false
+		done[typ] = true
+	}
+
+	for _, r := range roots {
+		if typ := unwrapPromise(r); isReferenceType(typ) && !done[typ] {
+			visit(typ)
+		}
+	}
+	return res
+}
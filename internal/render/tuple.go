@@ -0,0 +1,155 @@
+package render
+
+import (
+	"github.com/dave/jennifer/jen"
+	"github.com/samber/lo"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/utils"
+)
+
+// TupleItems describes the struct, that is marshaled as an array with items of different types (prefixItems or
+// items array in schema). Struct fields are the items in order, AdditionalItems field goes last if any.
+type TupleItems struct {
+	MinItems     int  // Number of items that must be present, the fields of the rest are pointers
+	Additional   bool // Struct has AdditionalItems field, which is a slice of items going after the fields
+	NoAdditional bool // Items after the fields are not allowed, otherwise they are ignored if there is no AdditionalItems field
+}
+
+// itemFields returns the struct fields that are the tuple items at fixed positions
+func (s GoStruct) itemFields() []GoStructField {
+	if s.Tuple != nil && s.Tuple.Additional {
+		return s.Fields[:len(s.Fields)-1]
+	}
+	return s.Fields
+}
+
+// renderTupleMethods renders the JSON and YAML marshal methods, that put the struct fields to array and back
+func renderTupleMethods(ctx *common.RenderContext, s *GoStruct) []*jen.Statement {
+	rn := s.ReceiverName()
+	fields := s.itemFields()
+	maxItems := lo.Ternary(s.Tuple.NoAdditional, len(fields), -1)
+	returnIfErr := func(call jen.Code) jen.Code {
+		return jen.If(jen.Err().Op(":=").Add(call), jen.Err().Op("!=").Nil()).Block(jen.Return(jen.Err()))
+	}
+	checkCount := func(count jen.Code) jen.Code {
+		return returnIfErr(jen.Qual(ctx.RuntimeModule(""), "CheckItemsCount").Call(count, jen.Lit(s.Tuple.MinItems), jen.Lit(maxItems)))
+	}
+	resetAdditional := jen.Null()
+	if s.Tuple.Additional {
+		resetAdditional = jen.Id(rn).Dot("AdditionalItems").Op("=").Nil()
+	}
+
+	return []*jen.Statement{
+		jen.Comment("setItem decodes the array item to the struct field at its position"),
+		jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("setItem").
+			Params(jen.Id("idx").Int(), jen.Id("decode").Func().Params(jen.Id("target").Any()).Error()).
+			Error().
+			Block(renderSetItem(ctx, s, rn, fields)...),
+
+		jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("UnmarshalJSON").Params(jen.Id("data").Index().Byte()).Error().Block(
+			jen.Var().Id("items").Index().Qual("encoding/json", "RawMessage"),
+			returnIfErr(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("data"), jen.Op("&").Id("items"))),
+			checkCount(jen.Len(jen.Id("items"))),
+			resetAdditional,
+			jen.For(jen.List(jen.Id("idx"), jen.Id("item")).Op(":=").Range().Id("items")).Block(
+				returnIfErr(jen.Id(rn).Dot("setItem").Call(
+					jen.Id("idx"),
+					jen.Func().Params(jen.Id("target").Any()).Error().Block(
+						jen.Return(jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("item"), jen.Id("target"))),
+					),
+				)),
+			),
+			jen.Return(jen.Nil()),
+		),
+
+		jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("UnmarshalYAML").Params(jen.Id("node").Op("*").Qual("gopkg.in/yaml.v3", "Node")).Error().Block(
+			jen.If(jen.Id("node").Dot("Kind").Op("!=").Qual("gopkg.in/yaml.v3", "SequenceNode")).Block(
+				jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("line %d: expected a sequence"), jen.Id("node").Dot("Line"))),
+			),
+			checkCount(jen.Len(jen.Id("node").Dot("Content"))),
+			resetAdditional,
+			jen.For(jen.List(jen.Id("idx"), jen.Id("item")).Op(":=").Range().Id("node").Dot("Content")).Block(
+				returnIfErr(jen.Id(rn).Dot("setItem").Call(jen.Id("idx"), jen.Id("item").Dot("Decode"))),
+			),
+			jen.Return(jen.Nil()),
+		),
+
+		jen.Comment("items returns the struct fields as array items, the trailing optional items that are not set are omitted"),
+		jen.Func().Params(jen.Id(rn).Id(s.Name)).Id("items").Params().Index().Any().Block(renderTupleItems(s, rn, fields)...),
+
+		jen.Func().Params(jen.Id(rn).Id(s.Name)).Id("MarshalJSON").Params().Params(jen.Index().Byte(), jen.Error()).Block(
+			jen.Return(jen.Qual("encoding/json", "Marshal").Call(jen.Id(rn).Dot("items").Call())),
+		),
+
+		jen.Func().Params(jen.Id(rn).Id(s.Name)).Id("MarshalYAML").Params().Params(jen.Any(), jen.Error()).Block(
+			jen.Return(jen.Id(rn).Dot("items").Call(), jen.Nil()),
+		),
+	}
+}
+
+// renderSetItem renders the body of setItem method
+func renderSetItem(ctx *common.RenderContext, s *GoStruct, rn string, fields []GoStructField) []jen.Code {
+	wrapErr := func(call jen.Code) jen.Code {
+		return jen.If(jen.Err().Op(":=").Add(call), jen.Err().Op("!=").Nil()).Block(
+			jen.Return(jen.Qual("fmt", "Errorf").Call(jen.Lit("item %d: %w"), jen.Id("idx"), jen.Err())),
+		)
+	}
+
+	var restCode []jen.Code
+	if s.Tuple.Additional {
+		arr := unwrapPromise(s.Fields[len(s.Fields)-1].Type).(*GoArray)
+		restCode = []jen.Code{
+			jen.Var().Id("value").Add(utils.ToCode(arr.ItemsType.RenderUsage(ctx))...),
+			wrapErr(jen.Id("decode").Call(jen.Op("&").Id("value"))),
+			jen.Id(rn).Dot("AdditionalItems").Op("=").Append(jen.Id(rn).Dot("AdditionalItems"), jen.Id("value")),
+		}
+	}
+
+	return []jen.Code{
+		jen.Switch(jen.Id("idx")).BlockFunc(func(g *jen.Group) {
+			for i, f := range fields {
+				g.Case(jen.Lit(i)).Block(wrapErr(jen.Id("decode").Call(jen.Op("&").Id(rn).Dot(f.Name))))
+			}
+			if len(restCode) > 0 {
+				g.Default().Block(restCode...)
+			}
+		}),
+		jen.Return(jen.Nil()),
+	}
+}
+
+// renderTupleItems renders the body of items method
+func renderTupleItems(s *GoStruct, rn string, fields []GoStructField) []jen.Code {
+	values := jen.Index().Any().Values(lo.Map(fields, func(item GoStructField, _ int) jen.Code {
+		return jen.Id(rn).Dot(item.Name)
+	})...)
+	if s.Tuple.MinItems >= len(fields) && !s.Tuple.Additional {
+		return []jen.Code{jen.Return(values)}
+	}
+
+	var res []jen.Code
+	if s.Tuple.MinItems >= len(fields) {
+		res = append(res, jen.Id("res").Op(":=").Add(values))
+	} else {
+		res = append(res, jen.Id("size").Op(":=").Lit(s.Tuple.MinItems))
+		for i := s.Tuple.MinItems; i < len(fields); i++ {
+			res = append(res, jen.If(jen.Id(rn).Dot(fields[i].Name).Op("!=").Nil()).Block(jen.Id("size").Op("=").Lit(i+1)))
+		}
+		if !s.Tuple.Additional {
+			return append(res, jen.Return(values.Index(jen.Empty(), jen.Id("size"))))
+		}
+		res = append(res,
+			jen.If(jen.Len(jen.Id(rn).Dot("AdditionalItems")).Op(">").Lit(0)).Block(
+				jen.Id("size").Op("=").Lit(len(fields)).Comment("Additional items follow all the fixed ones"),
+			),
+			jen.Id("res").Op(":=").Add(values).Index(jen.Empty(), jen.Id("size")),
+		)
+	}
+	return append(res,
+		jen.For(jen.List(jen.Id("_"), jen.Id("item")).Op(":=").Range().Id(rn).Dot("AdditionalItems")).Block(
+			jen.Id("res").Op("=").Append(jen.Id("res"), jen.Id("item")),
+		),
+		jen.Return(jen.Id("res")),
+	)
+}
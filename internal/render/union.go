@@ -2,7 +2,6 @@ package render
 
 import (
 	"reflect"
-	"strings"
 
 	"github.com/xcnt/go-asyncapi/internal/common"
 	"github.com/dave/jennifer/jen"
@@ -11,6 +10,15 @@ import (
 
 type UnionStruct struct {
 	GoStruct
+	// VariantDiscriminator is a property name, whose value denotes which variant the data contains. Empty if no
+	// discriminator is set, and the variants are tried one by one.
+	VariantDiscriminator string
+	// VariantValues are discriminator values for every field set by discriminator mapping, see variantValues
+	VariantValues [][]string
+	// VariantNames are the names of schemas the fields refer to, empty for inline schemas, see variantValues
+	VariantNames []string
+	// Polymorphic is true if the data is only one of variants (oneOf, anyOf), false if it consists of all of them (allOf)
+	Polymorphic bool
 }
 
 func (s UnionStruct) RenderDefinition(ctx *common.RenderContext) []*jen.Statement {
@@ -18,13 +26,16 @@ func (s UnionStruct) RenderDefinition(ctx *common.RenderContext) []*jen.Statemen
 	ctx.LogStartRender("UnionStruct", s.Import, s.Name, "definition", s.DirectRendering())
 	defer ctx.LogFinishRender()
 
+	// Union renders its own Validate method, that checks the variants, so the underlying struct must not render one
+	strct := s.GoStruct
+	strct.Constraints = nil
+
 	onlyStructs := lo.EveryBy(s.Fields, func(item GoStructField) bool {
 		return isTypeStruct(item.Type)
 	})
 	if onlyStructs { // Draw simplified union with embedded fields
-		res = s.GoStruct.RenderDefinition(ctx)
-	} else { // Draw union with named fields and methods
-		strct := s.GoStruct
+		res = strct.RenderDefinition(ctx)
+	} else { // Draw union with named fields
 		strct.Fields = lo.Map(strct.Fields, func(item GoStructField, _ int) GoStructField {
 			item.Name = item.Type.TypeName()
 			return item
@@ -33,46 +44,226 @@ func (s UnionStruct) RenderDefinition(ctx *common.RenderContext) []*jen.Statemen
 			panic("Must not happen")
 		}
 		res = strct.RenderDefinition(ctx)
-		res = append(res, s.renderMethods(ctx)...)
+	}
+	res = append(res, s.renderMethods(ctx, onlyStructs)...)
+	if s.hasValidateMethod() {
+		res = append(res, renderTypeValidateMethod(ctx, &s, s.Name)...)
 	}
 	return res
 }
 
-func (s UnionStruct) renderMethods(ctx *common.RenderContext) []*jen.Statement {
+// unionCodec is an encoding to render the union marshal and unmarshal methods for
+type unionCodec struct {
+	Tag           string // Struct tag name
+	Title         string // Title in method names
+	UnmarshalArgs func() jen.Code
+	Decode        func(target jen.Code) *jen.Statement
+}
+
+var unionCodecs = []unionCodec{
+	{
+		Tag:           "json",
+		Title:         "JSON",
+		UnmarshalArgs: func() jen.Code { return jen.Id("bytes").Index().Byte() },
+		Decode: func(target jen.Code) *jen.Statement {
+			return jen.Qual("encoding/json", "Unmarshal").Call(jen.Id("bytes"), target)
+		},
+	},
+	{
+		Tag:           "yaml",
+		Title:         "YAML",
+		UnmarshalArgs: func() jen.Code { return jen.Id("node").Op("*").Qual("gopkg.in/yaml.v3", "Node") },
+		Decode: func(target jen.Code) *jen.Statement {
+			return jen.Id("node").Dot("Decode").Call(target)
+		},
+	},
+}
+
+func (s UnionStruct) renderMethods(ctx *common.RenderContext, onlyStructs bool) []*jen.Statement {
 	ctx.Logger.Trace("renderMethods")
 
 	var res []*jen.Statement
-	receiverName := strings.ToLower(string(s.GoStruct.Name[0]))
+	for _, codec := range unionCodecs {
+		switch {
+		case s.VariantDiscriminator != "":
+			res = append(res, s.renderDiscriminatorUnmarshal(ctx, codec))
+		case s.Polymorphic || !onlyStructs: // Embedded structs of allOf are decoded by default
+			res = append(res, s.renderTryUnmarshal(ctx, codec))
+		}
+	}
+	if s.Polymorphic {
+		res = append(res, s.renderVariantMethods(ctx)...)
+	}
+
+	return res
+}
 
-	// Method UnmarshalJSON(bytes []byte) error
-	body := []jen.Code{jen.Var().Err().Error()}
-	stmt := &jen.Statement{}
+// renderTryUnmarshal renders the unmarshal method, that tries to decode the data to the variants one by one until
+// it succeeds
+func (s UnionStruct) renderTryUnmarshal(ctx *common.RenderContext, codec unionCodec) *jen.Statement {
+	ctx.Logger.Trace("renderTryUnmarshal", "codec", codec.Title)
+
+	rn := s.ReceiverName()
+	body := []jen.Code{
+		jen.Op("*").Id(rn).Op("=").Id(s.Name).Values(),
+		jen.Var().Err().Error(),
+	}
 	for _, f := range s.GoStruct.Fields {
-		op := ""
-		if v, ok := f.Type.(golangPointerType); !ok || !v.IsPointer() { // No need to take address for a pointer
-			op = "&"
+		field := jen.Id(rn).Dot(f.Type.TypeName())
+		body = append(body, jen.If(
+			jen.Err().Op("=").Add(codec.Decode(jen.Op("&").Add(field))),
+			jen.Err().Op("==").Nil(),
+		).Block(jen.Return(jen.Nil())))
+		if isTypeNilable(f.Type) { // Drop the partially decoded variant
+			body = append(body, jen.Id(rn).Dot(f.Type.TypeName()).Op("=").Nil())
 		}
-		stmt = stmt.If(
-			jen.Err().Op("=").Qual("encoding/json", "Unmarshal").Call(jen.Id("bytes"), jen.Op(op).Id(receiverName).Dot(f.Type.TypeName())),
-			jen.Err().Op("!=").Nil(),
-		).
-			Block(jen.Return(jen.Nil())).
-			Else()
-	}
-	if len(s.GoStruct.Fields) > 0 {
-		stmt = stmt.Block(jen.Return(jen.Err()))
-	} else {
-		stmt = stmt.Return(jen.Return(jen.Nil()))
-	}
-	body = append(body, stmt)
-
-	res = append(res, jen.Func().Params(jen.Id(receiverName).Op("*").Id(s.GoStruct.Name)).Id("UnmarshalJSON").
-		Params(jen.Id("bytes").Index().Byte()).
+	}
+	body = append(body, jen.Return(jen.Err()))
+
+	return jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("Unmarshal"+codec.Title).
+		Params(codec.UnmarshalArgs()).
 		Error().
-		Block(body...),
-	)
+		Block(body...)
+}
 
-	return res
+// renderDiscriminatorUnmarshal renders unmarshal method that reads the discriminator property first and then
+// unmarshals the data only to the variant this property value denotes.
+func (s UnionStruct) renderDiscriminatorUnmarshal(ctx *common.RenderContext, codec unionCodec) *jen.Statement {
+	ctx.Logger.Trace("renderDiscriminatorUnmarshal", "discriminator", s.VariantDiscriminator, "codec", codec.Title)
+
+	rn := s.ReceiverName()
+	var cases []jen.Code
+	for i, f := range s.GoStruct.Fields {
+		variantValues := s.variantValues(i)
+		if len(variantValues) == 0 {
+			continue
+		}
+		values := lo.Map(variantValues, func(item string, _ int) jen.Code { return jen.Lit(item) })
+		cases = append(cases, jen.Case(values...).Block(
+			jen.Return(codec.Decode(jen.Op("&").Id(rn).Dot(f.Type.TypeName()))),
+		))
+	}
+
+	return jen.Func().Params(jen.Id(rn).Op("*").Id(s.Name)).Id("Unmarshal"+codec.Title).
+		Params(codec.UnmarshalArgs()).
+		Error().
+		Block(
+			jen.Var().Id("discriminator").Struct(
+				jen.Id("Value").String().Tag(map[string]string{codec.Tag: s.VariantDiscriminator}),
+			),
+			jen.If(
+				jen.Err().Op(":=").Add(codec.Decode(jen.Op("&").Id("discriminator"))),
+				jen.Err().Op("!=").Nil(),
+			).Block(jen.Return(jen.Err())),
+			jen.Op("*").Id(rn).Op("=").Id(s.Name).Values(),
+			jen.Switch(jen.Id("discriminator").Dot("Value")).Block(cases...),
+			jen.Return(jen.Qual("fmt", "Errorf").Call(
+				jen.Lit("unknown "+s.VariantDiscriminator+" value %q"),
+				jen.Id("discriminator").Dot("Value"),
+			)),
+		)
+}
+
+// renderVariantMethods renders the Variant method, that returns the only variant that is set, and marshal methods
+// that encode only this variant
+func (s UnionStruct) renderVariantMethods(ctx *common.RenderContext) []*jen.Statement {
+	ctx.Logger.Trace("renderVariantMethods")
+
+	rn := s.ReceiverName()
+	var cases []jen.Code
+	for _, f := range s.GoStruct.Fields {
+		if !isTypeNilable(f.Type) {
+			continue
+		}
+		field := jen.Id(rn).Dot(f.Type.TypeName())
+		cases = append(cases, jen.Case(jen.Add(field).Op("!=").Nil()).Block(jen.Return(field.Clone())))
+	}
+	receiver := jen.Id(rn).Id(s.Name)
+
+	return []*jen.Statement{
+		jen.Comment("Variant returns the variant the union contains, or nil if no variant is set. The result is one of " +
+			"the variant field values and is intended to be used in a type switch."),
+		jen.Func().Params(receiver.Clone()).Id("Variant").Params().Any().Block(
+			jen.Switch().Block(cases...),
+			jen.Return(jen.Nil()),
+		),
+		jen.Func().Params(receiver.Clone()).Id("MarshalJSON").Params().Params(jen.Index().Byte(), jen.Error()).Block(
+			jen.Return(jen.Qual("encoding/json", "Marshal").Call(jen.Id(rn).Dot("Variant").Call())),
+		),
+		jen.Func().Params(receiver.Clone()).Id("MarshalYAML").Params().Params(jen.Any(), jen.Error()).Block(
+			jen.Return(jen.Id(rn).Dot("Variant").Call(), jen.Nil()),
+		),
+	}
+}
+
+// variantValues returns the discriminator values denoting the i-th field. Field without values is never chosen by
+// discriminator.
+func (s UnionStruct) variantValues(i int) []string {
+	var mapped []string
+	var schemaName string
+	if i < len(s.VariantValues) {
+		mapped = s.VariantValues[i]
+	}
+	if i < len(s.VariantNames) {
+		schemaName = s.VariantNames[i]
+	}
+	return discriminatorValues(s.GoStruct.Fields[i].Type, s.VariantDiscriminator, mapped, schemaName)
+}
+
+// discriminatorValues returns the values of discriminator property denoting the struct type. These are the values
+// set by discriminator mapping if any, otherwise the const value of the discriminator property in struct, otherwise
+// the schema name if not empty. Must be called after linking, when the struct fields are known.
+func discriminatorValues(typ common.GolangType, property string, mapped []string, schemaName string) []string {
+	if len(mapped) > 0 {
+		return mapped
+	}
+	if strct, ok := underlyingStruct(typ); ok {
+		f, ok := lo.Find(strct.Fields, func(item GoStructField) bool { return item.MarshalName == property })
+		if v, isConst := typeConstValue(f.Type); ok && isConst {
+			return []string{v}
+		}
+	}
+	if schemaName != "" {
+		return []string{schemaName}
+	}
+	return nil
+}
+
+// underlyingStruct returns the struct the type refers to, looking through pointers, type aliases and promises
+func underlyingStruct(typ common.GolangType) (*GoStruct, bool) {
+	for typ != nil {
+		switch v := typ.(type) {
+		case *GoStruct:
+			return v, true
+		case golangTypeWrapperType:
+			typ, _ = v.WrappedGolangType()
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// typeConstValue returns the value of enum type with the only string value, that is generated from schema with
+// `const`
+func typeConstValue(typ common.GolangType) (string, bool) {
+	for typ != nil {
+		switch v := typ.(type) {
+		case *GoEnum:
+			if len(v.Values) != 1 {
+				return "", false
+			}
+			res, ok := v.Values[0].Value.(string)
+			return res, ok
+		case *GoOptional:
+			typ = v.Type
+		case golangTypeWrapperType:
+			typ, _ = v.WrappedGolangType()
+		default:
+			return "", false
+		}
+	}
+	return "", false
 }
 
 func isTypeStruct(typ common.GolangType) bool {
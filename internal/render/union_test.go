@@ -0,0 +1,56 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xcnt/go-asyncapi/internal/common"
+)
+
+func TestUnionVariantValues(t *testing.T) {
+	// Schemas referenced by variants, like `#/components/schemas/Circle`
+	refStruct := func(name string, discriminatorType common.GolangType) *GoPointer {
+		prm := NewGolangTypePromise("#/components/schemas/"+name, common.PromiseOriginUser)
+		prm.Assign(&GoStruct{
+			BaseType: BaseType{Name: name, DirectRender: true},
+			Fields:   []GoStructField{{Name: "ShapeType", MarshalName: "shapeType", Type: discriminatorType}},
+		})
+		return &GoPointer{Type: prm}
+	}
+	constType := func(value string) common.GolangType {
+		return &GoEnum{
+			BaseType:       BaseType{Name: "ShapeType", DirectRender: true},
+			UnderlyingType: &GoSimple{Name: "string"},
+			Values:         []GoEnumValue{{Name: "ShapeType" + value, Value: value}},
+		}
+	}
+	str := &GoSimple{Name: "string"}
+
+	tests := []struct {
+		name   string
+		typ    common.GolangType
+		mapped []string
+		schema string
+		want   []string
+	}{
+		{"ref with const", refStruct("Circle", constType("circle")), nil, "Circle", []string{"circle"}},
+		{"ref with optional const", refStruct("Circle", &GoPointer{Type: constType("circle")}), nil, "Circle", []string{"circle"}},
+		{"ref without const", refStruct("Square", str), nil, "Square", []string{"Square"}},
+		{"mapping overrides const", refStruct("Circle", constType("circle")), []string{"round"}, "Circle", []string{"round"}},
+		{"inline with const", &GoPointer{Type: &GoStruct{Fields: []GoStructField{{MarshalName: "shapeType", Type: constType("dot")}}}}, nil, "", []string{"dot"}},
+		{"inline without const", &GoPointer{Type: &GoStruct{Fields: []GoStructField{{MarshalName: "shapeType", Type: str}}}}, nil, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := UnionStruct{
+				GoStruct:             GoStruct{Fields: []GoStructField{{Type: tt.typ}}},
+				VariantDiscriminator: "shapeType",
+				VariantValues:        [][]string{tt.mapped},
+				VariantNames:         []string{tt.schema},
+			}
+			if got := u.variantValues(0); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expect %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
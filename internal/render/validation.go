@@ -0,0 +1,391 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/samber/lo"
+	"github.com/xcnt/go-asyncapi/internal/common"
+)
+
+const validatorVarName = "validator"
+
+// Constraints are JSON Schema validation keywords, that are checked by the generated Validate method. Types with
+// nil constraints are not schema models and get no Validate method.
+type Constraints struct {
+	MinLength        *int
+	MaxLength        *int
+	Pattern          string
+	Minimum          *json.Number
+	Maximum          *json.Number
+	ExclusiveMinimum *json.Number
+	ExclusiveMaximum *json.Number
+	MultipleOf       *json.Number
+	MinItems         *int
+	MaxItems         *int
+	UniqueItems      bool
+	MinProperties    *int
+	MaxProperties    *int
+}
+
+type validatedType interface {
+	hasValidateMethod() bool
+}
+
+// renderValidateMethod renders the Validate method with given checks
+func renderValidateMethod(ctx *common.RenderContext, receiver jen.Code, checks []jen.Code) []*jen.Statement {
+	body := []jen.Code{jen.Return(jen.Nil())}
+	if len(checks) > 0 {
+		body = append([]jen.Code{jen.Var().Id(validatorVarName).Qual(ctx.RuntimeModule(""), "Validator")}, checks...)
+		body = append(body, jen.Return(jen.Id(validatorVarName).Dot("Err").Call()))
+	}
+	return []*jen.Statement{
+		jen.Comment("Validate checks the value against the schema constraints and returns all violations as run.ValidationErrors"),
+		jen.Func().Params(receiver).Id("Validate").Params().Error().Block(body...),
+	}
+}
+
+// renderTypeValidateMethod renders the Validate method of a type with value receiver
+func renderTypeValidateMethod(ctx *common.RenderContext, typ common.GolangType, name string) []*jen.Statement {
+	rn := strings.ToLower(name[:1])
+	r := validationRenderer{ctx: ctx, visiting: map[common.GolangType]bool{typ: true}}
+	checks := r.renderContents(typ, jen.Id(rn), validationPath{"$"})
+	return renderValidateMethod(ctx, jen.Id(rn).Id(name), checks)
+}
+
+// validationPath is a JSON path of the value being validated, as string literals and expressions to be concatenated
+type validationPath []any
+
+func (p validationPath) with(items ...any) validationPath {
+	res := append(validationPath{}, p...)
+	for _, item := range items {
+		if s, ok := item.(string); ok && len(res) > 0 {
+			if last, ok := res[len(res)-1].(string); ok {
+				res[len(res)-1] = last + s
+				continue
+			}
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+func (p validationPath) render() *jen.Statement {
+	res := &jen.Statement{}
+	for i, item := range p {
+		if i > 0 {
+			res.Op("+")
+		}
+		switch v := item.(type) {
+		case string:
+			res.Lit(v)
+		case jen.Code:
+			res.Add(v)
+		}
+	}
+	return res
+}
+
+// validationRenderer renders the code that checks a value against the constraints of its type and the types
+// it consists of
+type validationRenderer struct {
+	ctx      *common.RenderContext
+	depth    int                        // Nesting level of loops, to make unique variable names
+	visiting map[common.GolangType]bool // Anonymous types being rendered, to stop on recursive types
+}
+
+// renderType renders the checks of a value, calling Validate method if its type has one
+func (r *validationRenderer) renderType(typ common.GolangType, expr jen.Code, path validationPath) []jen.Code {
+	switch v := typ.(type) {
+	case *GolangTypePromise:
+		if !v.Assigned() {
+			return nil
+		}
+		return r.renderType(v.Target(), expr, path)
+	case *GoPointer:
+		if !v.isPointer() {
+			return r.renderType(v.Type, expr, path)
+		}
+		valueExpr := jen.Op("*").Add(expr)
+		if _, isOptional := unwrapPromise(v.Type).(*GoOptional); isOptional || isTypeStruct(v.Type) || hasValidateMethod(v.Type) {
+			valueExpr = jen.Add(expr) // Fields and methods are accessible via pointer
+		}
+		checks := r.renderType(v.Type, valueExpr, path)
+		if len(checks) == 0 {
+			return nil
+		}
+		return []jen.Code{jen.If(jen.Add(expr).Op("!=").Nil()).Block(checks...)}
+	case *GoOptional:
+		if !v.isOptional() {
+			return r.renderType(v.Type, expr, path)
+		}
+		checks := r.renderType(v.Type, jen.Add(expr).Dot("Value"), path)
+		if len(checks) == 0 {
+			return nil
+		}
+		return []jen.Code{jen.If(jen.Add(expr).Dot("Set")).Block(checks...)}
+	}
+
+	if hasValidateMethod(typ) {
+		return []jen.Code{
+			jen.Id(validatorVarName).Dot("Merge").Call(path.render(), jen.Add(expr).Dot("Validate").Call()),
+		}
+	}
+	if r.visiting[typ] {
+		return nil
+	}
+	r.visiting[typ] = true
+	defer delete(r.visiting, typ)
+	return r.renderContents(typ, expr, path)
+}
+
+// renderContents renders the checks of a value in place, without calling its Validate method
+func (r *validationRenderer) renderContents(typ common.GolangType, expr jen.Code, path validationPath) []jen.Code {
+	var res []jen.Code
+	switch v := typ.(type) {
+	case *UnionStruct:
+		res = r.renderConstraints(v.Constraints, v, expr, path)
+		for _, f := range v.Fields {
+			// All variants are the same value, so the path is the same
+			res = append(res, r.renderType(f.Type, jen.Add(expr).Dot(f.Type.TypeName()), path)...)
+		}
+	case *GoStruct:
+		res = r.renderConstraints(v.Constraints, v, expr, path)
+		if v.DirectRender && v.Conditions != nil {
+			// Conditional schemas are checked against the value as it's marshaled, i.e. as it's sent
+			res = append(res, jen.Id(validatorVarName).Dot("Merge").Call(
+				path.render(), jen.Id(v.conditionsVarName()).Dot("CheckValue").Call(jen.Op("&").Add(expr)),
+			))
+		}
+		for i, f := range v.Fields {
+			if f.Name == "" {
+				continue // Embedded type
+			}
+			fieldExpr := jen.Add(expr).Dot(f.Name)
+			if v.Tuple != nil {
+				res = append(res, r.renderTupleItem(v, i, fieldExpr, path)...)
+				continue
+			}
+			marshalName := f.MarshalName
+			if marshalName == "" {
+				marshalName = strings.ToLower(f.Name[:1]) + f.Name[1:]
+			}
+			fieldPath := path.with("." + marshalName)
+			if f.KeyPattern != "" || f.Additional {
+				fieldPath = path // Map keys are the properties of object itself
+			}
+			if f.Required && isTypeNilable(f.Type) && !isTypeNullable(f.Type) {
+				res = append(res, jen.Id(validatorVarName).Dot("Required").Call(fieldPath.render(), jen.Add(fieldExpr).Op("!=").Nil()))
+			}
+			checks := r.renderType(f.Type, fieldExpr, fieldPath)
+			if f.OmitEmpty && len(checks) > 0 && !isTypeNilable(f.Type) && !isTypeStruct(f.Type) {
+				// Absent optional property is the zero value, that may not satisfy the constraints
+				checks = []jen.Code{jen.If(jen.Op("!").Qual(r.ctx.RuntimeModule(""), "IsEmpty").Call(fieldExpr)).Block(checks...)}
+			}
+			res = append(res, checks...)
+		}
+	case *GoArray:
+		res = r.renderConstraints(v.Constraints, v, expr, path)
+		idx, item := fmt.Sprintf("i%d", r.depth), fmt.Sprintf("item%d", r.depth)
+		r.depth++
+		itemChecks := r.renderType(v.ItemsType, jen.Id(item), path.with("[", jen.Qual("strconv", "Itoa").Call(jen.Id(idx)), "]"))
+		r.depth--
+		if len(itemChecks) > 0 {
+			res = append(res, jen.For(jen.List(jen.Id(idx), jen.Id(item)).Op(":=").Range().Add(expr)).Block(itemChecks...))
+		}
+	case *GoMap:
+		res = r.renderConstraints(v.Constraints, v, expr, path)
+		key, val := fmt.Sprintf("k%d", r.depth), fmt.Sprintf("v%d", r.depth)
+		var keyExpr jen.Code = jen.Qual("fmt", "Sprint").Call(jen.Id(key))
+		if s, ok := unwrapPromise(v.KeyType).(*GoSimple); ok && s.Name == "string" && s.Import == "" {
+			keyExpr = jen.Id(key)
+		}
+		r.depth++
+		valChecks := r.renderType(v.ValueType, jen.Id(val), path.with(".", keyExpr))
+		r.depth--
+		if len(valChecks) > 0 {
+			res = append(res, jen.For(jen.List(jen.Id(key), jen.Id(val)).Op(":=").Range().Add(expr)).Block(valChecks...))
+		}
+	case *GoTypeAlias:
+		res = r.renderConstraints(v.Constraints, v, expr, path)
+		if !v.Transparent {
+			res = append(res, r.renderType(v.AliasedType, expr, path)...)
+		}
+	case *GoEnum:
+		res = r.renderConstraints(v.Constraints, v, expr, path)
+		verb := lo.Ternary(v.UnderlyingType.Name == "string", "%q", "%v")
+		res = append(res, jen.If(jen.Op("!").Add(expr).Dot("IsValid").Call()).Block(
+			jen.Id(validatorVarName).Dot("Add").Call(path.render(), jen.Lit("unknown "+v.Name+" value "+verb), expr),
+		))
+	}
+	return res
+}
+
+// renderTupleItem renders the checks of the tuple struct field, which is the array item at the field position.
+// The AdditionalItems field contains the items going after the fixed ones.
+func (r *validationRenderer) renderTupleItem(s *GoStruct, idx int, expr jen.Code, path validationPath) []jen.Code {
+	if !s.Tuple.Additional || idx < len(s.Fields)-1 {
+		return r.renderType(s.Fields[idx].Type, expr, path.with(fmt.Sprintf("[%d]", idx)))
+	}
+	arr := unwrapPromise(s.Fields[idx].Type).(*GoArray)
+	i, item := fmt.Sprintf("i%d", r.depth), fmt.Sprintf("item%d", r.depth)
+	r.depth++
+	itemChecks := r.renderType(arr.ItemsType, jen.Id(item), path.with("[", jen.Qual("strconv", "Itoa").Call(jen.Id(i).Op("+").Lit(idx)), "]"))
+	r.depth--
+	if len(itemChecks) == 0 {
+		return nil
+	}
+	return []jen.Code{jen.For(jen.List(jen.Id(i), jen.Id(item)).Op(":=").Range().Add(expr)).Block(itemChecks...)}
+}
+
+// renderConstraints renders the checks of constraints, that are applicable to a value of given type
+func (r *validationRenderer) renderConstraints(c *Constraints, typ common.GolangType, expr jen.Code, path validationPath) []jen.Code {
+	if c == nil {
+		return nil
+	}
+	var res []jen.Code
+	check := func(method string, args ...jen.Code) {
+		res = append(res, jen.Id(validatorVarName).Dot(method).Call(append([]jen.Code{path.render()}, args...)...))
+	}
+	number := func(n json.Number) jen.Code { return jen.Op(n.String()) }
+	length := func(n int) jen.Code { return jen.Lit(n) }
+	named := isNamedType(typ)
+
+	switch kind, typeName := underlyingKind(typ); kind {
+	case "string":
+		val := expr
+		if named || typeName != "string" {
+			val = jen.String().Call(expr)
+		}
+		if c.MinLength != nil {
+			check("MinLength", val, length(*c.MinLength))
+		}
+		if c.MaxLength != nil {
+			check("MaxLength", val, length(*c.MaxLength))
+		}
+		if c.Pattern != "" {
+			check("Pattern", val, jen.Lit(c.Pattern))
+		}
+	case "number":
+		val := expr
+		if named || typeName != "float64" {
+			val = jen.Float64().Call(expr)
+		}
+		if c.Minimum != nil {
+			check("Minimum", val, number(*c.Minimum), jen.False())
+		}
+		if c.ExclusiveMinimum != nil {
+			check("Minimum", val, number(*c.ExclusiveMinimum), jen.True())
+		}
+		if c.Maximum != nil {
+			check("Maximum", val, number(*c.Maximum), jen.False())
+		}
+		if c.ExclusiveMaximum != nil {
+			check("Maximum", val, number(*c.ExclusiveMaximum), jen.True())
+		}
+		if c.MultipleOf != nil {
+			check("MultipleOf", val, number(*c.MultipleOf))
+		}
+	case "array":
+		items := expr
+		if s, ok := typ.(*GoStruct); ok && s.Tuple != nil {
+			items = jen.Add(expr).Dot("items").Call()
+		}
+		if c.MinItems != nil {
+			check("MinItems", jen.Len(items), length(*c.MinItems))
+		}
+		if c.MaxItems != nil {
+			check("MaxItems", jen.Len(items), length(*c.MaxItems))
+		}
+		if c.UniqueItems {
+			check("UniqueItems", items)
+		}
+	case "object":
+		count := jen.Len(expr)
+		if _, ok := typ.(*GoMap); !ok {
+			count = jen.Qual(r.ctx.RuntimeModule(""), "PropertiesCount").Call(expr)
+		}
+		if c.MinProperties != nil {
+			check("MinProperties", count, length(*c.MinProperties))
+		}
+		if c.MaxProperties != nil {
+			check("MaxProperties", count, length(*c.MaxProperties))
+		}
+	}
+	return res
+}
+
+// underlyingKind returns the JSON Schema kind of type values the constraints are applicable to, and the Go type name
+// if type is a simple type
+func underlyingKind(typ common.GolangType) (kind, typeName string) {
+	switch v := typ.(type) {
+	case *GolangTypePromise:
+		if v.Assigned() {
+			return underlyingKind(v.Target())
+		}
+	case *GoTypeAlias:
+		if !v.Transparent {
+			return underlyingKind(v.AliasedType)
+		}
+	case *GoEnum:
+		return underlyingKind(v.UnderlyingType)
+	case *GoSimple:
+		if v.Import != "" {
+			return "", ""
+		}
+		switch v.Name {
+		case "string":
+			return "string", v.Name
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+			return "number", v.Name
+		}
+	case *GoArray:
+		return "array", ""
+	case *GoStruct:
+		return lo.Ternary(v.Tuple != nil, "array", "object"), ""
+	case *GoMap, *UnionStruct:
+		return "object", ""
+	}
+	return "", ""
+}
+
+func hasValidateMethod(typ common.GolangType) bool {
+	if v, ok := unwrapPromise(typ).(validatedType); ok {
+		return v.hasValidateMethod()
+	}
+	return false
+}
+
+// isTypeNilable returns true if the value of type can be compared with nil
+func isTypeNilable(typ common.GolangType) bool {
+	switch v := typ.(type) {
+	case *GolangTypePromise:
+		return v.Assigned() && isTypeNilable(v.Target())
+	case *GoPointer:
+		return v.isPointer() || isTypeNilable(v.Type)
+	case *GoOptional:
+		return !v.isOptional() && isTypeNilable(v.Type)
+	case *GoTypeAlias:
+		return !v.Transparent && isTypeNilable(v.AliasedType)
+	case *GoArray:
+		return v.Size == 0
+	case *GoMap, *GoInterface:
+		return true
+	case *GoSimple:
+		return v.IsIface
+	}
+	return false
+}
+
+// isTypeNullable returns true if type is nullable in schema, so its nil or unset value is valid
+func isTypeNullable(typ common.GolangType) bool {
+	switch v := unwrapPromise(typ).(type) {
+	case *GoPointer:
+		return v.Nullable || isTypeNullable(v.Type)
+	case *GoOptional:
+		return true
+	}
+	return false
+}
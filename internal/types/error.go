@@ -6,16 +6,25 @@ import (
 )
 
 type CompileError struct {
-	Err   error
-	Path  string
-	Proto string
+	Err    error
+	Path   string
+	Proto  string
+	Source *SourcePosition // Position in spec file the Path points to, nil if unknown
 }
 
 func (c CompileError) Error() string {
+	var prefix string
+	if c.Source != nil {
+		prefix = c.Source.String() + ": "
+	}
 	if c.Proto != "" {
-		return fmt.Sprintf("path=%q proto=%q: %v", c.Path, c.Proto, c.Err)
+		return fmt.Sprintf("%spath=%q proto=%q: %v", prefix, c.Path, c.Proto, c.Err)
 	}
-	return fmt.Sprintf("path=%q: %v", c.Path, c.Err)
+	return fmt.Sprintf("%spath=%q: %v", prefix, c.Path, c.Err)
+}
+
+func (c CompileError) SourcePosition() *SourcePosition {
+	return c.Source
 }
 
 func (c CompileError) Unwrap() error {
@@ -73,11 +73,10 @@ func (o *OrderedMap[K, V]) Set(key K, value V) {
 	if o.data == nil {
 		o.data = make(map[K]V)
 	}
-	if _, ok := o.data[key]; ok {
-		o.keys = lo.DropWhile(o.keys, func(item K) bool { return item == key })
+	if _, ok := o.data[key]; !ok {
+		o.keys = append(o.keys, key)
 	}
 	o.data[key] = value
-	o.keys = append(o.keys, key)
 }
 
 func (o *OrderedMap[K, V]) Delete(key K) bool {
@@ -88,7 +87,7 @@ func (o *OrderedMap[K, V]) Delete(key K) bool {
 		return false
 	}
 
-	o.keys = lo.DropWhile(o.keys, func(item K) bool { return item == key })
+	o.keys = lo.Without(o.keys, key)
 	delete(o.data, key)
 	return true
 }
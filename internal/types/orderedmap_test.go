@@ -0,0 +1,49 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderedMapSetDelete(t *testing.T) {
+	type op struct {
+		del   bool
+		key   string
+		value int
+	}
+	tests := []struct {
+		name       string
+		ops        []op
+		wantKeys   []string
+		wantValues []int
+	}{
+		{"set keeps insertion order", []op{{key: "b", value: 1}, {key: "a", value: 2}, {key: "c", value: 3}}, []string{"b", "a", "c"}, []int{1, 2, 3}},
+		{"set existing key keeps its position", []op{{key: "a", value: 1}, {key: "b", value: 2}, {key: "a", value: 3}}, []string{"a", "b"}, []int{3, 2}},
+		{"delete first key", []op{{key: "a", value: 1}, {key: "b", value: 2}, {del: true, key: "a"}}, []string{"b"}, []int{2}},
+		{"delete middle key", []op{{key: "a", value: 1}, {key: "b", value: 2}, {key: "c", value: 3}, {del: true, key: "b"}}, []string{"a", "c"}, []int{1, 3}},
+		{"delete unknown key", []op{{key: "a", value: 1}, {del: true, key: "x"}}, []string{"a"}, []int{1}},
+		{"set deleted key appends it", []op{{key: "a", value: 1}, {key: "b", value: 2}, {del: true, key: "a"}, {key: "a", value: 3}}, []string{"b", "a"}, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m OrderedMap[string, int]
+			for _, o := range tt.ops {
+				if o.del {
+					_, existed := m.Get(o.key)
+					if got := m.Delete(o.key); got != existed {
+						t.Errorf("expect Delete(%q) to return %v, got %v", o.key, existed, got)
+					}
+					continue
+				}
+				m.Set(o.key, o.value)
+			}
+			values := make([]int, 0, m.Len())
+			for _, e := range m.Entries() {
+				values = append(values, e.Value)
+			}
+			if !reflect.DeepEqual(m.Keys(), tt.wantKeys) || !reflect.DeepEqual(values, tt.wantValues) || m.Len() != len(tt.wantKeys) {
+				t.Errorf("expect %q %v, got %q %v", tt.wantKeys, tt.wantValues, m.Keys(), values)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// snippetContextLines is a number of source lines shown in snippet before the line with error
+const snippetContextLines = 2
+
+// SourcePosition is a position of an entity in a spec file
+type SourcePosition struct {
+	File    string
+	Line    int    // 1-based
+	Column  int    // 1-based
+	Pointer string // JSON pointer to the entity in file, e.g. "#/components/schemas/Foo". May be empty.
+
+	source []byte // Contents of the whole file, to make a snippet
+}
+
+// NewSourcePosition returns the position in the file with given contents
+func NewSourcePosition(file string, line, column int, source []byte) *SourcePosition {
+	return &SourcePosition{File: file, Line: line, Column: column, source: source}
+}
+
+func (p SourcePosition) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Snippet returns the source line at the position with several lines before it and the caret pointing to the
+// column below. Returns empty string if file contents are unknown.
+func (p SourcePosition) Snippet() string {
+	lines := bytes.Split(p.source, []byte("\n"))
+	if p.Line < 1 || p.Line > len(lines) {
+		return ""
+	}
+	numWidth := len(fmt.Sprint(p.Line))
+	var b strings.Builder
+	first := p.Line - snippetContextLines
+	if first < 1 {
+		first = 1
+	}
+	for i := first; i <= p.Line; i++ {
+		fmt.Fprintf(&b, "%*d | %s\n", numWidth, i, strings.TrimRight(string(lines[i-1]), "\r"))
+	}
+	// Keep tabs in indent, so that the caret is aligned the same way as the line above
+	var indent strings.Builder
+	for i, r := range []rune(string(lines[p.Line-1])) {
+		if i >= p.Column-1 {
+			break
+		}
+		indent.WriteRune(lo.Ternary(r == '\t', '\t', ' '))
+	}
+	fmt.Fprintf(&b, "%*s | %s^", numWidth, "", indent.String())
+	return b.String()
+}
+
+// ErrorSource returns the position in spec file the error refers to, nil if error has no position
+func ErrorSource(err error) *SourcePosition {
+	var e interface{ SourcePosition() *SourcePosition }
+	if errors.As(err, &e) {
+		return e.SourcePosition()
+	}
+	return nil
+}
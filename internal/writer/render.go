@@ -16,6 +16,7 @@ import (
 	"github.com/xcnt/go-asyncapi/internal/utils"
 
 	"github.com/xcnt/go-asyncapi/internal/common"
+	"github.com/xcnt/go-asyncapi/internal/specurl"
 	"github.com/dave/jennifer/jen"
 )
 
@@ -53,6 +54,26 @@ func (e MultilineError) RestLines() string {
 	return bld.String()
 }
 
+// RenderError is a panic occurred during an object rendering
+type RenderError struct {
+	Object string
+	Path   string                // Ref to the object in spec, e.g. "#/components/schemas/Foo"
+	Source *types.SourcePosition // Position in spec file the object is defined at, nil if unknown
+	Panic  any
+	Stack  []byte
+}
+
+func (e RenderError) Error() string {
+	if e.Source != nil {
+		return fmt.Sprintf("%s: %s: %v\n%s", e.Source, e.Object, e.Panic, e.Stack)
+	}
+	return fmt.Sprintf("%s: %v\n%s", e.Object, e.Panic, e.Stack)
+}
+
+func (e RenderError) SourcePosition() *types.SourcePosition {
+	return e.Source
+}
+
 type renderSource interface {
 	PackageObjects(pkgName string) []compiler.Object
 	Packages() []string
@@ -99,6 +120,10 @@ func RenderPackages(source renderSource, protoRenderers map[string]common.Protoc
 			if !ok {
 				f = jen.NewFilePathName(opts.ImportBase, targetPkg)
 				f.HeaderComment(GeneratedCodePreamble)
+				// Package names differ from the last import path item
+				f.ImportName("github.com/hamba/avro/v2", "avro")
+				f.ImportName("github.com/fxamacker/cbor/v2", "cbor")
+				f.ImportName("github.com/pierrec/lz4/v4", "lz4")
 			}
 
 			rendered++
@@ -107,7 +132,7 @@ func RenderPackages(source renderSource, protoRenderers map[string]common.Protoc
 				// catch panics produced by rendering
 				defer func() {
 					if r := recover(); r != nil {
-						err = fmt.Errorf("%s: %s\n%v", item.Object.String(), debug.Stack(), r)
+						err = RenderError{Object: item.Object.String(), Path: specurl.BuildRef(item.Path...), Source: item.Source, Panic: r, Stack: debug.Stack()}
 					}
 				}()
 				for _, stmt := range item.Object.RenderDefinition(ctx) {
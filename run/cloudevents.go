@@ -0,0 +1,343 @@
+package run
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CloudEventsSpecVersion is the CloudEvents specification version the events are produced in
+const CloudEventsSpecVersion = "1.0"
+
+// CloudEventsContentType is the content type of event in structured content mode, that contains both the attributes
+// and the data
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEventsHTTPHeaderPrefix is the prefix of attribute headers in HTTP binary content mode. The HTTP protocol binding
+// requires the header values to be percent-encoded, so Headers and CloudEventFromHeaders do it for this prefix.
+const CloudEventsHTTPHeaderPrefix = "ce-"
+
+// nonCloudEventHeaders are the runtime headers that are never attributes, even if their names look like ones
+var nonCloudEventHeaders = []string{MessageIDHeader}
+
+// CloudEvent contains the CloudEvents context attributes of a message. The generated message structs have it if
+// the code is generated with --cloudevents flag. See https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md
+type CloudEvent struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	DataContentType string
+	DataSchema      string
+	Subject         string
+	Time            time.Time
+	Extensions      map[string]string // Extension attributes, the names are lowercase alphanumeric
+}
+
+// SetDefaults fills the empty attributes: id is set to a random UUID, time to the current time, and the rest to
+// the given values
+func (e *CloudEvent) SetDefaults(eventType, source, dataContentType string) {
+	if e.ID == "" {
+		e.ID = newUUID().String()
+	}
+	if e.SpecVersion == "" {
+		e.SpecVersion = CloudEventsSpecVersion
+	}
+	if e.Type == "" {
+		e.Type = eventType
+	}
+	if e.Source == "" {
+		e.Source = source
+	}
+	if e.DataContentType == "" {
+		e.DataContentType = dataContentType
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+}
+
+// Validate checks that the required attributes are set
+func (e CloudEvent) Validate() error {
+	var missing []string
+	for _, attr := range [][2]string{{"id", e.ID}, {"source", e.Source}, {"specversion", e.SpecVersion}, {"type", e.Type}} {
+		if attr[1] == "" {
+			missing = append(missing, attr[0])
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cloudevent required attributes are not set: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// attributes returns the event attributes by their names, the empty ones are omitted
+func (e CloudEvent) attributes() map[string]string {
+	res := make(map[string]string, 8+len(e.Extensions))
+	for k, v := range e.Extensions {
+		res[k] = v
+	}
+	for k, v := range map[string]string{
+		"id":              e.ID,
+		"source":          e.Source,
+		"specversion":     e.SpecVersion,
+		"type":            e.Type,
+		"datacontenttype": e.DataContentType,
+		"dataschema":      e.DataSchema,
+		"subject":         e.Subject,
+	} {
+		if v != "" {
+			res[k] = v
+		}
+	}
+	if !e.Time.IsZero() {
+		res["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+	return res
+}
+
+func (e *CloudEvent) setAttribute(name, value string) error {
+	switch name {
+	case "id":
+		e.ID = value
+	case "source":
+		e.Source = value
+	case "specversion":
+		e.SpecVersion = value
+	case "type":
+		e.Type = value
+	case "datacontenttype":
+		e.DataContentType = value
+	case "dataschema":
+		e.DataSchema = value
+	case "subject":
+		e.Subject = value
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("cloudevent time: %w", err)
+		}
+		e.Time = t
+	default:
+		if e.Extensions == nil {
+			e.Extensions = make(map[string]string)
+		}
+		e.Extensions[name] = value
+	}
+	return nil
+}
+
+// Headers returns the event attributes as headers with a given prefix for binary content mode, e.g. "ce_" for Kafka
+// or "ce-" for HTTP. The datacontenttype attribute is omitted, since it's carried by the content type of message.
+// The values are percent-encoded for CloudEventsHTTPHeaderPrefix.
+func (e CloudEvent) Headers(prefix string) Headers {
+	attrs := e.attributes()
+	delete(attrs, "datacontenttype")
+	res := make(Headers, len(attrs))
+	for k, v := range attrs {
+		if strings.EqualFold(prefix, CloudEventsHTTPHeaderPrefix) {
+			v = percentEncode(v)
+		}
+		res[prefix+k] = v
+	}
+	return res
+}
+
+// CloudEventFromHeaders returns the event from headers with a given prefix in binary content mode. The header names
+// are compared case-insensitively. The datacontenttype attribute is taken from the Content-Type header if any.
+//
+// With the empty prefix (MQTT 5 user properties) every lowercase alphanumeric header is an attribute, so the headers
+// that are not, e.g. the message headers, should be passed in ignore.
+func CloudEventFromHeaders(headers Headers, prefix string, ignore ...string) (CloudEvent, error) {
+	var res CloudEvent
+	prefix = strings.ToLower(prefix)
+	ignored := make(map[string]struct{}, len(ignore)+len(nonCloudEventHeaders))
+	for _, k := range ignore {
+		ignored[strings.ToLower(k)] = struct{}{}
+	}
+	for _, k := range nonCloudEventHeaders {
+		ignored[strings.ToLower(k)] = struct{}{}
+	}
+	for k := range headers {
+		name := strings.ToLower(k)
+		if _, ok := ignored[name]; ok {
+			continue
+		}
+		if !strings.HasPrefix(name, prefix) || !isCloudEventAttributeName(strings.TrimPrefix(name, prefix)) {
+			continue
+		}
+		v, ok := headers.GetString(k)
+		if !ok {
+			continue
+		}
+		if prefix == CloudEventsHTTPHeaderPrefix {
+			var err error
+			if v, err = url.PathUnescape(v); err != nil {
+				return res, fmt.Errorf("cloudevent header %s: %w", k, err)
+			}
+		}
+		if err := res.setAttribute(strings.TrimPrefix(name, prefix), v); err != nil {
+			return res, err
+		}
+	}
+	if v, ok := headers.GetString("Content-Type"); ok && res.DataContentType == "" {
+		res.DataContentType = v
+	}
+	return res, nil
+}
+
+// MarshalStructuredCloudEvent writes the event with data in structured content mode, i.e. in JSON format. The data
+// is written as JSON value if the data content type is JSON, otherwise it's base64-encoded.
+func MarshalStructuredCloudEvent(w io.Writer, e CloudEvent, data []byte) error {
+	res := make(map[string]any)
+	for k, v := range e.attributes() {
+		res[k] = v
+	}
+	switch {
+	case len(data) == 0:
+	case isJSONContentType(e.DataContentType) && json.Valid(data):
+		res["data"] = json.RawMessage(data)
+	default:
+		res["data_base64"] = base64.StdEncoding.EncodeToString(data)
+	}
+	return json.NewEncoder(w).Encode(res)
+}
+
+// UnmarshalStructuredCloudEvent reads the event in structured content mode and returns it with its data
+func UnmarshalStructuredCloudEvent(r io.Reader) (CloudEvent, []byte, error) {
+	var res CloudEvent
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return res, nil, fmt.Errorf("decode cloudevent: %w", err)
+	}
+
+	var data []byte
+	for k, v := range raw {
+		switch k {
+		case "data":
+			data = v
+		case "data_base64":
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				return res, nil, fmt.Errorf("cloudevent data_base64: %w", err)
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return res, nil, fmt.Errorf("cloudevent data_base64: %w", err)
+			}
+			data = b
+		default:
+			value, ok, err := cloudEventAttributeValue(k, v)
+			if err != nil {
+				return res, nil, fmt.Errorf("cloudevent attribute %s: %w", k, err)
+			}
+			if !ok {
+				continue
+			}
+			if err = res.setAttribute(k, value); err != nil {
+				return res, nil, err
+			}
+		}
+	}
+	if _, ok := raw["data"]; ok && !isJSONContentType(res.DataContentType) {
+		// Non-JSON data is written as JSON string
+		var s string
+		if err := json.Unmarshal(data, &s); err == nil {
+			data = []byte(s)
+		}
+	}
+	if res.SpecVersion == "" {
+		return res, nil, errors.New("decode cloudevent: specversion is not set")
+	}
+	return res, data, nil
+}
+
+// cloudEventAttributeValue returns the string representation of attribute value in structured content mode, according
+// to the CloudEvents type system. The context attributes are strings, the extension attributes may be booleans and
+// integers as well. Returns false if the value is null, which means the attribute is not set.
+func cloudEventAttributeValue(name string, data json.RawMessage) (string, bool, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return "", false, err
+	}
+	var isContext bool
+	switch name {
+	case "id", "source", "specversion", "type", "datacontenttype", "dataschema", "subject", "time":
+		isContext = true
+	}
+	switch val := v.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return val, true, nil
+	case bool:
+		if !isContext {
+			return strconv.FormatBool(val), true, nil
+		}
+	case json.Number:
+		// Integer is 32-bit signed in CloudEvents type system
+		if i, err := strconv.ParseInt(val.String(), 10, 32); err == nil && !isContext {
+			return strconv.FormatInt(i, 10), true, nil
+		}
+	}
+	return "", false, fmt.Errorf("unsupported value %s", data)
+}
+
+// isCloudEventAttributeName returns true if name consists of lowercase letters and digits, as the attribute names do
+func isCloudEventAttributeName(name string) bool {
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// percentEncode encodes the space, double quote, percent and the characters outside of printable ASCII range in
+// UTF-8 representation, as the CloudEvents HTTP protocol binding requires for header values
+func percentEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isJSONContentType returns true for JSON content types, the empty content type is JSON by default
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() UUID {
+	var res UUID
+	if _, err := rand.Read(res[:]); err != nil {
+		panic(fmt.Sprintf("Cannot generate UUID: %v", err))
+	}
+	res[6] = res[6]&0x0f | 0x40
+	res[8] = res[8]&0x3f | 0x80
+	return res
+}
@@ -0,0 +1,197 @@
+package run
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCloudEventBinary(t *testing.T) {
+	event := CloudEvent{
+		ID:          "1",
+		Source:      "/orders",
+		SpecVersion: CloudEventsSpecVersion,
+		Type:        "orderPlaced",
+		Time:        time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC),
+		Extensions:  map[string]string{"traceparent": "00-abc"},
+	}
+	headers := event.Headers("ce-")
+	if v, _ := headers.GetString("ce-time"); v != "2024-02-29T10:00:00Z" {
+		t.Errorf("unexpected ce-time %q", v)
+	}
+
+	// Headers may come canonicalized, e.g. in HTTP
+	received := Headers{"Content-Type": "application/json", "Other": "x"}
+	for k, v := range headers {
+		received["C"+k[1:]] = []byte(v.(string))
+	}
+	got, err := CloudEventFromHeaders(received, "ce-")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	event.DataContentType = "application/json"
+	if !reflect.DeepEqual(got, event) {
+		t.Errorf("expect %+v, got %+v", event, got)
+	}
+}
+
+func TestCloudEventFromHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers Headers
+		prefix  string
+		ignore  []string
+		want    CloudEvent
+		wantErr string
+	}{
+		{
+			"kafka content type",
+			Headers{"ce_id": "1", "content-type": "text/plain"},
+			"ce_",
+			nil,
+			CloudEvent{ID: "1", DataContentType: "text/plain"},
+			"",
+		},
+		{
+			"http percent-encoded",
+			Headers{"ce-id": "1", "ce-subject": "order%20%22%C3%A9%22%25"},
+			"ce-",
+			nil,
+			CloudEvent{ID: "1", Subject: `order "é"%`},
+			"",
+		},
+		{
+			"http bad percent-encoding",
+			Headers{"ce-subject": "100%"},
+			"ce-",
+			nil,
+			CloudEvent{},
+			`cloudevent header ce-subject: invalid URL escape "%"`,
+		},
+		{
+			"kafka not percent-encoded",
+			Headers{"ce_subject": "100%25"},
+			"ce_",
+			nil,
+			CloudEvent{Subject: "100%25"},
+			"",
+		},
+		{
+			"mqtt message headers ignored",
+			Headers{"id": "1", "traceid": "abc", "messageId": "orderPlaced", "Content-Encoding": "gzip", "tenant": "x"},
+			"",
+			[]string{"tenant"},
+			CloudEvent{ID: "1", Extensions: map[string]string{"traceid": "abc"}},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CloudEventFromHeaders(tt.headers, tt.prefix, tt.ignore...)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expect error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expect %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	headers := CloudEvent{ID: "1", Subject: `order "é"%`}.Headers(CloudEventsHTTPHeaderPrefix)
+	if v, _ := headers.GetString("ce-subject"); v != "order%20%22%C3%A9%22%25" {
+		t.Errorf("unexpected ce-subject %q", v)
+	}
+}
+
+func TestCloudEventStructured(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        string
+		want        string
+	}{
+		{"json", "application/json", `{"a":1}`, `"data":{"a":1}`},
+		{"binary", "application/octet-stream", "\x00\x01", `"data_base64":"AAE="`},
+		{"empty", "application/json", "", `"specversion":"1.0"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := CloudEvent{Source: "/orders", Type: "orderPlaced"}
+			event.SetDefaults("ignored", "ignored", tt.contentType)
+			if err := event.Validate(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			event.Time = event.Time.Truncate(time.Microsecond)
+
+			var buf bytes.Buffer
+			if err := MarshalStructuredCloudEvent(&buf, event, []byte(tt.data)); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !bytes.Contains(buf.Bytes(), []byte(tt.want)) {
+				t.Errorf("expect %s in %s", tt.want, buf.String())
+			}
+
+			got, data, err := UnmarshalStructuredCloudEvent(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !got.Time.Equal(event.Time) {
+				t.Errorf("expect time %v, got %v", event.Time, got.Time)
+			}
+			got.Time = event.Time
+			if !reflect.DeepEqual(got, event) {
+				t.Errorf("expect %+v, got %+v", event, got)
+			}
+			if string(data) != tt.data {
+				t.Errorf("expect data %q, got %q", tt.data, data)
+			}
+		})
+	}
+
+	if err := (CloudEvent{ID: "1", SpecVersion: CloudEventsSpecVersion}).Validate(); err == nil || err.Error() != "cloudevent required attributes are not set: source, type" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestUnmarshalStructuredCloudEventAttributes(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes string
+		want       map[string]string
+		wantErr    string
+	}{
+		{"string", `"ext":"a"`, map[string]string{"ext": "a"}, ""},
+		{"boolean", `"ext":true`, map[string]string{"ext": "true"}, ""},
+		{"integer", `"ext":1234567`, map[string]string{"ext": "1234567"}, ""},
+		{"null", `"ext":null`, nil, ""},
+		{"float", `"ext":1.5`, nil, "cloudevent attribute ext: unsupported value 1.5"},
+		{"integer out of range", `"ext":4294967296`, nil, "cloudevent attribute ext: unsupported value 4294967296"},
+		{"object", `"ext":{"a":1}`, nil, `cloudevent attribute ext: unsupported value {"a":1}`},
+		{"context attribute not string", `"subject":1`, nil, "cloudevent attribute subject: unsupported value 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"specversion":"1.0","id":"1","source":"/orders","type":"orderPlaced",` + tt.attributes + `}`
+			got, _, err := UnmarshalStructuredCloudEvent(bytes.NewBufferString(data))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expect error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(got.Extensions, tt.want) {
+				t.Errorf("expect extensions %v, got %v", tt.want, got.Extensions)
+			}
+		})
+	}
+}
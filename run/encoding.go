@@ -0,0 +1,182 @@
+package run
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+// ContentTypeKeys returns the keys the encoder for content type is looked up by, in order of priority: the content
+// type as is, the media type without parameters and the structured syntax suffix pattern. E.g. for
+// "application/cloudevents+json; charset=utf-8" these are the content type itself, "application/cloudevents+json"
+// and "*+json".
+func ContentTypeKeys(contentType string) []string {
+	res := []string{contentType}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	if mediaType != contentType {
+		res = append(res, mediaType)
+	}
+	if _, subtype, ok := strings.Cut(mediaType, "/"); ok {
+		if idx := strings.LastIndex(subtype, "+"); idx >= 0 {
+			res = append(res, "*"+subtype[idx:])
+		}
+	}
+	return res
+}
+
+// LookupContentType returns the map value for the first of ContentTypeKeys found in the map
+func LookupContentType[T any](m map[string]T, contentType string) (T, bool) {
+	for _, key := range ContentTypeKeys(contentType) {
+		if v, ok := m[key]; ok {
+			return v, true
+		}
+	}
+	return *new(T), false
+}
+
+// TextEncoder writes the values as plain text. Strings and byte slices are written as is, encoding.TextMarshaler
+// as the text it returns, other values in fmt default format.
+type TextEncoder struct {
+	w io.Writer
+}
+
+func NewTextEncoder(w io.Writer) *TextEncoder {
+	return &TextEncoder{w: w}
+}
+
+func (e *TextEncoder) Encode(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		if _, ok := rv.Interface().(encoding.TextMarshaler); ok {
+			break
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil // Nothing to write
+	}
+
+	var data []byte
+	switch val := rv.Interface().(type) {
+	case encoding.TextMarshaler:
+		var err error
+		if data, err = val.MarshalText(); err != nil {
+			return err
+		}
+	case []byte:
+		data = val
+	default:
+		if rv.Kind() == reflect.String {
+			data = []byte(rv.String())
+		} else {
+			data = []byte(fmt.Sprint(val))
+		}
+	}
+	_, err := e.w.Write(data)
+	return err
+}
+
+// TextDecoder reads all the data as plain text. The target may be a string or byte slice, encoding.TextUnmarshaler
+// or a value fmt.Sscan can parse, such as number or bool.
+type TextDecoder struct {
+	r io.Reader
+}
+
+func NewTextDecoder(r io.Reader) *TextDecoder {
+	return &TextDecoder{r: r}
+}
+
+func (d *TextDecoder) Decode(v any) error {
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return err
+	}
+	switch target := v.(type) {
+	case encoding.TextUnmarshaler:
+		return target.UnmarshalText(data)
+	case *[]byte:
+		*target = data
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cannot decode text to %T, expected non-nil pointer", v)
+	}
+	if elem := rv.Elem(); elem.Kind() == reflect.String {
+		elem.SetString(string(data))
+		return nil
+	}
+	_, err = fmt.Sscan(string(data), v)
+	return err
+}
+
+// RawEncoder writes the byte slices and strings as is, it's used for `application/octet-stream` content type
+type RawEncoder struct {
+	w io.Writer
+}
+
+func NewRawEncoder(w io.Writer) *RawEncoder {
+	return &RawEncoder{w: w}
+}
+
+func (e *RawEncoder) Encode(v any) error {
+	rv := reflect.ValueOf(v)
+	for (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return nil // Nothing to write
+	}
+
+	var data []byte
+	switch {
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		data = rv.Bytes()
+	case rv.Kind() == reflect.String:
+		data = []byte(rv.String())
+	default:
+		return fmt.Errorf("cannot encode %T as raw bytes, expected byte slice or string", v)
+	}
+	_, err := e.w.Write(data)
+	return err
+}
+
+// RawDecoder reads all the data as is to a byte slice or string
+type RawDecoder struct {
+	r io.Reader
+}
+
+func NewRawDecoder(r io.Reader) *RawDecoder {
+	return &RawDecoder{r: r}
+}
+
+func (d *RawDecoder) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cannot decode raw bytes to %T, expected non-nil pointer", v)
+	}
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return err
+	}
+
+	elem := rv.Elem()
+	switch {
+	case elem.Kind() == reflect.Interface && elem.NumMethod() == 0:
+		elem.Set(reflect.ValueOf(data))
+	case elem.Kind() == reflect.Slice && elem.Type().Elem().Kind() == reflect.Uint8:
+		elem.SetBytes(data)
+	case elem.Kind() == reflect.String:
+		elem.SetString(string(data))
+	default:
+		return fmt.Errorf("cannot decode raw bytes to %T, expected byte slice or string pointer", v)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package run
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContentTypeKeys(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        []string
+	}{
+		{"application/json", []string{"application/json"}},
+		{"application/json; charset=utf-8", []string{"application/json; charset=utf-8", "application/json"}},
+		{"application/cloudevents+json", []string{"application/cloudevents+json", "*+json"}},
+		{"Application/Vnd.Foo+YAML;v=1", []string{"Application/Vnd.Foo+YAML;v=1", "application/vnd.foo+yaml", "*+yaml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := ContentTypeKeys(tt.contentType); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expect %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLookupContentType(t *testing.T) {
+	m := map[string]string{"application/json": "json", "*+json": "suffix", "application/x; v=1": "exact"}
+	tests := []struct {
+		contentType string
+		want        string
+		wantOk      bool
+	}{
+		{"application/json; charset=utf-8", "json", true},
+		{"application/cloudevents+json", "suffix", true},
+		{"application/x; v=1", "exact", true},
+		{"application/x", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, ok := LookupContentType(m, tt.contentType)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("expect %q %v, got %q %v", tt.want, tt.wantOk, got, ok)
+			}
+		})
+	}
+}
+
+func TestTextEncoding(t *testing.T) {
+	type name string
+	var date Date
+	if err := date.UnmarshalText([]byte("2024-02-29")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		value any
+		text  string
+	}{
+		{"string", "hello", "hello"},
+		{"named string", name("bob"), "bob"},
+		{"int", 42, "42"},
+		{"text marshaler", date, "2024-02-29"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ptr := reflect.New(reflect.TypeOf(tt.value))
+			ptr.Elem().Set(reflect.ValueOf(tt.value))
+			for _, v := range []any{tt.value, ptr.Interface()} {
+				var buf bytes.Buffer
+				if err := NewTextEncoder(&buf).Encode(v); err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if buf.String() != tt.text {
+					t.Errorf("expect %q, got %q", tt.text, buf.String())
+				}
+			}
+
+			target := reflect.New(reflect.TypeOf(tt.value))
+			if err := NewTextDecoder(strings.NewReader(tt.text)).Decode(target.Interface()); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got := target.Elem().Interface(); !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("expect %v, got %v", tt.value, got)
+			}
+		})
+	}
+}
+
+func TestRawEncoding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"bytes", []byte{0, 1, 0xff}},
+		{"named bytes", Bytes("abc")},
+		{"string", "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewRawEncoder(&buf).Encode(&tt.value); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			target := reflect.New(reflect.TypeOf(tt.value))
+			if err := NewRawDecoder(&buf).Decode(target.Interface()); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got := target.Elem().Interface(); !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("expect %v, got %v", tt.value, got)
+			}
+		})
+	}
+
+	if err := NewRawEncoder(io.Discard).Encode(42); err == nil {
+		t.Error("expect error on encoding a number")
+	}
+}
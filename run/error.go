@@ -2,4 +2,7 @@ package run
 
 import "errors"
 
-var ErrEmptyServers = errors.New("empty servers list")
+var (
+	ErrEmptyServers   = errors.New("empty servers list")
+	ErrUnknownMessage = errors.New("unknown message")
+)
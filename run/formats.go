@@ -0,0 +1,204 @@
+package run
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UUID is a value of JSON Schema `uuid` string format, e.g. "123e4567-e89b-12d3-a456-426614174000"
+type UUID [16]byte
+
+// ParseUUID parses the UUID in canonical 8-4-4-4-12 hex form, optionally enclosed in braces or prefixed with "urn:uuid:"
+func ParseUUID(s string) (UUID, error) {
+	var res UUID
+	s = strings.TrimPrefix(s, "urn:uuid:")
+	if len(s) == 38 && s[0] == '{' && s[37] == '}' {
+		s = s[1:37]
+	}
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return res, fmt.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.Decode(res[:], []byte(s[0:8]+s[9:13]+s[14:18]+s[19:23]+s[24:])); err != nil {
+		return res, fmt.Errorf("invalid UUID %q: %w", s, err)
+	}
+	return res, nil
+}
+
+func (u UUID) String() string {
+	b := hex.EncodeToString(u[:])
+	return b[0:8] + "-" + b[8:12] + "-" + b[12:16] + "-" + b[16:20] + "-" + b[20:]
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) (err error) {
+	*u, err = ParseUUID(string(text))
+	return
+}
+
+// URL is a value of JSON Schema `uri`, `uri-reference`, `iri` and `iri-reference` string formats
+type URL struct {
+	url.URL
+}
+
+func (u URL) String() string {
+	return u.URL.String()
+}
+
+func (u URL) MarshalText() ([]byte, error) {
+	return []byte(u.URL.String()), nil
+}
+
+func (u *URL) UnmarshalText(text []byte) error {
+	v, err := url.Parse(string(text))
+	if err != nil {
+		return err
+	}
+	u.URL = *v
+	return nil
+}
+
+// Date is a value of JSON Schema `date` string format, e.g. "2006-01-02"
+type Date struct {
+	time.Time
+}
+
+const DateLayout = time.DateOnly
+
+func (d Date) String() string {
+	return d.Time.Format(DateLayout)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.Time.Format(DateLayout)), nil
+}
+
+func (d *Date) UnmarshalText(text []byte) (err error) {
+	d.Time, err = time.Parse(DateLayout, string(text))
+	return
+}
+
+// MarshalJSON overrides the method of embedded time.Time
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON overrides the method of embedded time.Time
+func (d *Date) UnmarshalJSON(data []byte) error {
+	s, err := strconv.Unquote(string(data))
+	if err != nil {
+		return fmt.Errorf("invalid date %s: %w", data, err)
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// Bytes is a value of OpenAPI `byte` and `binary` string formats, which is encoded as base64 string both in JSON
+// and YAML (unlike []byte, which YAML encodes as a list of numbers)
+type Bytes []byte
+
+func (b Bytes) MarshalText() ([]byte, error) {
+	res := make([]byte, base64.StdEncoding.EncodedLen(len(b)))
+	base64.StdEncoding.Encode(res, b)
+	return res, nil
+}
+
+func (b *Bytes) UnmarshalText(text []byte) error {
+	res := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(res, text)
+	if err != nil {
+		return err
+	}
+	*b = res[:n]
+	return nil
+}
+
+// Duration is a value of JSON Schema `duration` string format, which is ISO 8601 duration, e.g. "P1DT2H30M".
+// Years and months are not supported, since they have no fixed length.
+type Duration time.Duration
+
+// ParseDuration parses the ISO 8601 duration, e.g. "PT1H30M", "P2W", "-P1DT0.5S". A day is considered as 24 hours.
+func ParseDuration(s string) (Duration, error) {
+	orig := s
+	var sign time.Duration = 1
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", orig)
+	}
+	s = s[1:]
+
+	var res float64
+	units := map[byte]float64{'W': float64(7 * 24 * time.Hour), 'D': float64(24 * time.Hour)}
+	for s != "" {
+		if s[0] == 'T' {
+			units = map[byte]float64{'H': float64(time.Hour), 'M': float64(time.Minute), 'S': float64(time.Second)}
+			s = s[1:]
+			continue
+		}
+		i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != ',' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", orig)
+		}
+		unit, ok := units[s[i]]
+		if !ok {
+			return 0, fmt.Errorf("invalid or unsupported unit %q in ISO 8601 duration %q", s[i], orig)
+		}
+		n, err := strconv.ParseFloat(strings.ReplaceAll(s[:i], ",", "."), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", orig, err)
+		}
+		res += n * unit
+		delete(units, s[i]) // Every unit may appear once
+		s = s[i+1:]
+	}
+	if res > math.MaxInt64 {
+		return 0, fmt.Errorf("ISO 8601 duration %q is out of range", orig)
+	}
+	return Duration(sign * time.Duration(res)), nil
+}
+
+func (d Duration) String() string {
+	v := time.Duration(d)
+	if v == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	if v < 0 {
+		b.WriteByte('-')
+		v = -v
+	}
+	b.WriteString("PT")
+	if h := v / time.Hour; h > 0 {
+		b.WriteString(strconv.FormatInt(int64(h), 10) + "H")
+		v -= h * time.Hour
+	}
+	if m := v / time.Minute; m > 0 {
+		b.WriteString(strconv.FormatInt(int64(m), 10) + "M")
+		v -= m * time.Minute
+	}
+	if v > 0 {
+		b.WriteString(strconv.FormatFloat(v.Seconds(), 'f', -1, 64) + "S")
+	}
+	return b.String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) (err error) {
+	*d, err = ParseDuration(string(text))
+	return
+}
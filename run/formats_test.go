@@ -0,0 +1,61 @@
+package run
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"PT0S", 0, false},
+		{"PT1H30M", 90 * time.Minute, false},
+		{"P1DT2H", 26 * time.Hour, false},
+		{"P2W", 14 * 24 * time.Hour, false},
+		{"-PT1.5S", -1500 * time.Millisecond, false},
+		{"PT0,5S", 500 * time.Millisecond, false},
+		{"P1Y", 0, true},
+		{"P1M", 0, true}, // Months are not supported, minutes must follow T
+		{"PT1H1H", 0, true},
+		{"P", 0, true},
+		{"PT", 0, true},
+		{"1H", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDuration(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if time.Duration(got) != tt.want {
+				t.Errorf("expect %v, got %v", tt.want, time.Duration(got))
+			}
+		})
+	}
+}
+
+func TestFormatsJSONRoundTrip(t *testing.T) {
+	type payload struct {
+		ID       UUID     `json:"id"`
+		Link     URL      `json:"link"`
+		Day      Date     `json:"day"`
+		Duration Duration `json:"duration"`
+		Blob     Bytes    `json:"blob"`
+	}
+	in := `{"id":"123e4567-e89b-12d3-a456-426614174000","link":"https://example.com/a?b=c","day":"2024-02-29","duration":"PT26H0.5S","blob":"aGk="}`
+	var p payload
+	if err := json.Unmarshal([]byte(in), &p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != in {
+		t.Errorf("expect %s, got %s", in, out)
+	}
+}
@@ -3,8 +3,15 @@ package run
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
+// MessageIDHeader is a header that carries the message id, used to tell apart the messages of one channel
+const MessageIDHeader = "messageId"
+
+// ContentEncodingHeader is a header that carries the compression of message payload, e.g. "gzip"
+const ContentEncodingHeader = "Content-Encoding"
+
 type Headers map[string]any
 
 func (h Headers) ToByteValues() map[string][]byte {
@@ -27,6 +34,37 @@ func (h Headers) ToByteValues() map[string][]byte {
 	return res
 }
 
+// GetString returns a header value as string. If there is no exact key match, the key is looked up
+// case-insensitively, since some protocols (e.g. HTTP) canonicalize the header names. Returns false if header is not
+// set or its value cannot be represented as string.
+func (h Headers) GetString(key string) (string, bool) {
+	v, ok := h[key]
+	if !ok {
+		for k, val := range h {
+			if strings.EqualFold(k, key) {
+				v, ok = val, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	switch tv := v.(type) {
+	case string:
+		return tv, true
+	case []byte:
+		return string(tv), true
+	case []string:
+		if len(tv) > 0 {
+			return tv[0], true
+		}
+	case fmt.Stringer:
+		return tv.String(), true
+	}
+	return "", false
+}
+
 type Parameter interface {
 	Name() string
 	String() string
@@ -0,0 +1,333 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Schema types the schema registry supports
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeJSON     = "JSON"
+	SchemaTypeProtobuf = "PROTOBUF"
+)
+
+// Values of MessageBindings.SchemaIDLocation
+const (
+	SchemaIDLocationPayload = "payload"
+	SchemaIDLocationHeader  = "header"
+)
+
+// Values of MessageBindings.SchemaIDPayloadEncoding
+const (
+	SchemaIDPayloadEncodingConfluent      = "confluent"       // Magic byte and 4-byte schema id
+	SchemaIDPayloadEncodingApicurioNew    = "apicurio-new"    // Magic byte and 4-byte schema id
+	SchemaIDPayloadEncodingApicurioLegacy = "apicurio-legacy" // Magic byte and 8-byte schema id
+)
+
+// Values of ServerBindings.SchemaRegistryVendor
+const (
+	SchemaRegistryVendorConfluent = "confluent"
+	SchemaRegistryVendorApicurio  = "apicurio"
+)
+
+// SchemaIDHeader is the record header the schema id is written to if the schema id location is "header". Only
+// Apicurio Registry supports this location, so the header is the one its serdes read.
+const SchemaIDHeader = "apicurio.value.globalId"
+
+const schemaIDMagicByte = 0
+
+// Schema is the payload schema of a message. The generated code passes it to the envelope if the message bindings
+// set the schema id location, see SchemaEnvelopeWriter.
+type Schema struct {
+	Type           string // SchemaTypeAvro, SchemaTypeJSON or SchemaTypeProtobuf
+	Schema         string // Schema text. If empty, the latest schema version registered for the subject is used
+	RecordName     string // Fully qualified record or message name, used by the record name lookup strategies
+	MessageIndexes []int  // Protobuf message indexes in the .proto file, e.g. [1, 0] is the first nested message of the second one
+}
+
+// SchemaEnvelopeWriter is implemented by EnvelopeWriter that supports the schema registry wire format. The generated
+// code sets the payload schema, the implementation looks up the schema id on sending and writes it to the record.
+type SchemaEnvelopeWriter interface {
+	SetSchema(schema Schema)
+}
+
+// SchemaEnvelopeReader is implemented by EnvelopeReader that supports the schema registry wire format. The generated
+// code passes the schema id read from the payload, so that the consumer may check it, see EnvelopeIn.SchemaID in
+// the implementation.
+type SchemaEnvelopeReader interface {
+	SetSchemaID(id int64)
+}
+
+// SubjectName returns the registry subject name of schema for a given topic according to the lookup strategy
+// (MessageBindings.SchemaLookupStrategy). Empty strategy is TopicNameStrategy.
+func SubjectName(strategy, topic string, schema Schema) (string, error) {
+	switch strategy {
+	case "", "TopicNameStrategy", "TopicIdStrategy":
+		return topic + "-value", nil
+	case "RecordNameStrategy", "RecordIdStrategy":
+		if schema.RecordName == "" {
+			return "", fmt.Errorf("%s requires a record name", strategy)
+		}
+		return schema.RecordName, nil
+	case "TopicRecordNameStrategy", "TopicRecordIdStrategy":
+		if schema.RecordName == "" {
+			return "", fmt.Errorf("%s requires a record name", strategy)
+		}
+		return topic + "-" + schema.RecordName, nil
+	}
+	return "", fmt.Errorf("unknown schema lookup strategy %q", strategy)
+}
+
+// AppendSchemaID appends the schema id in a given payload encoding (MessageBindings.SchemaIDPayloadEncoding) to b.
+// The id is followed by message indexes for Protobuf schemas in Confluent encoding.
+func AppendSchemaID(b []byte, id int64, payloadEncoding string, schema Schema) ([]byte, error) {
+	size, err := schemaIDSize(payloadEncoding)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, schemaIDMagicByte)
+	if size == 8 {
+		b = binary.BigEndian.AppendUint64(b, uint64(id))
+	} else {
+		b = binary.BigEndian.AppendUint32(b, uint32(id))
+	}
+	if schema.Type == SchemaTypeProtobuf && isConfluentEncoding(payloadEncoding) {
+		if len(schema.MessageIndexes) == 0 || len(schema.MessageIndexes) == 1 && schema.MessageIndexes[0] == 0 {
+			return append(b, 0), nil // Shortcut for the first message in file
+		}
+		b = binary.AppendVarint(b, int64(len(schema.MessageIndexes)))
+		for _, idx := range schema.MessageIndexes {
+			b = binary.AppendVarint(b, int64(idx))
+		}
+	}
+	return b, nil
+}
+
+// ReadSchemaID reads the schema id written by AppendSchemaID from the beginning of payload and returns it, so that r
+// is positioned at the payload itself. Nothing is read and false is returned if the schema id location is
+// not "payload".
+func ReadSchemaID(r io.Reader, location, payloadEncoding, schemaType string) (int64, bool, error) {
+	if location != SchemaIDLocationPayload {
+		return 0, false, nil
+	}
+	size, err := schemaIDSize(payloadEncoding)
+	if err != nil {
+		return 0, false, err
+	}
+	buf := make([]byte, 1+size)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return 0, false, fmt.Errorf("read schema id: %w", err)
+	}
+	if buf[0] != schemaIDMagicByte {
+		return 0, false, fmt.Errorf("unknown schema id magic byte %d", buf[0])
+	}
+	var id int64
+	if size == 8 {
+		id = int64(binary.BigEndian.Uint64(buf[1:]))
+	} else {
+		id = int64(binary.BigEndian.Uint32(buf[1:]))
+	}
+	if schemaType == SchemaTypeProtobuf && isConfluentEncoding(payloadEncoding) {
+		br := byteReader{r}
+		count, err := binary.ReadVarint(br)
+		if err != nil {
+			return 0, false, fmt.Errorf("read message indexes: %w", err)
+		}
+		for i := int64(0); i < count; i++ {
+			if _, err = binary.ReadVarint(br); err != nil {
+				return 0, false, fmt.Errorf("read message indexes: %w", err)
+			}
+		}
+	}
+	return id, true, nil
+}
+
+// SchemaIDFormat returns the schema id payload encoding of message bindings, or the default one of schema registry
+// vendor (ServerBindings.SchemaRegistryVendor) if bindings don't set it. Empty vendor means Confluent defaults. Returns
+// error if the vendor is unknown or doesn't support the schema id location. The "header" location is vendor-specific,
+// so it requires the vendor to be set.
+func SchemaIDFormat(vendor string, bindings MessageBindings) (string, error) {
+	switch vendor {
+	case "", SchemaRegistryVendorConfluent:
+		if bindings.SchemaIDLocation == SchemaIDLocationHeader {
+			if vendor == "" {
+				return "", fmt.Errorf("schema id location %q requires the schema registry vendor to be set", bindings.SchemaIDLocation)
+			}
+			return "", fmt.Errorf("schema id location %q is not supported by %s schema registry", bindings.SchemaIDLocation, vendor)
+		}
+		return bindings.SchemaIDPayloadEncoding, nil
+	case SchemaRegistryVendorApicurio:
+		if bindings.SchemaIDPayloadEncoding == "" {
+			return SchemaIDPayloadEncodingApicurioNew, nil
+		}
+		return bindings.SchemaIDPayloadEncoding, nil
+	}
+	return "", fmt.Errorf("unknown schema registry vendor %q", vendor)
+}
+
+func schemaIDSize(payloadEncoding string) (int, error) {
+	switch payloadEncoding {
+	case "", SchemaIDPayloadEncodingConfluent, SchemaIDPayloadEncodingApicurioNew:
+		return 4, nil
+	case SchemaIDPayloadEncodingApicurioLegacy:
+		return 8, nil
+	}
+	return 0, fmt.Errorf("unknown schema id payload encoding %q", payloadEncoding)
+}
+
+func isConfluentEncoding(payloadEncoding string) bool {
+	return payloadEncoding == "" || payloadEncoding == SchemaIDPayloadEncodingConfluent
+}
+
+// byteReader reads the varints byte by byte, not to consume the payload following them
+type byteReader struct {
+	io.Reader
+}
+
+func (r byteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(r.Reader, b[:])
+	return b[0], err
+}
+
+// SchemaRegistry is a client of schema registry REST API compatible with Confluent Schema Registry. Apicurio Registry
+// provides such API at `/apis/ccompat/v7` path. The schema ids are cached, so the registry is requested only once for
+// every subject and schema.
+type SchemaRegistry struct {
+	url    string
+	client *http.Client
+
+	mu  sync.Mutex
+	ids map[[2]string]int64 // By subject and schema text
+}
+
+// NewSchemaRegistry returns a new client of schema registry at url. If client is nil, http.DefaultClient is used.
+func NewSchemaRegistry(url string, client *http.Client) *SchemaRegistry {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &SchemaRegistry{
+		url:    strings.TrimSuffix(url, "/"),
+		client: client,
+		ids:    make(map[[2]string]int64),
+	}
+}
+
+// SchemaID returns the id of schema in subject. The schema is registered if it has the text, otherwise the latest
+// version registered for the subject is used.
+func (s *SchemaRegistry) SchemaID(ctx context.Context, subject string, schema Schema) (int64, error) {
+	key := [2]string{subject, schema.Schema}
+	s.mu.Lock()
+	id, ok := s.ids[key]
+	s.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var err error
+	if schema.Schema != "" {
+		id, err = s.Register(ctx, subject, schema)
+	} else {
+		id, err = s.LatestID(ctx, subject)
+	}
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	s.ids[key] = id
+	s.mu.Unlock()
+	return id, nil
+}
+
+// Register registers the schema in subject and returns its id. If the same schema is already registered, the
+// registry returns the existing id.
+func (s *SchemaRegistry) Register(ctx context.Context, subject string, schema Schema) (int64, error) {
+	req := registrySchema{Schema: schema.Schema}
+	if schema.Type != SchemaTypeAvro { // Avro is default, old registry versions don't know the schemaType field
+		req.SchemaType = schema.Type
+	}
+	var res registrySchema
+	if err := s.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", req, &res); err != nil {
+		return 0, fmt.Errorf("register schema in subject %q: %w", subject, err)
+	}
+	return res.ID, nil
+}
+
+// LatestID returns the id of the latest schema version registered for subject
+func (s *SchemaRegistry) LatestID(ctx context.Context, subject string) (int64, error) {
+	var res registrySchema
+	if err := s.do(ctx, http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions/latest", nil, &res); err != nil {
+		return 0, fmt.Errorf("get latest schema of subject %q: %w", subject, err)
+	}
+	return res.ID, nil
+}
+
+// SchemaByID returns the schema registered with id
+func (s *SchemaRegistry) SchemaByID(ctx context.Context, id int64) (Schema, error) {
+	var res registrySchema
+	if err := s.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &res); err != nil {
+		return Schema{}, fmt.Errorf("get schema %d: %w", id, err)
+	}
+	if res.SchemaType == "" {
+		res.SchemaType = SchemaTypeAvro
+	}
+	return Schema{Type: res.SchemaType, Schema: res.Schema}, nil
+}
+
+type registrySchema struct {
+	ID         int64  `json:"id,omitempty"`
+	Schema     string `json:"schema,omitempty"`
+	SchemaType string `json:"schemaType,omitempty"`
+}
+
+type registryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (s *SchemaRegistry) do(ctx context.Context, method, path string, body, result any) error {
+	var rd io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var regErr registryError
+		if json.Unmarshal(data, &regErr) == nil && regErr.Message != "" {
+			return fmt.Errorf("registry error %d: %s", regErr.ErrorCode, regErr.Message)
+		}
+		return errors.New(resp.Status)
+	}
+	return json.Unmarshal(data, result)
+}
@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSchemaID(t *testing.T) {
+	tests := []struct {
+		name            string
+		payloadEncoding string
+		schema          Schema
+		want            []byte
+	}{
+		{"confluent", "", Schema{Type: SchemaTypeAvro}, []byte{0, 0, 0, 1, 2}},
+		{"apicurio legacy", SchemaIDPayloadEncodingApicurioLegacy, Schema{Type: SchemaTypeJSON}, []byte{0, 0, 0, 0, 0, 0, 0, 1, 2}},
+		{"protobuf first message", SchemaIDPayloadEncodingConfluent, Schema{Type: SchemaTypeProtobuf}, []byte{0, 0, 0, 1, 2, 0}},
+		{"protobuf nested message", SchemaIDPayloadEncodingConfluent, Schema{Type: SchemaTypeProtobuf, MessageIndexes: []int{1, 0}}, []byte{0, 0, 0, 1, 2, 4, 2, 0}},
+		{"protobuf apicurio", SchemaIDPayloadEncodingApicurioNew, Schema{Type: SchemaTypeProtobuf}, []byte{0, 0, 0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendSchemaID(nil, 258, tt.payloadEncoding, tt.schema)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("expect %v, got %v", tt.want, got)
+			}
+
+			r := bytes.NewReader(append(got, "payload"...))
+			id, ok, err := ReadSchemaID(r, SchemaIDLocationPayload, tt.payloadEncoding, tt.schema.Type)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !ok || id != 258 {
+				t.Errorf("expect schema id 258, got %d, %v", id, ok)
+			}
+			if rest, _ := io.ReadAll(r); string(rest) != "payload" {
+				t.Errorf("expect payload left, got %q", rest)
+			}
+		})
+	}
+
+	if _, _, err := ReadSchemaID(bytes.NewReader([]byte(`{"a":1}`)), SchemaIDLocationPayload, "", SchemaTypeJSON); err == nil {
+		t.Error("expect error on missing magic byte")
+	}
+	if _, ok, err := ReadSchemaID(bytes.NewReader([]byte(`{"a":1}`)), SchemaIDLocationHeader, "", SchemaTypeJSON); ok || err != nil {
+		t.Errorf("expect nothing read for header location, got %v, %v", ok, err)
+	}
+}
+
+func TestSchemaIDFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		vendor   string
+		bindings MessageBindings
+		want     string
+		wantErr  bool
+	}{
+		{"no vendor", "", MessageBindings{SchemaIDLocation: SchemaIDLocationPayload}, "", false},
+		{"confluent", SchemaRegistryVendorConfluent, MessageBindings{SchemaIDLocation: SchemaIDLocationPayload}, "", false},
+		{"confluent header", SchemaRegistryVendorConfluent, MessageBindings{SchemaIDLocation: SchemaIDLocationHeader}, "", true},
+		{"no vendor header", "", MessageBindings{SchemaIDLocation: SchemaIDLocationHeader}, "", true},
+		{"apicurio header", SchemaRegistryVendorApicurio, MessageBindings{SchemaIDLocation: SchemaIDLocationHeader}, SchemaIDPayloadEncodingApicurioNew, false},
+		{"apicurio default", SchemaRegistryVendorApicurio, MessageBindings{SchemaIDLocation: SchemaIDLocationPayload}, SchemaIDPayloadEncodingApicurioNew, false},
+		{"apicurio legacy", SchemaRegistryVendorApicurio, MessageBindings{SchemaIDPayloadEncoding: SchemaIDPayloadEncodingApicurioLegacy}, SchemaIDPayloadEncodingApicurioLegacy, false},
+		{"unknown vendor", "karapace", MessageBindings{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SchemaIDFormat(tt.vendor, tt.bindings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expect error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expect %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "POST /subjects/orders-value/versions":
+			var req registrySchema
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Schema == "" || req.SchemaType != SchemaTypeProtobuf {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"error_code":42201,"message":"Invalid schema"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":7}`))
+		case "GET /subjects/com.example.User/versions/latest":
+			_, _ = w.Write([]byte(`{"subject":"com.example.User","version":3,"id":12,"schema":"{}","schemaType":"JSON"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code":40401,"message":"Subject not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	reg := NewSchemaRegistry(srv.URL+"/", srv.Client())
+	protoSchema := Schema{Type: SchemaTypeProtobuf, Schema: `syntax = "proto3"; message Order {}`}
+	for i := 0; i < 2; i++ {
+		id, err := reg.SchemaID(ctx, "orders-value", protoSchema)
+		if err != nil || id != 7 {
+			t.Errorf("expect id 7, got %d, error %v", id, err)
+		}
+	}
+	subject, err := SubjectName("RecordNameStrategy", "users", Schema{Type: SchemaTypeJSON, RecordName: "com.example.User"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if id, err := reg.SchemaID(ctx, subject, Schema{Type: SchemaTypeJSON}); err != nil || id != 12 {
+		t.Errorf("expect id 12, got %d, error %v", id, err)
+	}
+	if _, err = reg.SchemaID(ctx, "unknown-value", Schema{Type: SchemaTypeJSON}); err == nil {
+		t.Error("expect error on unknown subject")
+	}
+
+	want := []string{
+		"POST /subjects/orders-value/versions",
+		"GET /subjects/com.example.User/versions/latest",
+		"GET /subjects/unknown-value/versions/latest",
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("expect requests %v, got %v", want, requests)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("expect request %q, got %q", want[i], requests[i])
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"reflect"
+)
+
+// Optional is a value of nullable type, that is null if not Set. The generated code uses it instead of pointer for
+// nullable types, if --nullable-fields=optional is set.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+// NewOptional returns Optional with the value set
+func NewOptional[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Set: true}
+}
+
+// Get returns the value and true if it's set, or the zero value and false if it's null
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set
+}
+
+// IsZero returns true if the value is null. It's used by yaml `omitempty` option.
+func (o Optional[T]) IsZero() bool {
+	return !o.Set
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = Optional[T]{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+func (o Optional[T]) MarshalYAML() (any, error) {
+	if !o.Set {
+		return nil, nil
+	}
+	return o.Value, nil
+}
+
+// UnmarshalYAML decodes the YAML value. The null value is never passed here, yaml decoder resets the Optional to
+// zero value itself.
+func (o *Optional[T]) UnmarshalYAML(unmarshal func(any) error) error {
+	if err := unmarshal(&o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+// MarshalXML encodes the value if it's set, the null value is omitted
+func (o Optional[T]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !o.Set {
+		return nil
+	}
+	return e.EncodeElement(o.Value, start)
+}
+
+func (o *Optional[T]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if err := d.DecodeElement(&o.Value, &start); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+// Codec is a pair of functions, that marshal and unmarshal the values in a format
+type Codec struct {
+	Marshal   func(v any) ([]byte, error)
+	Unmarshal func(data []byte, v any) error
+}
+
+// MsgpackCodec and CBORCodec encode the value of Optional in msgpack and CBOR, since the runtime module doesn't depend
+// on these libraries. The generated encoding package sets them if the document has messages in these formats.
+var MsgpackCodec, CBORCodec Codec
+
+const (
+	msgpackNil    = 0xc0
+	cborNull      = 0xf6
+	cborUndefined = 0xf7
+)
+
+// MarshalMsgpack encodes the value by MsgpackCodec if it's set, or msgpack nil
+func (o Optional[T]) MarshalMsgpack() ([]byte, error) {
+	if !o.Set {
+		return []byte{msgpackNil}, nil
+	}
+	if MsgpackCodec.Marshal == nil {
+		return nil, errors.New("msgpack codec is not set")
+	}
+	return MsgpackCodec.Marshal(o.Value)
+}
+
+func (o *Optional[T]) UnmarshalMsgpack(data []byte) error {
+	if len(data) == 1 && data[0] == msgpackNil {
+		*o = Optional[T]{}
+		return nil
+	}
+	if MsgpackCodec.Unmarshal == nil {
+		return errors.New("msgpack codec is not set")
+	}
+	if err := MsgpackCodec.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+// MarshalCBOR encodes the value by CBORCodec if it's set, or CBOR null
+func (o Optional[T]) MarshalCBOR() ([]byte, error) {
+	if !o.Set {
+		return []byte{cborNull}, nil
+	}
+	if CBORCodec.Marshal == nil {
+		return nil, errors.New("CBOR codec is not set")
+	}
+	return CBORCodec.Marshal(o.Value)
+}
+
+func (o *Optional[T]) UnmarshalCBOR(data []byte) error {
+	if len(data) == 1 && (data[0] == cborNull || data[0] == cborUndefined) {
+		*o = Optional[T]{}
+		return nil
+	}
+	if CBORCodec.Unmarshal == nil {
+		return errors.New("CBOR codec is not set")
+	}
+	if err := CBORCodec.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Set = true
+	return nil
+}
+
+// IsEmpty returns true if the value is treated as empty by `omitempty` option of encoding/json, i.e. it's false, 0,
+// nil pointer, nil interface or empty array, slice, map or string. The generated Validate methods skip the checks
+// of empty optional fields.
+func IsEmpty(value any) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package run
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOptionalJSON(t *testing.T) {
+	type object struct {
+		Name Optional[string] `json:"name"`
+	}
+	tests := []struct {
+		name  string
+		data  string
+		value Optional[string]
+	}{
+		{"set", `{"name":"x"}`, NewOptional("x")},
+		{"empty string", `{"name":""}`, NewOptional("")},
+		{"null", `{"name":null}`, Optional[string]{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := object{Name: NewOptional("previous")}
+			if err := json.Unmarshal([]byte(tt.data), &obj); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if obj.Name != tt.value {
+				t.Errorf("expect %+v, got %+v", tt.value, obj.Name)
+			}
+			data, err := json.Marshal(obj)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if string(data) != tt.data {
+				t.Errorf("expect %s, got %s", tt.data, data)
+			}
+		})
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  bool
+	}{
+		{"nil", nil, true},
+		{"zero int", 0, true},
+		{"int", 1, false},
+		{"empty string", "", true},
+		{"string", "a", false},
+		{"empty slice", []int{}, true},
+		{"nil pointer", (*int)(nil), true},
+		{"pointer", ToPtr(0), false},
+		{"struct", struct{}{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsEmpty(tt.value); got != tt.want {
+				t.Errorf("expect %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MatchPattern returns true if the property name matches the regex from patternProperties
+func MatchPattern(pattern, name string) bool {
+	return compiledPattern(pattern).MatchString(name)
+}
+
+// SortedKeys returns the map keys in ascending order, so that the properties are marshaled in the same order
+func SortedKeys[V any](m map[string]V) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// AppendJSONProperties adds the properties to the end of marshaled JSON object, in order of their names
+func AppendJSONProperties(object []byte, props map[string]any) ([]byte, error) {
+	if len(props) == 0 {
+		return object, nil
+	}
+	object = bytes.TrimSpace(object)
+	if len(object) < 2 || object[0] != '{' || object[len(object)-1] != '}' {
+		return nil, errors.New("value is not a JSON object")
+	}
+	res := append([]byte{}, object[:len(object)-1]...)
+	empty := len(bytes.TrimSpace(res)) == 1
+	for _, name := range SortedKeys(props) {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(props[name])
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", name, err)
+		}
+		if !empty {
+			res = append(res, ',')
+		}
+		empty = false
+		res = append(append(append(res, key...), ':'), value...)
+	}
+	return append(res, '}'), nil
+}
+
+// CheckItemsCount returns error if the number of tuple items is out of bounds, negative max means no upper bound
+func CheckItemsCount(count, min, max int) error {
+	if count < min {
+		return fmt.Errorf("%d items is less than %d", count, min)
+	}
+	if max >= 0 && count > max {
+		return fmt.Errorf("%d items is greater than %d", count, max)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package run
+
+import "testing"
+
+func TestAppendJSONProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		object  string
+		props   map[string]any
+		want    string
+		wantErr bool
+	}{
+		{"no properties", `{"a":1}`, nil, `{"a":1}`, false},
+		{"empty object", `{}`, map[string]any{"b": "x", "a": 1}, `{"a":1,"b":"x"}`, false},
+		{"fields", `{"id":"1"}`, map[string]any{"x-tag": []int{1}}, `{"id":"1","x-tag":[1]}`, false},
+		{"not object", `[1]`, map[string]any{"a": 1}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendJSONProperties([]byte(tt.object), tt.props)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expect %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckItemsCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		count    int
+		min, max int
+		wantErr  string
+	}{
+		{"in bounds", 2, 1, 3, ""},
+		{"no upper bound", 10, 2, -1, ""},
+		{"too few", 1, 2, -1, "1 items is less than 2"},
+		{"too many", 4, 2, 3, "4 items is greater than 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckItemsCount(tt.count, tt.min, tt.max)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Package protobuf contains the Protocol Buffers wire format primitives used by the generated code to marshal and
+// unmarshal the protobuf messages without depending on the protobuf runtime.
+//
+// See https://protobuf.dev/programming-guides/encoding/
+package protobuf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type WireType uint8
+
+const (
+	VarintType  WireType = 0
+	Fixed64Type WireType = 1
+	BytesType   WireType = 2
+	StartGroup  WireType = 3 // Deprecated groups, only skipped on reading
+	EndGroup    WireType = 4
+	Fixed32Type WireType = 5
+)
+
+var ErrMalformed = errors.New("malformed protobuf data")
+
+func AppendTag(b []byte, num int, typ WireType) []byte {
+	return AppendVarint(b, uint64(num)<<3|uint64(typ))
+}
+
+func AppendVarint(b []byte, v uint64) []byte {
+	return binary.AppendUvarint(b, v)
+}
+
+func AppendFixed32(b []byte, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(b, v)
+}
+
+func AppendFixed64(b []byte, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+// AppendBytes appends the length-delimited value
+func AppendBytes(b []byte, v []byte) []byte {
+	return append(AppendVarint(b, uint64(len(v))), v...)
+}
+
+func AppendString(b []byte, v string) []byte {
+	return append(AppendVarint(b, uint64(len(v))), v...)
+}
+
+func EncodeBool(v bool) uint64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func EncodeZigZag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func DecodeZigZag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func NewReader(b []byte) *Reader {
+	return &Reader{buf: b}
+}
+
+// Reader reads the protobuf fields from a buffer
+type Reader struct {
+	buf []byte
+	pos int
+}
+
+// Done returns true if all data has been read
+func (r *Reader) Done() bool {
+	return r.pos >= len(r.buf)
+}
+
+// Next reads the next field tag. Returns io.EOF if all data has been read.
+func (r *Reader) Next() (num int, typ WireType, err error) {
+	if r.Done() {
+		return 0, 0, io.EOF
+	}
+	v, err := r.Varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	num, typ = int(v>>3), WireType(v&7)
+	if num <= 0 {
+		return 0, 0, fmt.Errorf("%w: invalid field number %d", ErrMalformed, num)
+	}
+	return num, typ, nil
+}
+
+// ExpectType returns error if the wire type of field differs from expected one
+func ExpectType(num int, typ, expected WireType) error {
+	if typ != expected {
+		return fmt.Errorf("%w: field %d has wire type %d, expected %d", ErrMalformed, num, typ, expected)
+	}
+	return nil
+}
+
+func (r *Reader) Varint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: bad varint at offset %d", ErrMalformed, r.pos)
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *Reader) Fixed32() (uint32, error) {
+	if len(r.buf)-r.pos < 4 {
+		return 0, fmt.Errorf("%w: unexpected end of fixed32 at offset %d", ErrMalformed, r.pos)
+	}
+	v := binary.LittleEndian.Uint32(r.buf[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *Reader) Fixed64() (uint64, error) {
+	if len(r.buf)-r.pos < 8 {
+		return 0, fmt.Errorf("%w: unexpected end of fixed64 at offset %d", ErrMalformed, r.pos)
+	}
+	v := binary.LittleEndian.Uint64(r.buf[r.pos:])
+	r.pos += 8
+	return v, nil
+}
+
+// Bytes reads the length-delimited value. The result refers to the reader buffer.
+func (r *Reader) Bytes() ([]byte, error) {
+	l, err := r.Varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.buf)-r.pos) < l {
+		return nil, fmt.Errorf("%w: unexpected end of bytes at offset %d", ErrMalformed, r.pos)
+	}
+	v := r.buf[r.pos : r.pos+int(l)]
+	r.pos += int(l)
+	return v, nil
+}
+
+// Skip skips the value of the field with a given wire type, e.g. unknown field
+func (r *Reader) Skip(typ WireType) (err error) {
+	switch typ {
+	case VarintType:
+		_, err = r.Varint()
+	case Fixed64Type:
+		_, err = r.Fixed64()
+	case BytesType:
+		_, err = r.Bytes()
+	case Fixed32Type:
+		_, err = r.Fixed32()
+	case StartGroup:
+		for {
+			var t WireType
+			if _, t, err = r.Next(); err != nil {
+				return err
+			}
+			if t == EndGroup {
+				return nil
+			}
+			if err = r.Skip(t); err != nil {
+				return err
+			}
+		}
+	default:
+		err = fmt.Errorf("%w: unknown wire type %d", ErrMalformed, typ)
+	}
+	return
+}
@@ -0,0 +1,254 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Schema is a subset of JSON Schema, that the generated code checks the decoded data against. It's used for the
+// conditional schemas (if/then/else, not), that can't be expressed by Go types. Nil pointer means the keyword is
+// not set.
+type Schema struct {
+	Type       []string // JSON types: "null", "boolean", "object", "array", "number", "integer", "string"
+	Enum       []any    // Allowed values, `const` is an enum with one value
+	Required   []string
+	Properties map[string]*Schema
+	Items      *Schema
+
+	MinLength        *int
+	MaxLength        *int
+	Pattern          string
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum *float64
+	ExclusiveMaximum *float64
+	MultipleOf       *float64
+	MinItems         *int
+	MaxItems         *int
+	UniqueItems      bool
+	MinProperties    *int
+	MaxProperties    *int
+
+	AllOf []*Schema
+	AnyOf []*Schema
+	OneOf []*Schema
+	Not   *Schema
+	If    *Schema
+	Then  *Schema
+	Else  *Schema
+}
+
+// Check checks the value decoded from JSON or YAML against the schema, and returns all violations as
+// ValidationErrors
+func (s *Schema) Check(value any) error {
+	var v Validator
+	s.check(&v, "$", normalizeValue(value))
+	return v.Err()
+}
+
+// CheckValue checks the Go value against the schema as it's marshaled to JSON, and returns all violations as
+// ValidationErrors
+func (s *Schema) CheckValue(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var decoded any
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	return s.Check(decoded)
+}
+
+func (s *Schema) matches(value any) bool {
+	var v Validator
+	s.check(&v, "$", value)
+	return len(v.Errors) == 0
+}
+
+func (s *Schema) check(v *Validator, path string, value any) {
+	if s == nil {
+		return
+	}
+	if len(s.Type) > 0 && !checkSchemaType(s.Type, value) {
+		v.Add(path, "value %v is not of type %v", value, s.Type)
+		return
+	}
+	if len(s.Enum) > 0 {
+		var found bool
+		for _, item := range s.Enum {
+			if reflect.DeepEqual(normalizeValue(item), value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.Add(path, "value %v is not one of %v", value, s.Enum)
+		}
+	}
+
+	switch val := value.(type) {
+	case string:
+		if s.MinLength != nil {
+			v.MinLength(path, val, *s.MinLength)
+		}
+		if s.MaxLength != nil {
+			v.MaxLength(path, val, *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			v.Pattern(path, val, s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil {
+			v.Minimum(path, val, *s.Minimum, false)
+		}
+		if s.ExclusiveMinimum != nil {
+			v.Minimum(path, val, *s.ExclusiveMinimum, true)
+		}
+		if s.Maximum != nil {
+			v.Maximum(path, val, *s.Maximum, false)
+		}
+		if s.ExclusiveMaximum != nil {
+			v.Maximum(path, val, *s.ExclusiveMaximum, true)
+		}
+		if s.MultipleOf != nil {
+			v.MultipleOf(path, val, *s.MultipleOf)
+		}
+	case []any:
+		if s.MinItems != nil {
+			v.MinItems(path, len(val), *s.MinItems)
+		}
+		if s.MaxItems != nil {
+			v.MaxItems(path, len(val), *s.MaxItems)
+		}
+		if s.UniqueItems {
+			v.UniqueItems(path, val)
+		}
+		for i, item := range val {
+			s.Items.check(v, path+"["+strconv.Itoa(i)+"]", item)
+		}
+	case map[string]any:
+		if s.MinProperties != nil {
+			v.MinProperties(path, len(val), *s.MinProperties)
+		}
+		if s.MaxProperties != nil {
+			v.MaxProperties(path, len(val), *s.MaxProperties)
+		}
+		for _, name := range s.Required {
+			_, ok := val[name]
+			v.Required(path+"."+name, ok)
+		}
+		for _, name := range SortedKeys(s.Properties) {
+			if item, ok := val[name]; ok {
+				s.Properties[name].check(v, path+"."+name, item)
+			}
+		}
+	}
+
+	for _, item := range s.AllOf {
+		item.check(v, path, value)
+	}
+	if len(s.AnyOf) > 0 {
+		var matched bool
+		for _, item := range s.AnyOf {
+			if item.matches(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.Add(path, "value does not match any schema in anyOf")
+		}
+	}
+	if len(s.OneOf) > 0 {
+		var count int
+		for _, item := range s.OneOf {
+			if item.matches(value) {
+				count++
+			}
+		}
+		if count != 1 {
+			v.Add(path, "value matches %d schemas in oneOf, must match exactly one", count)
+		}
+	}
+	if s.Not != nil && s.Not.matches(value) {
+		v.Add(path, `value must not match the "not" schema`)
+	}
+	if s.If != nil {
+		branch, reason := s.Then, `in "then" schema, since "if" schema matches`
+		if !s.If.matches(value) {
+			branch, reason = s.Else, `in "else" schema, since "if" schema does not match`
+		}
+		var bv Validator
+		branch.check(&bv, path, value)
+		for _, e := range bv.Errors {
+			v.Add(e.Path, "%s (%s)", e.Message, reason)
+		}
+	}
+}
+
+// checkSchemaType returns true if value has one of JSON types
+func checkSchemaType(types []string, value any) bool {
+	for _, t := range types {
+		var ok bool
+		switch t {
+		case "null":
+			ok = value == nil
+		case "boolean":
+			_, ok = value.(bool)
+		case "object":
+			_, ok = value.(map[string]any)
+		case "array":
+			_, ok = value.([]any)
+		case "number":
+			_, ok = value.(float64)
+		case "integer":
+			f, isNumber := value.(float64)
+			ok = isNumber && f == float64(int64(f))
+		case "string":
+			_, ok = value.(string)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeValue converts the value decoded from JSON or YAML to the JSON data model: numbers become float64,
+// objects become map[string]any
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			res[k] = normalizeValue(item)
+		}
+		return res
+	case map[any]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			res[fmt.Sprint(k)] = normalizeValue(item)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = normalizeValue(item)
+		}
+		return res
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return value
+}
@@ -0,0 +1,54 @@
+package run
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	address := &Schema{
+		If:   &Schema{Properties: map[string]*Schema{"country": {Enum: []any{"US"}}}, Required: []string{"country"}},
+		Then: &Schema{Required: []string{"zip"}, Properties: map[string]*Schema{"zip": {Pattern: "^[0-9]{5}$"}}},
+		Else: &Schema{Required: []string{"postcode"}},
+	}
+	tests := []struct {
+		name   string
+		schema *Schema
+		value  any
+		want   []string
+	}{
+		{"then ok", address, map[string]any{"country": "US", "zip": "12345"}, nil},
+		{
+			"then required",
+			address,
+			map[string]any{"country": "US"},
+			[]string{`$.zip: required property is missing (in "then" schema, since "if" schema matches)`},
+		},
+		{"then pattern", address, map[string]any{"country": "US", "zip": "1"}, []string{`$.zip: value "1" does not match pattern "^[0-9]{5}$" (in "then" schema, since "if" schema matches)`}},
+		{
+			"else required",
+			address,
+			map[string]any{"country": "DE"},
+			[]string{`$.postcode: required property is missing (in "else" schema, since "if" schema does not match)`},
+		},
+		{"not", &Schema{Not: &Schema{Required: []string{"a"}}}, map[string]any{"a": 1}, []string{`$: value must not match the "not" schema`}},
+		{"not ok", &Schema{Not: &Schema{Type: []string{"string"}}}, 1, nil},
+		{"yaml numbers", &Schema{Properties: map[string]*Schema{"n": {Enum: []any{2.0}, Type: []string{"integer"}}}}, map[any]any{"n": 2}, nil},
+		{"oneOf", &Schema{OneOf: []*Schema{{Type: []string{"number"}}, {Type: []string{"integer"}}}}, 1.0, []string{"$: value matches 2 schemas in oneOf, must match exactly one"}},
+		{"items", &Schema{Items: &Schema{Minimum: ToPtr(0.0)}}, []any{1, -1}, []string{"$[1]: value -1 is less than 0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.Check(tt.value)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != strings.Join(tt.want, "; ") {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationError is a violation of a schema constraint by the value on Path, e.g. "$.items[0].name"
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors are all violations found in a value
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	res := make([]string, 0, len(e))
+	for _, item := range e {
+		res = append(res, item.Error())
+	}
+	return strings.Join(res, "; ")
+}
+
+// Validator collects the violations of schema constraints. It's used by the generated Validate methods.
+type Validator struct {
+	Errors ValidationErrors
+}
+
+// Err returns the collected violations as ValidationErrors or nil if there are no violations
+func (v *Validator) Err() error {
+	if len(v.Errors) == 0 {
+		return nil
+	}
+	return v.Errors
+}
+
+// Add adds a violation of the value on the given path
+func (v *Validator) Add(path, format string, args ...any) {
+	v.Errors = append(v.Errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Merge adds the violations returned by Validate method of a nested value on the given path
+func (v *Validator) Merge(path string, err error) {
+	if err == nil {
+		return
+	}
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		v.Add(path, "%s", err)
+		return
+	}
+	for _, e := range errs {
+		e.Path = path + strings.TrimPrefix(e.Path, "$")
+		v.Errors = append(v.Errors, e)
+	}
+}
+
+func (v *Validator) Required(path string, present bool) {
+	if !present {
+		v.Add(path, "required property is missing")
+	}
+}
+
+func (v *Validator) MinLength(path, s string, n int) {
+	if l := utf8.RuneCountInString(s); l < n {
+		v.Add(path, "length %d is less than %d", l, n)
+	}
+}
+
+func (v *Validator) MaxLength(path, s string, n int) {
+	if l := utf8.RuneCountInString(s); l > n {
+		v.Add(path, "length %d is greater than %d", l, n)
+	}
+}
+
+var patterns sync.Map // Compiled regexes by pattern
+
+func compiledPattern(pattern string) *regexp.Regexp {
+	re, ok := patterns.Load(pattern)
+	if !ok {
+		re, _ = patterns.LoadOrStore(pattern, regexp.MustCompile(pattern))
+	}
+	return re.(*regexp.Regexp)
+}
+
+func (v *Validator) Pattern(path, s, pattern string) {
+	if !compiledPattern(pattern).MatchString(s) {
+		v.Add(path, "value %q does not match pattern %q", s, pattern)
+	}
+}
+
+func (v *Validator) Minimum(path string, x, min float64, exclusive bool) {
+	switch {
+	case exclusive && x <= min:
+		v.Add(path, "value %v must be greater than %v", x, min)
+	case x < min:
+		v.Add(path, "value %v is less than %v", x, min)
+	}
+}
+
+func (v *Validator) Maximum(path string, x, max float64, exclusive bool) {
+	switch {
+	case exclusive && x >= max:
+		v.Add(path, "value %v must be less than %v", x, max)
+	case x > max:
+		v.Add(path, "value %v is greater than %v", x, max)
+	}
+}
+
+func (v *Validator) MultipleOf(path string, x, n float64) {
+	q := x / n
+	if math.Abs(q-math.Round(q)) > 1e-9 {
+		v.Add(path, "value %v is not a multiple of %v", x, n)
+	}
+}
+
+func (v *Validator) MinItems(path string, l, n int) {
+	if l < n {
+		v.Add(path, "%d items is less than %d", l, n)
+	}
+}
+
+func (v *Validator) MaxItems(path string, l, n int) {
+	if l > n {
+		v.Add(path, "%d items is greater than %d", l, n)
+	}
+}
+
+// UniqueItems checks that all items in a slice or array are different
+func (v *Validator) UniqueItems(path string, items any) {
+	val := reflect.ValueOf(items)
+	for i := 0; i < val.Len(); i++ {
+		for j := i + 1; j < val.Len(); j++ {
+			if reflect.DeepEqual(val.Index(i).Interface(), val.Index(j).Interface()) {
+				v.Add(fmt.Sprintf("%s[%d]", path, j), "item is equal to item %d, items must be unique", i)
+				return
+			}
+		}
+	}
+}
+
+func (v *Validator) MinProperties(path string, l, n int) {
+	if l < n {
+		v.Add(path, "%d properties is less than %d", l, n)
+	}
+}
+
+func (v *Validator) MaxProperties(path string, l, n int) {
+	if l > n {
+		v.Add(path, "%d properties is greater than %d", l, n)
+	}
+}
+
+// PropertiesCount returns the number of properties in a struct or map, the struct fields with zero value are
+// considered as absent
+func PropertiesCount(value any) int {
+	val := reflect.Indirect(reflect.ValueOf(value))
+	switch val.Kind() {
+	case reflect.Map:
+		return val.Len()
+	case reflect.Struct:
+		var res int
+		for i := 0; i < val.NumField(); i++ {
+			if val.Type().Field(i).IsExported() && !val.Field(i).IsZero() {
+				res++
+			}
+		}
+		return res
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package run
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(v *Validator)
+		want  []string
+	}{
+		{"length ok", func(v *Validator) { v.MinLength("$.a", "héllo", 5); v.MaxLength("$.a", "héllo", 5) }, nil},
+		{"min length", func(v *Validator) { v.MinLength("$.a", "ab", 3) }, []string{"$.a: length 2 is less than 3"}},
+		{"pattern", func(v *Validator) { v.Pattern("$.a", "x1", "^[a-z]+$") }, []string{`$.a: value "x1" does not match pattern "^[a-z]+$"`}},
+		{"minimum", func(v *Validator) { v.Minimum("$.a", 1, 1, false); v.Minimum("$.b", 1, 1, true) }, []string{"$.b: value 1 must be greater than 1"}},
+		{"maximum", func(v *Validator) { v.Maximum("$.a", 2, 1, false) }, []string{"$.a: value 2 is greater than 1"}},
+		{"multiple of", func(v *Validator) { v.MultipleOf("$.a", 0.3, 0.1); v.MultipleOf("$.b", 5, 2) }, []string{"$.b: value 5 is not a multiple of 2"}},
+		{"unique items", func(v *Validator) { v.UniqueItems("$.a", []int{1, 2, 1}) }, []string{"$.a[2]: item is equal to item 0, items must be unique"}},
+		{"required", func(v *Validator) { v.Required("$.a", false) }, []string{"$.a: required property is missing"}},
+		{
+			"merge",
+			func(v *Validator) {
+				v.Merge("$.a[0]", ValidationErrors{{Path: "$", Message: "m1"}, {Path: "$.b", Message: "m2"}})
+				v.Merge("$.c", errors.New("m3"))
+				v.Merge("$.d", nil)
+			},
+			[]string{"$.a[0]: m1", "$.a[0].b: m2", "$.c: m3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Validator
+			tt.check(&v)
+			if len(tt.want) == 0 {
+				if err := v.Err(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(v.Err(), &errs) || len(errs) != len(tt.want) {
+				t.Fatalf("expect %d errors, got %v", len(tt.want), v.Err())
+			}
+			for i, e := range errs {
+				if e.Error() != tt.want[i] {
+					t.Errorf("expect %q, got %q", tt.want[i], e.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestPropertiesCount(t *testing.T) {
+	s := "x"
+	tests := []struct {
+		name  string
+		value any
+		want  int
+	}{
+		{"map", map[string]int{"a": 1, "b": 0}, 2},
+		{"struct", struct {
+			A *string
+			B int
+			c int
+		}{A: &s, c: 1}, 1},
+		{"pointer", &struct{ A, B int }{1, 2}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PropertiesCount(tt.value); got != tt.want {
+				t.Errorf("expect %d, got %d", tt.want, got)
+			}
+		})
+	}
+}